@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Address kinds that a removed block's from attribute can target. Terraform
+// currently only accepts resources and modules; data sources and provider
+// configurations are recognized so that filters keep working if that ever
+// changes. Anything else well-formed is AddressKindUnknown and is reported
+// rather than guessed at.
+const (
+	AddressKindResource = "resource"
+	AddressKindModule   = "module"
+	AddressKindData     = "data"
+	AddressKindProvider = "provider"
+	AddressKindUnknown  = "unknown"
+)
+
+// Address is a parsed removed block target such as
+// module.network.aws_vpc.main or module.legacy.
+type Address struct {
+	Kind string
+	// ModulePath holds the module call names, outermost first.
+	ModulePath []string
+	// Type and Name are set for resource and data addresses. For provider
+	// addresses Type is the local name or quoted source address and Name is
+	// the alias, if any.
+	Type string
+	Name string
+}
+
+// parseAddress parses the from address of a removed block.
+func parseAddress(address string) (Address, error) {
+	if address == "" {
+		return Address{}, fmt.Errorf("empty address")
+	}
+
+	var result Address
+	rest := address
+	for strings.HasPrefix(rest, "module.") {
+		name, remainder, more := strings.Cut(strings.TrimPrefix(rest, "module."), ".")
+		if name == "" {
+			return Address{}, fmt.Errorf("invalid address %q: empty module name", address)
+		}
+		if more && remainder == "" {
+			return Address{}, fmt.Errorf("invalid address %q", address)
+		}
+		result.ModulePath = append(result.ModulePath, name)
+		rest = remainder
+	}
+
+	if rest == "" {
+		if len(result.ModulePath) == 0 {
+			return Address{}, fmt.Errorf("invalid address %q", address)
+		}
+		result.Kind = AddressKindModule
+		return result, nil
+	}
+
+	if rest == "provider" || strings.HasPrefix(rest, "provider.") || strings.HasPrefix(rest, "provider[") {
+		return parseProviderAddress(address, rest, result)
+	}
+
+	parts := strings.Split(rest, ".")
+	for _, part := range parts {
+		if part == "" {
+			return Address{}, fmt.Errorf("invalid address %q", address)
+		}
+	}
+
+	switch {
+	case parts[0] == "data":
+		if len(parts) != 3 {
+			return Address{}, fmt.Errorf("invalid address %q: data addresses need a type and a name", address)
+		}
+		result.Kind = AddressKindData
+		result.Type = parts[1]
+		result.Name = parts[2]
+	case len(parts) == 2:
+		result.Kind = AddressKindResource
+		result.Type = parts[0]
+		result.Name = parts[1]
+	case len(parts) > 2:
+		result.Kind = AddressKindUnknown
+	default:
+		return Address{}, fmt.Errorf("invalid address %q", address)
+	}
+
+	return result, nil
+}
+
+// parseProviderAddress parses the provider configuration address rest, in
+// the forms provider.aws, provider.aws.west, and
+// provider["registry.terraform.io/hashicorp/aws"].
+func parseProviderAddress(address, rest string, result Address) (Address, error) {
+	rest = strings.TrimPrefix(rest, "provider")
+
+	var alias string
+	switch {
+	case strings.HasPrefix(rest, "["):
+		end := strings.Index(rest, "]")
+		if end < 0 {
+			return Address{}, fmt.Errorf("invalid address %q: unterminated provider source", address)
+		}
+		source, err := strconv.Unquote(rest[1:end])
+		if err != nil || source == "" {
+			return Address{}, fmt.Errorf("invalid address %q: invalid provider source", address)
+		}
+		result.Type = source
+		rest = rest[end+1:]
+		if rest != "" {
+			if !strings.HasPrefix(rest, ".") {
+				return Address{}, fmt.Errorf("invalid address %q", address)
+			}
+			alias = rest[1:]
+			if alias == "" || strings.Contains(alias, ".") {
+				return Address{}, fmt.Errorf("invalid address %q", address)
+			}
+		}
+	case strings.HasPrefix(rest, "."):
+		parts := strings.Split(rest[1:], ".")
+		if len(parts) > 2 || parts[0] == "" || (len(parts) == 2 && parts[1] == "") {
+			return Address{}, fmt.Errorf("invalid address %q", address)
+		}
+		result.Type = parts[0]
+		if len(parts) == 2 {
+			alias = parts[1]
+		}
+	default:
+		return Address{}, fmt.Errorf("invalid address %q: missing provider name", address)
+	}
+
+	result.Kind = AddressKindProvider
+	result.Name = alias
+	return result, nil
+}
+
+// Provider returns the provider an address belongs to. For resources and
+// data sources that is the part of the type before the first underscore;
+// for provider configurations it is the local name, or the type of a source
+// address like registry.terraform.io/hashicorp/aws.
+func (a Address) Provider() string {
+	switch a.Kind {
+	case AddressKindResource, AddressKindData:
+		provider, _, _ := strings.Cut(a.Type, "_")
+		return provider
+	case AddressKindProvider:
+		return path.Base(a.Type)
+	}
+	return ""
+}
+
+// addressKind returns the kind of address, or AddressKindUnknown if it can't
+// be parsed.
+func addressKind(address string) string {
+	parsed, err := parseAddress(address)
+	if err != nil {
+		return AddressKindUnknown
+	}
+	return parsed.Kind
+}
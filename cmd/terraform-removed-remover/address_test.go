@@ -0,0 +1,73 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Address
+		wantErr  bool
+	}{
+		{input: "aws_instance.web", expected: Address{Kind: AddressKindResource, Type: "aws_instance", Name: "web"}},
+		{input: "module.network.aws_vpc.main", expected: Address{Kind: AddressKindResource, ModulePath: []string{"network"}, Type: "aws_vpc", Name: "main"}},
+		{input: "module.a.module.b", expected: Address{Kind: AddressKindModule, ModulePath: []string{"a", "b"}}},
+		{input: "module.legacy", expected: Address{Kind: AddressKindModule, ModulePath: []string{"legacy"}}},
+		{input: "data.aws_ami.ubuntu", expected: Address{Kind: AddressKindData, Type: "aws_ami", Name: "ubuntu"}},
+		{input: "module.x.data.aws_ami.ubuntu", expected: Address{Kind: AddressKindData, ModulePath: []string{"x"}, Type: "aws_ami", Name: "ubuntu"}},
+		{input: "provider.aws", expected: Address{Kind: AddressKindProvider, Type: "aws"}},
+		{input: "provider.aws.west", expected: Address{Kind: AddressKindProvider, Type: "aws", Name: "west"}},
+		{input: `module.x.provider["registry.terraform.io/hashicorp/aws"].west`, expected: Address{Kind: AddressKindProvider, ModulePath: []string{"x"}, Type: "registry.terraform.io/hashicorp/aws", Name: "west"}},
+		{input: "ephemeral.aws_secret.token", expected: Address{Kind: AddressKindUnknown}},
+		{input: "aws_instance.web.extra", expected: Address{Kind: AddressKindUnknown}},
+		{input: "", wantErr: true},
+		{input: "aws_instance", wantErr: true},
+		{input: "module.", wantErr: true},
+		{input: "module.a.", wantErr: true},
+		{input: "aws_instance..web", wantErr: true},
+		{input: "data.aws_ami", wantErr: true},
+		{input: "provider", wantErr: true},
+		{input: `provider["registry.terraform.io/hashicorp/aws"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseAddress(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseAddress(%q) expected error, got %+v", tt.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseAddress(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("parseAddress(%q) = %+v, expected %+v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestAddressProvider(t *testing.T) {
+	tests := map[string]string{
+		"aws_instance.web":             "aws",
+		"module.x.google_sql_db.main":  "google",
+		"random.id":                    "random",
+		"data.azurerm_client_config.x": "azurerm",
+		"provider.aws.west":            "aws",
+		`provider["registry.terraform.io/hashicorp/google"]`: "google",
+		"module.legacy": "",
+	}
+
+	for input, expected := range tests {
+		address, err := parseAddress(input)
+		if err != nil {
+			t.Fatalf("parseAddress(%q) returned error: %v", input, err)
+		}
+		if got := address.Provider(); got != expected {
+			t.Errorf("Provider() for %q = %q, expected %q", input, got, expected)
+		}
+	}
+}
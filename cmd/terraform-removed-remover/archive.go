@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// openArchive opens the -archive file at path for appending, so it keeps
+// the deleted blocks of every run.
+func openArchive(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening archive: %w", err)
+	}
+	return f, nil
+}
+
+// archiveDeletedBlocks appends the source of each of blocks, deleted from
+// filePath, to stats.Archive below a comment naming the file, line, and
+// date. content is the source the block offsets refer to. Blocks from JSON
+// files are archived as their JSON text.
+func archiveDeletedBlocks(filePath string, blocks []removedBlock, content []byte, stats *Stats) error {
+	if stats.Archive == nil {
+		return nil
+	}
+	date := ""
+	if !stats.Pure {
+		date = " on " + time.Now().Format(time.DateOnly)
+	}
+	for _, block := range blocks {
+		if _, err := fmt.Fprintf(stats.Archive, "# Archived from %s:%d%s\n%s\n\n", filePath, block.Line, date, content[block.start:block.end]); err != nil {
+			return fmt.Errorf("error writing archive: %w", err)
+		}
+	}
+	return nil
+}
+
+// isArchive reports whether file is the -archive file at path, whose blocks
+// must not be removed again when it is inside a scanned directory.
+func isArchive(path, file string) bool {
+	archive, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(archive, info)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProcessFileArchivesDeletedBlocks(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-archive-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	archivePath := filepath.Join(tempDir, "removed-archive.tf")
+	for i, content := range []string{
+		"removed {\n  from = aws_instance.old\n}\n\nresource \"aws_instance\" \"web\" {}\n",
+		"removed {\n  from = aws_s3_bucket.logs\n}\n",
+	} {
+		testFile := filepath.Join(tempDir, "main.tf")
+		if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+		archive, err := openArchive(archivePath)
+		if err != nil {
+			t.Fatalf("openArchive failed: %v", err)
+		}
+		err = processFile(testFile, &Stats{Archive: archive, Pure: i == 0})
+		_ = archive.Close()
+		if err != nil {
+			t.Fatalf("processFile failed: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(archivePath)
+	if err != nil {
+		t.Fatalf("Failed to read archive: %v", err)
+	}
+	testFile := filepath.Join(tempDir, "main.tf")
+	expected := "# Archived from " + testFile + ":1\nremoved {\n  from = aws_instance.old\n}\n\n" +
+		"# Archived from " + testFile + ":1 on " + time.Now().Format(time.DateOnly) + "\nremoved {\n  from = aws_s3_bucket.logs\n}\n\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q, but got %q", expected, string(data))
+	}
+
+	if !isArchive(archivePath, archivePath) || isArchive(archivePath, testFile) {
+		t.Errorf("Expected only the archive itself to be excluded from discovery")
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// AuditRecord is a line of the -audit-log: one removed block deleted from a
+// file, with its source text as it was before the deletion.
+type AuditRecord struct {
+	// Timestamp is when the file was rewritten. It is omitted with -pure.
+	Timestamp time.Time `json:"timestamp,omitzero"`
+	File      string    `json:"file"`
+	Line      int       `json:"line"`
+	Address   string    `json:"address"`
+	// Destroy is the block's lifecycle destroy setting: whether Terraform
+	// was told to destroy the object rather than forget it.
+	Destroy bool   `json:"destroy"`
+	Source  string `json:"source"`
+}
+
+// openAuditLog opens the -audit-log at path for appending. Records are
+// never rewritten, so the log holds every deletion of every run.
+func openAuditLog(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening audit log: %w", err)
+	}
+	return f, nil
+}
+
+// auditDeletedBlocks appends a record for each of blocks, deleted from
+// filePath, to stats.AuditLog as a line of JSON. content is the source the
+// block offsets refer to. It is called once the file has been written, so
+// the log never records a deletion that did not happen.
+func auditDeletedBlocks(filePath string, blocks []removedBlock, content []byte, stats *Stats) error {
+	if stats.AuditLog == nil {
+		return nil
+	}
+	var now time.Time
+	if !stats.Pure {
+		now = time.Now().UTC()
+	}
+	encoder := json.NewEncoder(stats.AuditLog)
+	encoder.SetEscapeHTML(false)
+	for _, block := range blocks {
+		record := AuditRecord{
+			Timestamp: now,
+			File:      filePath,
+			Line:      block.Line,
+			Address:   block.Address,
+			Destroy:   block.Destroy,
+			Source:    string(content[block.start:block.end]),
+		}
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("error writing audit log: %w", err)
+		}
+	}
+	return nil
+}
+
+// blockDestroys reports whether a removed block destroys its object: true
+// unless its lifecycle block sets destroy to false. Terraform only accepts a
+// literal there, so anything else is taken as the default.
+func blockDestroys(block *hclsyntax.Block) bool {
+	for _, nested := range block.Body.Blocks {
+		if nested.Type != "lifecycle" {
+			continue
+		}
+		attr, ok := nested.Body.Attributes["destroy"]
+		if !ok {
+			continue
+		}
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() || !value.IsKnown() || value.IsNull() || value.Type() != cty.Bool {
+			continue
+		}
+		return value.True()
+	}
+	return true
+}
+
+// jsonBlockDestroys is blockDestroys for a removed block object in JSON
+// syntax, whose lifecycle is an object or an array of objects.
+func jsonBlockDestroys(object []byte) bool {
+	var block struct {
+		Lifecycle json.RawMessage `json:"lifecycle"`
+	}
+	if err := json.Unmarshal(object, &block); err != nil || block.Lifecycle == nil {
+		return true
+	}
+	type lifecycle struct {
+		Destroy *bool `json:"destroy"`
+	}
+	var lifecycles []lifecycle
+	if err := json.Unmarshal(block.Lifecycle, &lifecycles); err != nil {
+		var single lifecycle
+		if err := json.Unmarshal(block.Lifecycle, &single); err != nil {
+			return true
+		}
+		lifecycles = []lifecycle{single}
+	}
+	for _, l := range lifecycles {
+		if l.Destroy != nil {
+			return *l.Destroy
+		}
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProcessFileAuditLog(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-audit-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := `removed {
+  from = aws_instance.old
+}
+
+removed {
+  from = aws_s3_bucket.logs
+  lifecycle {
+    destroy = false
+  }
+}
+
+resource "aws_instance" "web" {}
+`
+	testFile := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	var auditLog bytes.Buffer
+	if err := processFile(testFile, &Stats{DryRun: true, AuditLog: &auditLog}); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	if auditLog.Len() != 0 {
+		t.Errorf("Expected a dry run to record nothing, but got %q", auditLog.String())
+	}
+
+	if err := processFile(testFile, &Stats{AuditLog: &auditLog, Pure: true}); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(auditLog.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 records, but got %q", auditLog.String())
+	}
+	expected := []AuditRecord{
+		{File: testFile, Line: 1, Address: "aws_instance.old", Destroy: true, Source: "removed {\n  from = aws_instance.old\n}"},
+		{File: testFile, Line: 5, Address: "aws_s3_bucket.logs", Destroy: false, Source: "removed {\n  from = aws_s3_bucket.logs\n  lifecycle {\n    destroy = false\n  }\n}"},
+	}
+	for i, line := range lines {
+		var record AuditRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Expected a JSON record, but got %q: %v", line, err)
+		}
+		if record != expected[i] {
+			t.Errorf("Expected %+v, but got %+v", expected[i], record)
+		}
+	}
+	if strings.Contains(auditLog.String(), "timestamp") {
+		t.Errorf("Expected no timestamp with -pure, but got %q", auditLog.String())
+	}
+}
+
+func TestJSONBlockDestroys(t *testing.T) {
+	for _, tt := range []struct {
+		object   string
+		expected bool
+	}{
+		{object: `{"from": "aws_instance.old"}`, expected: true},
+		{object: `{"from": "aws_instance.old", "lifecycle": {"destroy": false}}`, expected: false},
+		{object: `{"from": "aws_instance.old", "lifecycle": [{"destroy": false}]}`, expected: false},
+		{object: `{"from": "aws_instance.old", "lifecycle": {"destroy": true}}`, expected: true},
+	} {
+		if actual := jsonBlockDestroys([]byte(tt.object)); actual != tt.expected {
+			t.Errorf("jsonBlockDestroys(%s) = %v, expected %v", tt.object, actual, tt.expected)
+		}
+	}
+}
+
+func TestOpenAuditLogAppends(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-audit-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	logPath := filepath.Join(tempDir, "removals.json")
+	for _, address := range []string{"aws_instance.a", "aws_instance.b"} {
+		auditLog, err := openAuditLog(logPath)
+		if err != nil {
+			t.Fatalf("openAuditLog failed: %v", err)
+		}
+		stats := &Stats{AuditLog: auditLog, Pure: true}
+		block := removedBlock{Address: address, Line: 1, Destroy: true, end: 1}
+		if err := auditDeletedBlocks("main.tf", []removedBlock{block}, []byte("r"), stats); err != nil {
+			t.Fatalf("auditDeletedBlocks failed: %v", err)
+		}
+		_ = auditLog.Close()
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	expected := `{"file":"main.tf","line":1,"address":"aws_instance.a","destroy":true,"source":"r"}` + "\n" +
+		`{"file":"main.tf","line":1,"address":"aws_instance.b","destroy":true,"source":"r"}` + "\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q, but got %q", expected, string(data))
+	}
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// backendKeyAttributes maps backend types to the attribute naming the state
+// within the backend. Backends not listed use "key".
+var backendKeyAttributes = map[string]string{
+	"gcs":    "prefix",
+	"consul": "path",
+	"local":  "path",
+	"http":   "address",
+}
+
+// moduleConfig is what -state-key needs to know about a directory: the type
+// and state key of its backend, if it is a root module, and the local
+// modules it calls. StateKeyItem is the -backend-config item that set the
+// state key, if one did.
+type moduleConfig struct {
+	Backend      string
+	StateKey     string
+	StateKeyItem string
+	Modules      []string
+}
+
+// loadModuleConfig reads the backend state key and local module calls from
+// the Terraform files directly in dir. Only literal strings are understood.
+// backendConfig overrides the backend block as terraform init's
+// -backend-config does, which also resolves keys left out of partial
+// configurations. Files that can't be parsed are reported as parse errors.
+func loadModuleConfig(dir string, backendConfig []string, opts DiscoveryOptions) (*moduleConfig, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &moduleConfig{}
+	for _, entry := range entries {
+		// Backends and module calls in JSON syntax are not followed.
+		if entry.IsDir() || !opts.hasExtension(entry.Name()) || isJSONConfig(entry.Name()) || isIgnoredByTerraform(dir, filepath.Join(dir, entry.Name())) {
+			continue
+		}
+
+		filePath := filepath.Join(dir, entry.Name())
+		raw, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		content, _, err := decodeContent(raw)
+		if err != nil {
+			return nil, &parseError{fmt.Errorf("error decoding %s: %w", filePath, err)}
+		}
+		syntaxFile, diags := hclsyntax.ParseConfig(content, filePath, hcl.Pos{Line: 1, Column: 1})
+		if diags.HasErrors() {
+			return nil, &parseError{fmt.Errorf("error parsing %s: %s", filePath, diags.Error())}
+		}
+		body, ok := syntaxFile.Body.(*hclsyntax.Body)
+		if !ok {
+			continue
+		}
+
+		for _, block := range body.Blocks {
+			switch block.Type {
+			case "terraform":
+				for _, backend := range block.Body.Blocks {
+					if backend.Type != "backend" || len(backend.Labels) != 1 {
+						continue
+					}
+					config.Backend = backend.Labels[0]
+					attribute := backendKeyAttributes[config.Backend]
+					if attribute == "" {
+						attribute = "key"
+					}
+					key, _ := literalString(backend.Body, attribute)
+					if config.StateKey, config.StateKeyItem, err = applyBackendConfig(dir, attribute, key, backendConfig); err != nil {
+						return nil, err
+					}
+				}
+			case "module":
+				if source, ok := literalString(block.Body, "source"); ok && (strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../")) {
+					config.Modules = append(config.Modules, filepath.Join(dir, filepath.FromSlash(source)))
+				}
+			}
+		}
+	}
+	return config, nil
+}
+
+// applyBackendConfig returns the value of attribute after the -backend-config
+// items, in order, override key, and the item that set it last. Like terraform init, an item containing "="
+// sets a single attribute and any other item names a file of attributes. An
+// item prefixed with a directory and a colon, as in envs/prod:key=app.tfstate,
+// applies only to the root module in that directory, as if terraform init
+// ran there with it. Relative files are looked up in dir and are skipped in
+// directories that don't have them.
+func applyBackendConfig(dir, attribute, key string, items []string) (string, string, error) {
+	var from string
+	for _, item := range items {
+		scope, setting := splitBackendConfigScope(item)
+		if scope != "" && !sameDir(scope, dir) {
+			continue
+		}
+		if name, value, ok := strings.Cut(setting, "="); ok {
+			if strings.TrimSpace(name) == attribute {
+				key, from = value, item
+			}
+			continue
+		}
+
+		filePath := setting
+		if !filepath.IsAbs(filePath) {
+			filePath = filepath.Join(dir, filePath)
+		}
+		content, err := os.ReadFile(filePath)
+		if os.IsNotExist(err) && !filepath.IsAbs(setting) {
+			continue
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("error reading -backend-config file: %w", err)
+		}
+		syntaxFile, diags := hclsyntax.ParseConfig(content, filePath, hcl.Pos{Line: 1, Column: 1})
+		if diags.HasErrors() {
+			return "", "", fmt.Errorf("error parsing -backend-config file %s: %s", filePath, diags.Error())
+		}
+		if body, ok := syntaxFile.Body.(*hclsyntax.Body); ok {
+			if value, ok := literalString(body, attribute); ok {
+				key, from = value, item
+			}
+		}
+	}
+	return key, from, nil
+}
+
+// splitBackendConfigScope splits the directory prefix off a -backend-config
+// item. Drive letters and the colons in values, like the URL of an http
+// backend's address, are not prefixes.
+func splitBackendConfigScope(item string) (string, string) {
+	if filepath.VolumeName(item) != "" {
+		return "", item
+	}
+	scope, rest, ok := strings.Cut(item, ":")
+	if !ok || scope == "" || strings.Contains(scope, "=") {
+		return "", item
+	}
+	return scope, rest
+}
+
+// printBackends writes, for doctor, the backend and state key of each root
+// module among the directories of files, with the -backend-config items
+// applied.
+func printBackends(w io.Writer, files, backendConfig []string, opts DiscoveryOptions) {
+	seen := make(map[string]bool)
+	for _, file := range files {
+		dir := filepath.Dir(file)
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+
+		config, err := loadModuleConfig(dir, backendConfig, opts)
+		switch {
+		case err != nil:
+			fmt.Fprintf(w, "%s: backend cannot be read: %s\n", dir, err)
+		case config.Backend == "":
+		case config.StateKey == "":
+			fmt.Fprintf(w, "%s: %s backend, no state key (complete the partial configuration with -backend-config)\n", dir, config.Backend)
+		default:
+			fmt.Fprintf(w, "%s: %s backend, state key %s\n", dir, config.Backend, config.StateKey)
+		}
+	}
+}
+
+// literalString returns the value of a string attribute that doesn't
+// reference anything.
+func literalString(body *hclsyntax.Body, name string) (string, bool) {
+	attribute, ok := body.Attributes[name]
+	if !ok {
+		return "", false
+	}
+	value, diags := attribute.Expr.Value(nil)
+	if diags.HasErrors() || value.Type() != cty.String || value.IsNull() {
+		return "", false
+	}
+	return value.AsString(), true
+}
+
+// stateKeyDirs returns the directories, among dirs and the local modules
+// they call, that are reachable from a root module whose backend state key
+// matches one of the patterns. A directory whose files can't be parsed is
+// skipped as a root module, and its module calls aren't followed, with a
+// warning in stats.
+func stateKeyDirs(dirs []string, patterns, backendConfig []string, opts DiscoveryOptions, stats *Stats) (map[string]bool, error) {
+	configs := make(map[string]*moduleConfig)
+	load := func(dir string) (*moduleConfig, error) {
+		if config, ok := configs[dir]; ok {
+			return config, nil
+		}
+		config, err := loadModuleConfig(dir, backendConfig, opts)
+		var parseErr *parseError
+		if errors.As(err, &parseErr) {
+			stats.Warnings = append(stats.Warnings, fmt.Sprintf("%s: ignoring its backend and module calls for -state-key: %s", dir, err))
+			config, err = &moduleConfig{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		configs[dir] = config
+		return config, nil
+	}
+
+	reachable := make(map[string]bool)
+	shared := make(map[string][]string)
+	var queue []string
+	for _, dir := range dirs {
+		dir = filepath.Clean(dir)
+		config, err := load(dir)
+		if err != nil {
+			return nil, err
+		}
+		if scope, setting := splitBackendConfigScope(config.StateKeyItem); scope == "" && strings.Contains(setting, "=") {
+			shared[config.StateKeyItem] = append(shared[config.StateKeyItem], dir)
+		}
+		if config.StateKey != "" && matchesAnyStateKey(patterns, config.StateKey) && !reachable[dir] {
+			reachable[dir] = true
+			queue = append(queue, dir)
+		}
+	}
+	// A pair without a directory would give every root module the same
+	// state, which terraform init, run in one root module, never does.
+	for _, item := range backendConfig {
+		if roots := shared[item]; len(roots) > 1 {
+			return nil, fmt.Errorf("-backend-config %s sets the state key of %d root modules; prefix it with the directory of the one it is for, e.g. %s:%s", item, len(roots), roots[0], item)
+		}
+	}
+
+	for len(queue) > 0 {
+		dir := queue[0]
+		queue = queue[1:]
+		for _, module := range configs[dir].Modules {
+			module = filepath.Clean(module)
+			if reachable[module] {
+				continue
+			}
+			if _, err := load(module); err != nil {
+				return nil, fmt.Errorf("error reading module %s called from %s: %w", module, dir, err)
+			}
+			reachable[module] = true
+			queue = append(queue, module)
+		}
+	}
+	return reachable, nil
+}
+
+func matchesAnyStateKey(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, key); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// filterStateKeys keeps the files in directories reachable from the given
+// state keys, as resolved with the -backend-config items. Warnings about
+// directories that were skipped go to stats.
+func filterStateKeys(discovery *Discovery, patterns, backendConfig []string, opts DiscoveryOptions, stats *Stats) (*Discovery, error) {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, file := range append(append([]string{}, discovery.Files...), discovery.Ignored...) {
+		dir := absDir(file)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	reachable, err := stateKeyDirs(dirs, patterns, backendConfig, opts, stats)
+	if err != nil {
+		return nil, err
+	}
+	return discovery.exclude(func(file string) bool {
+		return !reachable[absDir(file)]
+	}), nil
+}
+
+// absDir returns the absolute directory containing file, so that paths
+// reached through module sources compare equal to discovered ones.
+func absDir(file string) string {
+	dir, err := filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return filepath.Clean(filepath.Dir(file))
+	}
+	return dir
+}
+
+// sameDir reports whether a and b name the same directory.
+func sameDir(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
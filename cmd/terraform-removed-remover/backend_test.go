@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFilterStateKeys(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-state-key-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	files := map[string]string{
+		"envs/prod/networking/main.tf": `
+terraform {
+  backend "s3" {
+    bucket = "state"
+    key    = "prod/networking.tfstate"
+  }
+}
+
+module "vpc" {
+  source = "../../../modules/vpc"
+}
+`,
+		"envs/staging/networking/main.tf": `
+terraform {
+  backend "s3" {
+    key = "staging/networking.tfstate"
+  }
+}
+
+module "vpc" {
+  source = "../../../modules/vpc"
+}
+
+module "extra" {
+  source = "../../../modules/extra"
+}
+`,
+		"envs/prod/gcs/main.tf": `
+terraform {
+  backend "gcs" {
+    prefix = "prod/gcs"
+  }
+}
+`,
+		"modules/vpc/main.tf": `
+module "subnets" {
+  source = "./subnets"
+}
+
+module "registry" {
+  source = "terraform-aws-modules/vpc/aws"
+}
+`,
+		"modules/vpc/subnets/main.tf": "",
+		"modules/extra/main.tf":       "",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if mkdirErr := os.MkdirAll(filepath.Dir(path), 0750); mkdirErr != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, mkdirErr)
+		}
+		if writeErr := os.WriteFile(path, []byte(content), 0600); writeErr != nil {
+			t.Fatalf("Failed to write file %s: %v", name, writeErr)
+		}
+	}
+
+	discovery, err := discoverFiles(tempDir, DiscoveryOptions{})
+	if err != nil {
+		t.Fatalf("discoverFiles failed: %v", err)
+	}
+
+	tests := []struct {
+		patterns []string
+		expected []string
+	}{
+		{
+			patterns: []string{"prod/networking.tfstate"},
+			expected: []string{"envs/prod/networking/main.tf", "modules/vpc/main.tf", "modules/vpc/subnets/main.tf"},
+		},
+		{
+			patterns: []string{"prod/*"},
+			expected: []string{"envs/prod/gcs/main.tf", "envs/prod/networking/main.tf", "modules/vpc/main.tf", "modules/vpc/subnets/main.tf"},
+		},
+		{
+			patterns: []string{"dev/*"},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		filtered, err := filterStateKeys(discovery, tt.patterns, nil, DiscoveryOptions{}, &Stats{})
+		if err != nil {
+			t.Fatalf("filterStateKeys failed: %v", err)
+		}
+
+		var got []string
+		for _, file := range filtered.Files {
+			rel, _ := filepath.Rel(tempDir, file)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		if !slices.Equal(got, tt.expected) {
+			t.Errorf("With %v, expected %v, but got %v", tt.patterns, tt.expected, got)
+		}
+	}
+}
+
+func TestFilterStateKeysBackendConfig(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-backend-config-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	files := map[string]string{
+		// A partial configuration completed by backend.hcl at init time.
+		"envs/prod/main.tf": `
+terraform {
+  backend "s3" {}
+}
+`,
+		"envs/prod/backend.hcl": `key = "prod/app.tfstate"`,
+		"envs/dev/main.tf": `
+terraform {
+  backend "s3" {
+    key = "dev/app.tfstate"
+  }
+}
+`,
+		"envs/broken/main.tf": `terraform {`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if mkdirErr := os.MkdirAll(filepath.Dir(path), 0750); mkdirErr != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, mkdirErr)
+		}
+		if writeErr := os.WriteFile(path, []byte(content), 0600); writeErr != nil {
+			t.Fatalf("Failed to write file %s: %v", name, writeErr)
+		}
+	}
+
+	discovery, err := discoverFiles(tempDir, DiscoveryOptions{})
+	if err != nil {
+		t.Fatalf("discoverFiles failed: %v", err)
+	}
+	prod := filepath.Join(tempDir, "envs", "prod")
+
+	tests := []struct {
+		patterns      []string
+		backendConfig []string
+		expected      []string
+	}{
+		{
+			patterns:      []string{"*/app.tfstate"},
+			backendConfig: []string{"backend.hcl"},
+			expected:      []string{"envs/dev/main.tf", "envs/prod/main.tf"},
+		},
+		{
+			patterns:      []string{"prod/*"},
+			backendConfig: []string{prod + ":key=prod/app.tfstate"},
+			expected:      []string{"envs/prod/main.tf"},
+		},
+	}
+
+	for _, tt := range tests {
+		stats := &Stats{}
+		filtered, err := filterStateKeys(discovery, tt.patterns, tt.backendConfig, DiscoveryOptions{}, stats)
+		if err != nil {
+			t.Fatalf("filterStateKeys failed: %v", err)
+		}
+
+		var got []string
+		for _, file := range filtered.Files {
+			rel, _ := filepath.Rel(tempDir, file)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		if !slices.Equal(got, tt.expected) {
+			t.Errorf("With %v and %v, expected %v, but got %v", tt.patterns, tt.backendConfig, tt.expected, got)
+		}
+		// The unparsable root module is skipped rather than failing the run.
+		if len(stats.Warnings) != 1 || !strings.Contains(stats.Warnings[0], filepath.Join("envs", "broken")) {
+			t.Errorf("Expected a warning about envs/broken, but got %v", stats.Warnings)
+		}
+	}
+
+	// terraform init runs in one root module, so a pair without a directory
+	// can't give several root modules their state key.
+	_, err = filterStateKeys(discovery, []string{"shared.tfstate"}, []string{"bucket=state", "key=shared.tfstate"}, DiscoveryOptions{}, &Stats{})
+	if err == nil || !strings.Contains(err.Error(), "prefix it with the directory") {
+		t.Errorf("Expected an error asking for a directory prefix, but got %v", err)
+	}
+}
+
+func TestApplyBackendConfig(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-backend-config-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	prod := filepath.Join(tempDir, "envs", "prod")
+	dev := filepath.Join(tempDir, "envs", "dev")
+	for _, dir := range []string{prod, dev} {
+		if mkdirErr := os.MkdirAll(dir, 0750); mkdirErr != nil {
+			t.Fatalf("Failed to create directory %s: %v", dir, mkdirErr)
+		}
+	}
+	if writeErr := os.WriteFile(filepath.Join(prod, "backend.hcl"), []byte(`key = "prod/app.tfstate"`), 0600); writeErr != nil {
+		t.Fatalf("Failed to write backend.hcl: %v", writeErr)
+	}
+
+	tests := []struct {
+		name      string
+		dir       string
+		attribute string
+		key       string
+		items     []string
+		expected  string
+	}{
+		{name: "file completes a partial configuration", dir: prod, items: []string{"backend.hcl"}, expected: "prod/app.tfstate"},
+		{name: "missing relative file is skipped", dir: dev, key: "dev/app.tfstate", items: []string{"backend.hcl"}, expected: "dev/app.tfstate"},
+		{name: "pairs", dir: prod, items: []string{"bucket=state", "key=shared.tfstate"}, expected: "shared.tfstate"},
+		{name: "later items win", dir: prod, items: []string{"backend.hcl", "key=other.tfstate"}, expected: "other.tfstate"},
+		{name: "scoped to the root", dir: prod, items: []string{prod + ":key=scoped.tfstate"}, expected: "scoped.tfstate"},
+		{name: "scoped to another root", dir: dev, key: "dev/app.tfstate", items: []string{prod + ":key=scoped.tfstate"}, expected: "dev/app.tfstate"},
+		{name: "colon in a value", dir: prod, attribute: "address", items: []string{"address=https://state.example.com/prod"}, expected: "https://state.example.com/prod"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attribute := tt.attribute
+			if attribute == "" {
+				attribute = "key"
+			}
+			got, _, err := applyBackendConfig(tt.dir, attribute, tt.key, tt.items)
+			if err != nil {
+				t.Fatalf("applyBackendConfig failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, but got %q", tt.expected, got)
+			}
+		})
+	}
+
+	if _, _, err := applyBackendConfig(prod, "key", "", []string{filepath.Join(tempDir, "missing.hcl")}); err == nil {
+		t.Errorf("Expected an error for a missing absolute -backend-config file, but got nil")
+	}
+}
+
+func TestPrintBackends(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-print-backends-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	files := map[string]string{
+		"envs/dev/main.tf": `
+terraform {
+  backend "s3" {
+    key = "dev/app.tfstate"
+  }
+}
+`,
+		"envs/prod/main.tf": `
+terraform {
+  backend "s3" {}
+}
+`,
+		"modules/vpc/main.tf": `resource "aws_vpc" "main" {}`,
+	}
+	var paths []string
+	for _, name := range []string{"envs/dev/main.tf", "envs/prod/main.tf", "modules/vpc/main.tf"} {
+		path := filepath.Join(tempDir, name)
+		if mkdirErr := os.MkdirAll(filepath.Dir(path), 0750); mkdirErr != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, mkdirErr)
+		}
+		if writeErr := os.WriteFile(path, []byte(files[name]), 0600); writeErr != nil {
+			t.Fatalf("Failed to write file %s: %v", name, writeErr)
+		}
+		paths = append(paths, path)
+	}
+
+	var out bytes.Buffer
+	printBackends(&out, paths, nil, DiscoveryOptions{})
+	expected := filepath.Join(tempDir, "envs", "dev") + ": s3 backend, state key dev/app.tfstate\n" +
+		filepath.Join(tempDir, "envs", "prod") + ": s3 backend, no state key (complete the partial configuration with -backend-config)\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, but got %q", expected, out.String())
+	}
+
+	out.Reset()
+	printBackends(&out, paths[1:2], []string{"key=prod/app.tfstate"}, DiscoveryOptions{})
+	if expected := filepath.Join(tempDir, "envs", "prod") + ": s3 backend, state key prod/app.tfstate\n"; out.String() != expected {
+		t.Errorf("Expected %q, but got %q", expected, out.String())
+	}
+}
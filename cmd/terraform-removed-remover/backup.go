@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultBackupSuffix is what -backup given without a value appends to the
+// name of each backup.
+const defaultBackupSuffix = ".bak"
+
+// backupManifestFile records the backups of the most recent run that made
+// any, in the working directory, for the undo subcommand.
+const backupManifestFile = ".removed-remover-backup.json"
+
+// backupManifest is the backup set of a run: every file it rewrote and
+// where its original was kept. Paths are absolute, so undo can run from
+// anywhere the manifest is found.
+type backupManifest struct {
+	ToolVersion string        `json:"tool_version"`
+	Created     time.Time     `json:"created"`
+	Files       []backupEntry `json:"files"`
+}
+
+// backupEntry is one file of a backup set.
+type backupEntry struct {
+	File   string `json:"file"`
+	Backup string `json:"backup"`
+}
+
+// backupFlag is the value of -backup: empty for no backups, a suffix such
+// as .bak for backups next to each file, or a directory. It may be given
+// without a value, like a boolean flag.
+type backupFlag string
+
+func (f *backupFlag) String() string {
+	return string(*f)
+}
+
+func (f *backupFlag) Set(value string) error {
+	switch value {
+	case "true":
+		value = defaultBackupSuffix
+	case "false":
+		value = ""
+	}
+	*f = backupFlag(value)
+	return nil
+}
+
+func (f *backupFlag) IsBoolFlag() bool {
+	return true
+}
+
+// isBackupSuffix reports whether the -backup value spec is a suffix, like
+// .bak or .orig, rather than a directory. A hidden directory can be given as
+// ./.backups.
+func isBackupSuffix(spec string) bool {
+	return strings.HasPrefix(spec, ".") && spec != "." && spec != ".." && !strings.ContainsAny(spec, `/\`)
+}
+
+// backupPath returns where -backup spec keeps the original of filePath:
+// next to it with the suffix added, or below the backup directory at the
+// file's path relative to the working directory. Files outside the working
+// directory keep their absolute path below the backup directory.
+func backupPath(spec, filePath string) (string, error) {
+	if isBackupSuffix(spec) {
+		return filePath + spec, nil
+	}
+
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(wd, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		rel = strings.TrimLeft(abs[len(filepath.VolumeName(abs)):], `/\`)
+	}
+	return filepath.Join(spec, rel), nil
+}
+
+// backupFile copies the current content of filePath to its backup before it
+// is rewritten, replacing an older backup of the same file, and adds it to
+// the run's backup set.
+func backupFile(spec, filePath string, stats *Stats) error {
+	original, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("error backing up %s: %w", filePath, err)
+	}
+	backup, err := backupPath(spec, filePath)
+	if err != nil {
+		return fmt.Errorf("error backing up %s: %w", filePath, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(backup), 0750); err != nil {
+		return fmt.Errorf("error backing up %s: %w", filePath, err)
+	}
+	if err := writeFileAtomic(backup, original); err != nil {
+		return fmt.Errorf("error backing up %s: %w", filePath, err)
+	}
+
+	entry := backupEntry{File: filePath, Backup: backup}
+	if entry.File, err = filepath.Abs(filePath); err != nil {
+		return fmt.Errorf("error backing up %s: %w", filePath, err)
+	}
+	if entry.Backup, err = filepath.Abs(backup); err != nil {
+		return fmt.Errorf("error backing up %s: %w", filePath, err)
+	}
+	stats.Backups = append(stats.Backups, entry)
+	return nil
+}
+
+// writeBackupManifest records entries as the most recent backup set,
+// replacing the previous one: later runs overwrite the backups it names.
+func writeBackupManifest(path string, entries []backupEntry, created time.Time) error {
+	data, err := json.MarshalIndent(backupManifest{ToolVersion: Version, Created: created, Files: entries}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing backup manifest: %w", err)
+	}
+	return nil
+}
+
+// runUndo restores every file of the backup set recorded at manifestPath
+// and removes the manifest, listing the files on w. Every backup is read
+// before any file is written, so a missing backup restores nothing. With
+// dryRun the files are only listed.
+func runUndo(manifestPath string, dryRun bool, w io.Writer) (int, error) {
+	data, err := os.ReadFile(manifestPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, fmt.Errorf("nothing to undo: no backup manifest at %s (runs record one with -backup)", manifestPath)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error reading backup manifest: %w", err)
+	}
+	var manifest backupManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return 0, fmt.Errorf("error reading backup manifest %s: %w", manifestPath, err)
+	}
+
+	originals := make([][]byte, len(manifest.Files))
+	for i, entry := range manifest.Files {
+		if originals[i], err = os.ReadFile(entry.Backup); err != nil {
+			return 0, fmt.Errorf("error reading backup of %s: %w", entry.File, err)
+		}
+	}
+
+	for i, entry := range manifest.Files {
+		fmt.Fprintf(w, "Restoring %s from %s\n", entry.File, entry.Backup)
+		if dryRun {
+			continue
+		}
+		if err := writeFileAtomic(entry.File, originals[i]); err != nil {
+			return i, writeError(fmt.Errorf("error restoring %s: %w", entry.File, err))
+		}
+	}
+	if dryRun {
+		return len(manifest.Files), nil
+	}
+	if err := os.Remove(manifestPath); err != nil {
+		return len(manifest.Files), fmt.Errorf("error removing backup manifest: %w", err)
+	}
+	return len(manifest.Files), nil
+}
+
+// inBackupDir reports whether file lies in the -backup directory spec, whose
+// copies must not be processed like the originals when it is inside a
+// scanned directory.
+func inBackupDir(spec, file string) bool {
+	if spec == "" || isBackupSuffix(spec) {
+		return false
+	}
+	dir, err := filepath.Abs(spec)
+	if err != nil {
+		return false
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return false
+	}
+	return isWithin(dir, abs)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackupFlag(t *testing.T) {
+	for _, tt := range []struct {
+		args     []string
+		expected string
+	}{
+		{args: nil, expected: ""},
+		{args: []string{"-backup"}, expected: ".bak"},
+		{args: []string{"-backup=.orig"}, expected: ".orig"},
+		{args: []string{"-backup=backups"}, expected: "backups"},
+		{args: []string{"-backup=false"}, expected: ""},
+	} {
+		var backup backupFlag
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.SetOutput(io.Discard)
+		flags.Var(&backup, "backup", "")
+		if err := flags.Parse(tt.args); err != nil {
+			t.Errorf("Parse(%v) failed: %v", tt.args, err)
+			continue
+		}
+		if string(backup) != tt.expected {
+			t.Errorf("Parse(%v) = %q, expected %q", tt.args, backup, tt.expected)
+		}
+	}
+}
+
+func TestProcessFileBacksUpOriginals(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-backup-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+	t.Chdir(tempDir)
+
+	content := "removed {\n  from = aws_instance.old\n}\n"
+	if err := os.MkdirAll(filepath.Join("envs", "prod"), 0750); err != nil {
+		t.Fatalf("Failed to create test dir: %v", err)
+	}
+	testFile := filepath.Join("envs", "prod", "main.tf")
+
+	for _, tt := range []struct {
+		backup   string
+		expected string
+	}{
+		{backup: ".bak", expected: filepath.Join("envs", "prod", "main.tf.bak")},
+		{backup: "backups", expected: filepath.Join("backups", "envs", "prod", "main.tf")},
+		{backup: "./.backups", expected: filepath.Join(".backups", "envs", "prod", "main.tf")},
+	} {
+		if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+		if err := processFile(testFile, &Stats{Backup: tt.backup}); err != nil {
+			t.Fatalf("processFile failed: %v", err)
+		}
+
+		backup, err := os.ReadFile(tt.expected)
+		if err != nil {
+			t.Errorf("Expected a backup at %s for -backup=%s: %v", tt.expected, tt.backup, err)
+			continue
+		}
+		if string(backup) != content {
+			t.Errorf("Expected the backup to hold the original content, but got %q", backup)
+		}
+		if !inBackupDir(tt.backup, tt.expected) && tt.backup != ".bak" {
+			t.Errorf("Expected %s to be excluded from discovery", tt.expected)
+		}
+	}
+
+	if inBackupDir("backups", testFile) {
+		t.Errorf("Expected %s not to be in the backup directory", testFile)
+	}
+}
+
+func TestUndoRestoresBackupSet(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-undo-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+	t.Chdir(tempDir)
+
+	content := "removed {\n  from = aws_instance.old\n}\n"
+	if err := os.WriteFile("main.tf", []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	stats := &Stats{Backup: "backups"}
+	if err := processFile("main.tf", stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	if len(stats.Backups) != 1 {
+		t.Fatalf("Expected 1 backup in the set, but got %d", len(stats.Backups))
+	}
+	if err := writeBackupManifest(backupManifestFile, stats.Backups, time.Now()); err != nil {
+		t.Fatalf("writeBackupManifest failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	restored, err := runUndo(backupManifestFile, true, &out)
+	if err != nil {
+		t.Fatalf("runUndo failed: %v", err)
+	}
+	if restored != 1 || !strings.Contains(out.String(), "Restoring "+stats.Backups[0].File) {
+		t.Errorf("Expected the dry run to list main.tf, but got %d files: %q", restored, out.String())
+	}
+	if data, _ := os.ReadFile("main.tf"); string(data) == content {
+		t.Errorf("Expected -dry-run not to restore main.tf")
+	}
+
+	if _, err := runUndo(backupManifestFile, false, io.Discard); err != nil {
+		t.Fatalf("runUndo failed: %v", err)
+	}
+	data, err := os.ReadFile("main.tf")
+	if err != nil {
+		t.Fatalf("Failed to read restored file: %v", err)
+	}
+	if string(data) != content {
+		t.Errorf("Expected %q, but got %q", content, string(data))
+	}
+	if _, err := os.Stat(backupManifestFile); !os.IsNotExist(err) {
+		t.Errorf("Expected the manifest to be removed after undo")
+	}
+	if _, err := runUndo(backupManifestFile, false, io.Discard); err == nil || !strings.Contains(err.Error(), "nothing to undo") {
+		t.Errorf("Expected nothing to undo, but got %v", err)
+	}
+}
+
+func TestUndoMissingBackupRestoresNothing(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-undo-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	kept := filepath.Join(tempDir, "a.tf")
+	missing := filepath.Join(tempDir, "b.tf")
+	for _, file := range []string{kept, kept + ".bak", missing} {
+		if err := os.WriteFile(file, []byte(filepath.Base(file)), 0600); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+	manifest := filepath.Join(tempDir, backupManifestFile)
+	entries := []backupEntry{{File: kept, Backup: kept + ".bak"}, {File: missing, Backup: missing + ".bak"}}
+	if err := writeBackupManifest(manifest, entries, time.Now()); err != nil {
+		t.Fatalf("writeBackupManifest failed: %v", err)
+	}
+
+	if _, err := runUndo(manifest, false, io.Discard); err == nil {
+		t.Fatalf("Expected error for a missing backup, but got nil")
+	}
+	if data, _ := os.ReadFile(kept); string(data) != "a.tf" {
+		t.Errorf("Expected a.tf to be left alone, but got %q", data)
+	}
+	if _, err := os.Stat(manifest); err != nil {
+		t.Errorf("Expected the manifest to be kept: %v", err)
+	}
+}
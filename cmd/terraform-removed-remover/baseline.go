@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// baselineVersion is the format version written to baseline files.
+const baselineVersion = 1
+
+// Finding records a removed block that was (or, in dry-run and check mode,
+// would be) removed.
+type Finding struct {
+	File    string
+	Address string
+	Line    int
+	// Blame is set with -blame when the introducing commit is known.
+	Blame *BlameInfo
+	// Owner is set with -owners to the most recent commit touching the block.
+	Owner *BlameInfo
+}
+
+// ExpiringFinding records a removed block whose remove-after date is near.
+type ExpiringFinding struct {
+	Finding
+	// RemoveAfter is the date as written in the block's comment.
+	RemoveAfter string
+}
+
+// NestedFinding records a removed block found inside another block.
+type NestedFinding struct {
+	Finding
+	Column int
+	// Parent is the type of the block directly containing the removed block.
+	Parent string
+}
+
+// Baseline is a set of known findings that check mode does not fail on.
+type Baseline struct {
+	Version int             `json:"version"`
+	Entries []BaselineEntry `json:"entries"`
+}
+
+// BaselineEntry identifies a suppressed removed block. Line numbers are not
+// recorded so that unrelated edits don't invalidate the baseline.
+type BaselineEntry struct {
+	File    string `json:"file"`
+	Address string `json:"address"`
+}
+
+func baselineKey(file, address string) BaselineEntry {
+	return BaselineEntry{File: filepath.ToSlash(filepath.Clean(file)), Address: address}
+}
+
+func loadBaseline(path string) (*Baseline, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading baseline %s: %w", path, err)
+	}
+
+	var baseline Baseline
+	if err := json.Unmarshal(content, &baseline); err != nil {
+		return nil, fmt.Errorf("error parsing baseline %s: %w", path, err)
+	}
+	if baseline.Version != baselineVersion {
+		return nil, fmt.Errorf("unsupported baseline version %d in %s", baseline.Version, path)
+	}
+
+	return &baseline, nil
+}
+
+// contains reports whether finding is suppressed by the baseline.
+func (b *Baseline) contains(finding Finding) bool {
+	key := baselineKey(finding.File, finding.Address)
+	for _, entry := range b.Entries {
+		if entry == key {
+			return true
+		}
+	}
+	return false
+}
+
+// unsuppressed returns the findings not present in the baseline. A nil
+// baseline suppresses nothing.
+func (b *Baseline) unsuppressed(findings []Finding) []Finding {
+	var result []Finding
+	for _, finding := range findings {
+		if b == nil || !b.contains(finding) {
+			result = append(result, finding)
+		}
+	}
+	return result
+}
+
+// prune returns a copy of the baseline without entries that no longer match
+// any finding, so the baseline only shrinks as blocks are cleaned up.
+func (b *Baseline) prune(findings []Finding) *Baseline {
+	present := make(map[BaselineEntry]bool)
+	for _, finding := range findings {
+		present[baselineKey(finding.File, finding.Address)] = true
+	}
+
+	pruned := &Baseline{Version: baselineVersion}
+	for _, entry := range b.Entries {
+		if present[entry] {
+			pruned.Entries = append(pruned.Entries, entry)
+		}
+	}
+	return pruned
+}
+
+// newBaseline builds a baseline suppressing every finding.
+func newBaseline(findings []Finding) *Baseline {
+	seen := make(map[BaselineEntry]bool)
+	baseline := &Baseline{Version: baselineVersion}
+	for _, finding := range findings {
+		key := baselineKey(finding.File, finding.Address)
+		if !seen[key] {
+			seen[key] = true
+			baseline.Entries = append(baseline.Entries, key)
+		}
+	}
+	return baseline
+}
+
+func writeBaseline(path string, baseline *Baseline) error {
+	sort.Slice(baseline.Entries, func(i, j int) bool {
+		if baseline.Entries[i].File != baseline.Entries[j].File {
+			return baseline.Entries[i].File < baseline.Entries[j].File
+		}
+		return baseline.Entries[i].Address < baseline.Entries[j].Address
+	})
+	if baseline.Entries == nil {
+		baseline.Entries = []BaselineEntry{}
+	}
+
+	content, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding baseline: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(content, '\n'), 0600); err != nil {
+		return fmt.Errorf("error writing baseline %s: %w", path, err)
+	}
+	return nil
+}
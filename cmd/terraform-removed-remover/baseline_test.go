@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBaselineRoundTrip(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-baseline-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	findings := []Finding{
+		{File: "envs/prod/main.tf", Address: "aws_instance.old", Line: 10},
+		{File: "envs/prod/main.tf", Address: "aws_instance.old", Line: 30},
+		{File: "./modules/vpc.tf", Address: "aws_vpc.legacy", Line: 5},
+	}
+
+	baselinePath := filepath.Join(tempDir, "baseline.json")
+	if err := writeBaseline(baselinePath, newBaseline(findings)); err != nil {
+		t.Fatalf("writeBaseline failed: %v", err)
+	}
+
+	baseline, err := loadBaseline(baselinePath)
+	if err != nil {
+		t.Fatalf("loadBaseline failed: %v", err)
+	}
+
+	if len(baseline.Entries) != 2 {
+		t.Errorf("Expected 2 baseline entries, but got %d", len(baseline.Entries))
+	}
+
+	current := []Finding{
+		{File: "modules/vpc.tf", Address: "aws_vpc.legacy", Line: 7},
+		{File: "envs/prod/main.tf", Address: "aws_instance.new", Line: 12},
+	}
+
+	failures := baseline.unsuppressed(current)
+	if len(failures) != 1 || failures[0].Address != "aws_instance.new" {
+		t.Errorf("Expected only aws_instance.new to be unsuppressed, but got %v", failures)
+	}
+
+	pruned := baseline.prune(current)
+	if len(pruned.Entries) != 1 || pruned.Entries[0].Address != "aws_vpc.legacy" {
+		t.Errorf("Expected pruned baseline to keep only aws_vpc.legacy, but got %v", pruned.Entries)
+	}
+}
+
+func TestBaselineNilSuppressesNothing(t *testing.T) {
+	var baseline *Baseline
+	findings := []Finding{{File: "main.tf", Address: "aws_instance.old", Line: 1}}
+
+	if got := baseline.unsuppressed(findings); len(got) != 1 {
+		t.Errorf("Expected nil baseline to suppress nothing, but got %v", got)
+	}
+}
+
+func TestLoadBaselineErrors(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-baseline-error-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	if _, err := loadBaseline(filepath.Join(tempDir, "missing.json")); err == nil {
+		t.Errorf("Expected error for missing baseline, but got nil")
+	}
+
+	badVersion := filepath.Join(tempDir, "bad.json")
+	if err := os.WriteFile(badVersion, []byte(`{"version": 99, "entries": []}`), 0600); err != nil {
+		t.Fatalf("Failed to write baseline: %v", err)
+	}
+	if _, err := loadBaseline(badVersion); err == nil {
+		t.Errorf("Expected error for unsupported baseline version, but got nil")
+	}
+}
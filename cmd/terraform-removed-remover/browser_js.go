@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"syscall/js"
+)
+
+// jsGlobal is the object the JS binding sets on the global scope.
+const jsGlobal = "terraformRemovedRemover"
+
+// runBrowser, built for GOOS=js, exposes the removal logic to JavaScript
+// instead of running the command line: it sets
+// terraformRemovedRemover.process(source, options) on the global scope and
+// blocks so the function stays callable. It never returns.
+func runBrowser() bool {
+	binding := js.Global().Get("Object").New()
+	process := js.FuncOf(jsProcess)
+	binding.Set("process", process)
+	binding.Set("version", Version)
+	js.Global().Set(jsGlobal, binding)
+	select {}
+}
+
+// jsProcess cleans args[0], the content of a file, with the options in the
+// optional args[1] object: filename (main.tf by default), only (an array of
+// -only patterns), normalizeWhitespace, and finalNewline. It returns
+// {content, report} with report in the -output json schema, or {error}.
+func jsProcess(_ js.Value, args []js.Value) any {
+	if len(args) == 0 || args[0].Type() != js.TypeString {
+		return jsError("the first argument must be the content to clean, as a string")
+	}
+	name := "main.tf"
+	var base Stats
+	if len(args) > 1 && args[1].Type() == js.TypeObject {
+		options := args[1]
+		if filename := options.Get("filename"); filename.Type() == js.TypeString {
+			name = filename.String()
+		}
+		if only := options.Get("only"); only.Type() == js.TypeObject {
+			for i := 0; i < only.Length(); i++ {
+				pattern := only.Index(i).String()
+				if _, err := path.Match(pattern, ""); err != nil {
+					return jsError(fmt.Sprintf("invalid only pattern %q: %s", pattern, err))
+				}
+				base.Only = append(base.Only, pattern)
+			}
+		}
+		base.NormalizeWhitespace = options.Get("normalizeWhitespace").Truthy()
+		if finalNewline := options.Get("finalNewline"); finalNewline.Type() == js.TypeString {
+			policy, ok := parseFinalNewline(finalNewline.String())
+			if !ok {
+				return jsError(fmt.Sprintf("invalid finalNewline %q: must be always (one), preserve, or never (none)", finalNewline.String()))
+			}
+			base.FinalNewline = policy
+		}
+	}
+
+	result, stats, err := processContent(name, []byte(args[0].String()), contentOptions(base))
+	if err != nil {
+		return jsError(err.Error())
+	}
+	report, err := json.Marshal(newReport(stats))
+	if err != nil {
+		return jsError(err.Error())
+	}
+	return map[string]any{
+		"content": string(result),
+		"report":  js.Global().Get("JSON").Call("parse", string(report)),
+	}
+}
+
+func jsError(message string) map[string]any {
+	return map[string]any{"error": message}
+}
@@ -0,0 +1,9 @@
+//go:build !js
+
+package main
+
+// runBrowser runs the JS binding when built for GOOS=js; elsewhere there is
+// none and the command line runs.
+func runBrowser() bool {
+	return false
+}
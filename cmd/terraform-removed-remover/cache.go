@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// cacheVersion is bumped whenever the cache file format changes. Caches of
+// another version are discarded.
+const cacheVersion = 1
+
+// runCache is the -cache file: the files a previous run found clean, meaning
+// they held no removed blocks and needed no change. A file whose size and
+// modification time, or failing that its content, still match its entry is
+// skipped.
+type runCache struct {
+	path string
+
+	Version int `json:"version"`
+	// Options fingerprints everything that decides whether a file is clean.
+	// A cache written with other options is discarded.
+	Options string                `json:"options"`
+	Files   map[string]cacheEntry `json:"files"`
+}
+
+type cacheEntry struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"`
+	SHA256  string `json:"sha256"`
+}
+
+// cacheOptions fingerprints the tool version and the options that change
+// what is done to a file without removed blocks, including the path and
+// digest of every nested configuration file that applies to one of files.
+func cacheOptions(stats *Stats, files []string) (string, error) {
+	options := fmt.Sprintf("%s fmt=%t fmt-all=%t no-format=%t consolidate=%t render=%t normalize=%t normalize-all=%t max-blank-lines=%d final-newline=%q line-endings=%q preserve-encoding=%t terragrunt=%t",
+		Version, stats.FormatOnly, stats.FmtAll, stats.NoFormat, stats.Consolidate, rendersCleanFiles(stats), stats.NormalizeWhitespace, stats.NormalizeAll, stats.maxBlankLines(),
+		stats.FinalNewline, stats.LineEndings, stats.PreserveEncoding, stats.DiscoveryOptions.Terragrunt)
+	if stats.DirConfigs == nil {
+		return options, nil
+	}
+	configs, err := stats.DirConfigs.paths(files)
+	if err != nil {
+		return "", err
+	}
+	for _, config := range configs {
+		digest, err := fileDigest(config)
+		if err != nil {
+			return "", fmt.Errorf("error reading config file: %w", err)
+		}
+		options += fmt.Sprintf(" config=%q:%s", config, digest)
+	}
+	return options, nil
+}
+
+// loadRunCache reads the cache at path for processing files. A missing,
+// unreadable, or outdated cache starts out empty.
+func loadRunCache(path string, stats *Stats, files []string) (*runCache, error) {
+	options, err := cacheOptions(stats, files)
+	if err != nil {
+		return nil, err
+	}
+	cache := &runCache{path: path, Version: cacheVersion, Options: options, Files: map[string]cacheEntry{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading cache: %w", err)
+	}
+
+	var stored runCache
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Version != cacheVersion || stored.Options != cache.Options {
+		return cache, nil
+	}
+	if stored.Files != nil {
+		cache.Files = stored.Files
+	}
+	return cache, nil
+}
+
+// isClean reports whether filePath is unchanged since it was found clean.
+// An entry whose file was only touched is refreshed.
+func (c *runCache) isClean(filePath string) bool {
+	entry, ok := c.Files[filePath]
+	if !ok {
+		return false
+	}
+	info, err := os.Stat(filePath)
+	if err != nil || info.Size() != entry.Size {
+		return false
+	}
+	if info.ModTime().UnixNano() == entry.ModTime {
+		return true
+	}
+
+	digest, err := fileDigest(filePath)
+	if err != nil || digest != entry.SHA256 {
+		return false
+	}
+	entry.ModTime = info.ModTime().UnixNano()
+	c.Files[filePath] = entry
+	return true
+}
+
+// record adds filePath as it is on disk now, or drops it when it isn't clean.
+func (c *runCache) record(filePath string, clean bool) {
+	delete(c.Files, filePath)
+	if !clean {
+		return
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return
+	}
+	digest, err := fileDigest(filePath)
+	if err != nil {
+		return
+	}
+	c.Files[filePath] = cacheEntry{Size: info.Size(), ModTime: info.ModTime().UnixNano(), SHA256: digest}
+}
+
+// save writes the cache, without the entries of files that no longer exist.
+func (c *runCache) save() error {
+	for filePath := range c.Files {
+		if _, err := os.Stat(filePath); err != nil {
+			delete(c.Files, filePath)
+		}
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(c.path, append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing cache: %w", err)
+	}
+	return nil
+}
+
+// cleanSince reports whether processing a file left stats as they were in
+// before: nothing was found, skipped, ignored, changed, or warned about.
+// An ignored file is never clean, so it is processed once it no longer is.
+func cleanSince(before Stats, stats *Stats) bool {
+	return stats.FilesModified == before.FilesModified &&
+		stats.FilesIgnored == before.FilesIgnored &&
+		stats.FilesSkipped == before.FilesSkipped &&
+		stats.FilesNotUTF8 == before.FilesNotUTF8 &&
+		stats.RemovedBlocksSkipped == before.RemovedBlocksSkipped &&
+		len(stats.Findings) == len(before.Findings) &&
+		len(stats.NestedFindings) == len(before.NestedFindings) &&
+		len(stats.Reformatted) == len(before.Reformatted) &&
+		len(stats.Warnings) == len(before.Warnings)
+}
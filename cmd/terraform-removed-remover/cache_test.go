@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunCache(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-cache-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	cleanFile := filepath.Join(tempDir, "clean.tf")
+	dirtyFile := filepath.Join(tempDir, "dirty.tf")
+	if err := os.WriteFile(cleanFile, []byte("locals {}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	if err := os.WriteFile(dirtyFile, []byte("removed {\n  from = aws_instance.old\n}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	cachePath := filepath.Join(tempDir, ".removed-remover-cache")
+	stats := Stats{DryRun: true}
+	cache, err := loadRunCache(cachePath, &stats, nil)
+	if err != nil {
+		t.Fatalf("loadRunCache failed: %v", err)
+	}
+	for _, file := range []string{cleanFile, dirtyFile} {
+		before := stats
+		err := processFile(file, &stats)
+		cache.record(file, err == nil && cleanSince(before, &stats))
+	}
+	if err := cache.save(); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	cache, err = loadRunCache(cachePath, &Stats{DryRun: true}, nil)
+	if err != nil {
+		t.Fatalf("loadRunCache failed: %v", err)
+	}
+	if !cache.isClean(cleanFile) {
+		t.Errorf("Expected %s to be cached as clean", cleanFile)
+	}
+	if cache.isClean(dirtyFile) {
+		t.Errorf("Expected %s not to be cached", dirtyFile)
+	}
+
+	// Touching a file keeps it clean; changing it does not
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(cleanFile, later, later); err != nil {
+		t.Fatalf("Failed to touch test file: %v", err)
+	}
+	if !cache.isClean(cleanFile) {
+		t.Errorf("Expected a touched file with the same content to stay clean")
+	}
+	if err := os.WriteFile(cleanFile, []byte("locals { a = 1 }\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	if cache.isClean(cleanFile) {
+		t.Errorf("Expected a changed file to be examined again")
+	}
+
+	cache, err = loadRunCache(cachePath, &Stats{DryRun: true, NormalizeAll: true}, nil)
+	if err != nil {
+		t.Fatalf("loadRunCache failed: %v", err)
+	}
+	if len(cache.Files) != 0 {
+		t.Errorf("Expected the cache to be discarded when options change, but got %v", cache.Files)
+	}
+}
+
+func TestRunCacheNestedConfig(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-cache-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	rootConfig := filepath.Join(tempDir, configFileName)
+	if err := os.WriteFile(rootConfig, nil, 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	sub := filepath.Join(tempDir, "sub")
+	if err := os.MkdirAll(sub, 0750); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	nestedConfig := filepath.Join(sub, configFileName)
+	if err := os.WriteFile(nestedConfig, []byte("ignore = true\n"), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	testFile := filepath.Join(sub, "main.tf")
+	if err := os.WriteFile(testFile, []byte("locals {}\n\nremoved {\n  from = aws_instance.old\n}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	args := []string{"-config", rootConfig, "-cache", filepath.Join(tempDir, ".removed-remover-cache"), "-log-level", "error", tempDir}
+	if status := run(args); status != exitOK {
+		t.Fatalf("Expected exit status %d with sub/ ignored, but got %d", exitOK, status)
+	}
+	if err := os.Remove(nestedConfig); err != nil {
+		t.Fatalf("Failed to remove config file: %v", err)
+	}
+	if status := run(args); status != exitChanges {
+		t.Errorf("Expected exit status %d once sub/ is no longer ignored, but got %d", exitChanges, status)
+	}
+	content, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	if strings.Contains(string(content), "removed") {
+		t.Errorf("Expected the removed block deleted, but got %q", content)
+	}
+}
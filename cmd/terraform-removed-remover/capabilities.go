@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net"
+	"os/exec"
+)
+
+// Capabilities records which optional external dependencies were available
+// to a run. Features that need a missing dependency are skipped with a
+// warning instead of failing, so the same invocation works in minimal
+// containers.
+type Capabilities struct {
+	Git       bool `json:"git"`
+	Terraform bool `json:"terraform"`
+	Network   bool `json:"network"`
+}
+
+// lookPath and interfaceAddrs are replaced in tests.
+var (
+	lookPath       = exec.LookPath
+	interfaceAddrs = net.InterfaceAddrs
+)
+
+// detectCapabilities checks for git and a terraform or tofu binary on PATH,
+// and for a non-loopback network address. Nothing is contacted over the
+// network.
+func detectCapabilities() Capabilities {
+	return Capabilities{
+		Git:       hasBinary("git"),
+		Terraform: hasBinary("terraform") || hasBinary("tofu"),
+		Network:   hasNetwork(),
+	}
+}
+
+func hasBinary(name string) bool {
+	_, err := lookPath(name)
+	return err == nil
+}
+
+func hasNetwork() bool {
+	addrs, err := interfaceAddrs()
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if ok && !ipNet.IP.IsLoopback() && !ipNet.IP.IsLinkLocalUnicast() {
+			return true
+		}
+	}
+	return false
+}
+
+// hasGit reports whether the git-dependent features can run. Without
+// detected capabilities, as in the tests, git is assumed to be available.
+func (s *Stats) hasGit() bool {
+	return s.Capabilities == nil || s.Capabilities.Git
+}
+
+// disableGitFeatures turns off the git-dependent features that were asked
+// for when git is not available, with a warning for each. -older-than stays
+// on and keeps every block, since their age can't be determined.
+func disableGitFeatures(stats *Stats) {
+	if stats.hasGit() {
+		return
+	}
+	if stats.Blame {
+		stats.Blame = false
+		stats.Warnings = append(stats.Warnings, "git is not available: -blame is ignored")
+	}
+	if stats.Owners {
+		stats.Owners = false
+		stats.Warnings = append(stats.Warnings, "git is not available: -owners is ignored")
+	}
+	if stats.OlderThan != 0 {
+		stats.Warnings = append(stats.Warnings, "git is not available: -older-than keeps every block")
+	}
+}
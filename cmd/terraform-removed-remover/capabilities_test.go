@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDetectCapabilities(t *testing.T) {
+	originalLookPath, originalInterfaceAddrs := lookPath, interfaceAddrs
+	defer func() {
+		lookPath, interfaceAddrs = originalLookPath, originalInterfaceAddrs
+	}()
+
+	lookPath = func(name string) (string, error) {
+		if name == "tofu" {
+			return "/usr/bin/tofu", nil
+		}
+		return "", errors.New("not found")
+	}
+	interfaceAddrs = func() ([]net.Addr, error) {
+		return []net.Addr{&net.IPNet{IP: net.IPv4(127, 0, 0, 1), Mask: net.CIDRMask(8, 32)}}, nil
+	}
+
+	expected := Capabilities{Terraform: true}
+	if got := detectCapabilities(); got != expected {
+		t.Errorf("Expected %+v, but got %+v", expected, got)
+	}
+
+	interfaceAddrs = func() ([]net.Addr, error) {
+		return []net.Addr{&net.IPNet{IP: net.IPv4(10, 0, 0, 2), Mask: net.CIDRMask(24, 32)}}, nil
+	}
+	if got := detectCapabilities(); !got.Network {
+		t.Errorf("Expected a network with a non-loopback address, but got %+v", got)
+	}
+}
+
+func TestProcessFileWithoutGit(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-capabilities-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	testFile := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(testFile, []byte("removed {\n  from = aws_instance.old\n}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	stats := Stats{DryRun: true, Blame: true, Owners: true, Capabilities: &Capabilities{}}
+	disableGitFeatures(&stats)
+	if err := processFile(testFile, &stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+
+	if len(stats.Findings) != 1 || stats.Findings[0].Blame != nil || stats.Findings[0].Owner != nil {
+		t.Errorf("Expected one finding without blame or owner, but got %+v", stats.Findings)
+	}
+	if len(stats.Warnings) != 2 {
+		t.Errorf("Expected one warning per disabled feature, but got %v", stats.Warnings)
+	}
+
+	stats = Stats{DryRun: true, OlderThan: time.Hour, Capabilities: &Capabilities{}}
+	disableGitFeatures(&stats)
+	if err := processFile(testFile, &stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	if stats.RemovedBlocksRemoved != 0 || stats.RemovedBlocksSkipped != 1 {
+		t.Errorf("Expected -older-than to keep the block, but got %d removed and %d skipped", stats.RemovedBlocksRemoved, stats.RemovedBlocksSkipped)
+	}
+	if len(stats.Warnings) != 1 {
+		t.Errorf("Expected a single warning instead of one per block, but got %v", stats.Warnings)
+	}
+}
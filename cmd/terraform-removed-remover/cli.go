@@ -0,0 +1,1046 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// subcommands run the same discovery, filtering, and reporting pipeline:
+// "fmt" only formats files, "consolidate" moves removed blocks into
+// removed.tf, "tui" picks the blocks to delete in a browser, and "doctor"
+// explains the state recorded by -state-dir. "compat" runs the built-in
+// corpus, "undo" restores the latest -backup set, "serve" processes files
+// sent over HTTP instead, and "self-update" replaces the binary with the
+// latest release.
+var subcommands = []string{"fmt", "consolidate", "tui", "doctor", "compat", "undo", "serve", "self-update"}
+
+// run carries out the command line args, the arguments after the program
+// name, and returns the exit status.
+func run(args []string) int {
+	subcommand := ""
+	if len(args) > 0 && slices.Contains(subcommands, args[0]) {
+		subcommand, args = args[0], args[1:]
+	}
+
+	// Bad flags exit with exitUsage rather than the flag package's 2, which
+	// means a parse error here.
+	flags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	f := newCLIFlags(flags)
+	flags.Usage = func() { printUsage(flags) }
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	// Errors before the logger is set up go to the default one, on stderr
+	configFile, err := f.applyConfigFile(flags)
+	if err != nil {
+		slog.Error("Invalid configuration", "error", err)
+		return exitUsage
+	}
+	if err := f.checkPure(subcommand, flags); err != nil {
+		slog.Error("Invalid usage", "error", err)
+		return exitUsage
+	}
+
+	if f.help {
+		printUsage(flags)
+		return exitOK
+	}
+	if f.version {
+		fmt.Printf("Terraform Removed Block Remover v%s\n", Version)
+		return exitOK
+	}
+
+	if f.output != "text" && f.output != "json" {
+		slog.Error("Invalid usage", "error", fmt.Sprintf("unknown -output format %q (expected text or json)", f.output))
+		return exitUsage
+	}
+	msg := f.messages()
+	logger, closeLog, err := f.newLogger(msg)
+	if err != nil {
+		slog.Error("Invalid usage", "error", err)
+		return errorStatus(err, exitUsage)
+	}
+	defer closeLog()
+
+	var status int
+	switch subcommand {
+	case "undo":
+		status, err = runUndoCommand(f.dryRun)
+	case "self-update":
+		status, err = runSelfUpdateCommand(f.dryRun)
+	case "compat":
+		status, err = runCompatCommand()
+	default:
+		status, err = runPipeline(subcommand, flags, f, configFile, msg, logger)
+	}
+	if err != nil {
+		status = errorStatus(err, status)
+	}
+	switch {
+	case err == nil:
+	case status == exitUsage:
+		logger.Error("Invalid usage", "error", err)
+	default:
+		logger.Error("Run failed", "error", err)
+	}
+	return status
+}
+
+// applyConfigFile reads the -config file, or the configuration file found in
+// the current directory or a parent, into the flags not given on the command
+// line. -pure on the command line only looks in the current directory. It
+// returns the path of the file read, if any.
+func (f *cliFlags) applyConfigFile(flags *flag.FlagSet) (string, error) {
+	if f.noConfig {
+		return "", nil
+	}
+	configFile := f.config
+	if configFile == "" {
+		found, err := findConfigFile(".", !f.pure)
+		if err != nil {
+			return "", err
+		}
+		configFile = found
+	}
+	if configFile == "" {
+		return "", nil
+	}
+	settings, err := loadConfigFile(configFile)
+	if err != nil {
+		return "", err
+	}
+	return configFile, applyConfig(flags, settings)
+}
+
+// messages returns where progress and diagnostics go: stdout, or stderr
+// when stdout must stay machine-readable.
+func (f *cliFlags) messages() io.Writer {
+	if f.output == "json" || f.external || f.worker {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// newLogger returns the logger the flags configure, writing to msg or the
+// -log-file, and a function that closes the log file.
+func (f *cliFlags) newLogger(msg io.Writer) (*slog.Logger, func(), error) {
+	logOutput, logLevel := msg, f.logLevel
+	closeLog := func() {}
+	if f.logFile != "" {
+		logFile, err := openLogFile(f.logFile)
+		if err != nil {
+			return nil, nil, writeError(err)
+		}
+		closeLog = func() { _ = logFile.Close() }
+		logOutput = logFile
+		// The file is for the per-file details the summary leaves out
+		if logLevel == "" {
+			logLevel = "debug"
+		}
+	}
+	logger, err := newLogger(logOutput, logLevel, f.logFormat, f.verbose, f.pure)
+	if err != nil {
+		closeLog()
+		return nil, nil, err
+	}
+	return logger, closeLog, nil
+}
+
+// runUndoCommand runs the undo subcommand.
+func runUndoCommand(dryRun bool) (int, error) {
+	restored, err := runUndo(backupManifestFile, dryRun, os.Stdout)
+	if err != nil {
+		return exitUsage, err
+	}
+	if dryRun {
+		fmt.Printf("Would restore %d files\n", restored)
+	} else {
+		fmt.Printf("Restored %d files\n", restored)
+	}
+	return exitOK, nil
+}
+
+// runSelfUpdateCommand runs the self-update subcommand.
+func runSelfUpdateCommand(dryRun bool) (int, error) {
+	executable, err := os.Executable()
+	if err == nil {
+		executable, err = filepath.EvalSymlinks(executable)
+	}
+	if err != nil {
+		return exitUsage, fmt.Errorf("cannot locate the running binary: %w", err)
+	}
+	apiURL := strings.TrimSuffix(os.Getenv("GITHUB_API_URL"), "/")
+	if apiURL == "" {
+		apiURL = defaultGitHubAPI
+	}
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		token = os.Getenv("GH_TOKEN")
+	}
+	latest, err := runSelfUpdate(newForgeClient(), apiURL, token, releasePublicKey, executable, Version, dryRun)
+	switch {
+	case err != nil:
+		return exitRuntime, err
+	case latest == "":
+		fmt.Printf("Already up to date (v%s)\n", Version)
+	case dryRun:
+		fmt.Printf("v%s is available (running v%s)\n", latest, Version)
+	default:
+		fmt.Printf("Updated %s from v%s to v%s\n", executable, Version, latest)
+	}
+	return exitOK, nil
+}
+
+// runCompatCommand runs the compat subcommand.
+func runCompatCommand() (int, error) {
+	failed, err := runCompat(os.Stdout)
+	if err != nil {
+		return exitUsage, err
+	}
+	if failed > 0 {
+		return exitChanges, nil
+	}
+	return exitOK, nil
+}
+
+// runPipeline validates the flags of a run that processes files and carries
+// it out in the mode they select: as an external data source, a persistent
+// worker, the serve subcommand, or a cleanup run.
+func runPipeline(subcommand string, flags *flag.FlagSet, f *cliFlags, configFile string, msg io.Writer, logger *slog.Logger) (int, error) {
+	if err := f.validate(subcommand, flags); err != nil {
+		return exitUsage, err
+	}
+	stats, err := f.newStats(subcommand)
+	if err != nil {
+		return exitUsage, err
+	}
+	if !f.noConfig {
+		stats.DirConfigs = newDirConfigs(configFile)
+	}
+	if f.interactive {
+		stats.Prompter = newBlockPrompter(os.Stdin, msg, stats.Color)
+	}
+
+	var redactor *Redactor
+	if f.redactConfig != "" {
+		if redactor, err = loadRedactor(f.redactConfig); err != nil {
+			return exitUsage, err
+		}
+	}
+	if f.auditLog != "" {
+		auditLog, err := openAuditLog(f.auditLog)
+		if err != nil {
+			return exitWriteError, err
+		}
+		defer func() { _ = auditLog.Close() }()
+		stats.AuditLog = auditLog
+	}
+	if f.archive != "" {
+		archive, err := openArchive(f.archive)
+		if err != nil {
+			return exitWriteError, err
+		}
+		defer func() { _ = archive.Close() }()
+		stats.Archive = archive
+	}
+	// -pure runs without git and never looks at PATH or the network.
+	capabilities := Capabilities{}
+	if !stats.Pure {
+		capabilities = detectCapabilities()
+	}
+	stats.Capabilities = &capabilities
+	disableGitFeatures(&stats)
+
+	stopProfiling, err := startProfiling(f.cpuProfile, f.memProfile)
+	if err != nil {
+		return exitWriteError, err
+	}
+
+	if f.external || f.worker {
+		if f.external {
+			err = runExternalDataSource(os.Stdin, os.Stdout, &stats)
+		} else {
+			err = runWorker(os.Stdin, os.Stdout, &stats)
+		}
+		if stopErr := stopProfiling(); err == nil {
+			err = writeError(stopErr)
+		}
+		if err != nil {
+			return exitUsage, err
+		}
+		return exitOK, nil
+	}
+
+	// SIGINT and SIGTERM stop the run between files, so no file is left
+	// half-written. A second signal exits immediately.
+	ctx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	go func() {
+		<-ctx.Done()
+		stopSignals()
+		logger.Warn("Interrupted; finishing the current file (press Ctrl-C again to exit immediately)")
+	}()
+
+	if subcommand == "serve" {
+		err := serveAPIs(ctx, f.listen, f.grpcListen, newProcessServer(stats, redactor, logger), logger)
+		if err != nil {
+			err = runtimeError(err)
+		} else {
+			err = writeError(stopProfiling())
+		}
+		if err != nil {
+			return exitUsage, err
+		}
+		return exitOK, nil
+	}
+
+	r := &cleanupRun{
+		ctx:           ctx,
+		subcommand:    subcommand,
+		flags:         f,
+		args:          flags.Args(),
+		msg:           msg,
+		logger:        logger,
+		stats:         stats,
+		redactor:      redactor,
+		stopProfiling: stopProfiling,
+	}
+	return r.run()
+}
+
+// cleanupRun is a run that finds the files to process, processes them, and
+// reports the results: a normal run, or one of the fmt, consolidate, tui,
+// and doctor subcommands.
+type cleanupRun struct {
+	ctx        context.Context
+	subcommand string
+	flags      *cliFlags
+	// args are the path arguments.
+	args   []string
+	msg    io.Writer
+	logger *slog.Logger
+
+	stats    Stats
+	redactor *Redactor
+	baseline *Baseline
+	jira     *jiraClient
+	token    *continuationToken
+	clones   []*clonedRepo
+
+	roots     []string
+	rootIsDir map[string]bool
+	discovery *Discovery
+	files     []string
+	// remaining are the files left when the run stopped early.
+	remaining []string
+	// fileStatus is the worst exit status of the files processed.
+	fileStatus    int
+	stopProfiling func() error
+}
+
+// scannedModules returns the module directories whose files were all
+// processed, so that a clean result there means they have no removed blocks.
+func (r *cleanupRun) scannedModules() []string {
+	failed := make(map[string]bool)
+	for _, file := range r.remaining {
+		failed[jiraModule(file)] = true
+	}
+	for _, fileErr := range r.stats.Errors {
+		failed[jiraModule(fileErr.File)] = true
+	}
+
+	var modules []string
+	for _, file := range r.files {
+		if module := jiraModule(file); !failed[module] && !slices.Contains(modules, module) {
+			modules = append(modules, module)
+		}
+	}
+	return modules
+}
+
+// run carries out the run and returns its exit status.
+func (r *cleanupRun) run() (int, error) {
+	f := r.flags
+	var err error
+	if f.jiraProject != "" {
+		if r.jira, err = newJiraClientFromEnv(); err != nil {
+			return exitUsage, err
+		}
+	}
+	if f.baseline != "" && !f.baselineWrite {
+		if r.baseline, err = loadBaseline(f.baseline); err != nil {
+			return exitUsage, err
+		}
+	}
+	// Debug messages already show each file and would break up the line
+	progressMode := f.progress
+	if f.logFile == "" && r.logger.Enabled(context.Background(), slog.LevelDebug) && progressMode == "auto" {
+		progressMode = "off"
+	}
+	if f.interactive || r.subcommand == "tui" {
+		progressMode = "off"
+	}
+	progress, err := newProgressReporter(progressMode, os.Stderr)
+	if err != nil {
+		return exitUsage, err
+	}
+
+	// The clones are removed as soon as they are pushed, and on every way
+	// out of the run before that
+	defer r.removeClones()
+	for _, url := range f.repo {
+		r.logger.Info("Cloning repository", "repo", url)
+		clone, err := cloneRepository(url, r.stats.needsHistory())
+		if err != nil {
+			return exitRuntime, err
+		}
+		r.clones = append(r.clones, clone)
+	}
+	if status, err := r.discover(); err != nil || status != exitOK {
+		return status, err
+	}
+
+	var preview *previewServer
+	if f.servePreview != "" {
+		preview = newPreviewServer(len(r.files))
+		url, err := preview.serve(f.servePreview)
+		if err != nil {
+			return exitRuntime, fmt.Errorf("could not start preview server: %w", err)
+		}
+		r.logger.Info("Serving preview", "url", url)
+	}
+
+	if r.subcommand == "doctor" {
+		stateDir := f.stateDir
+		if stateDir == "" {
+			stateDir = defaultStateDir
+		}
+		runDoctor(os.Stdout, stateDir, r.files, &r.stats)
+		printBackends(os.Stdout, r.files, f.backendConfig, r.stats.DiscoveryOptions)
+		if err := r.stopProfiling(); err != nil {
+			return exitWriteError, err
+		}
+		return exitOK, nil
+	}
+
+	var summary *summaryWriter
+	if f.summaryFile != "" {
+		summary = newSummaryWriter(f.summaryFile, f.summaryInterval)
+		summary.update(r.redactor.redactStats(&r.stats))
+		summary.start()
+	}
+
+	var cache *runCache
+	if f.cache != "" {
+		if cache, err = loadRunCache(f.cache, &r.stats, r.files); err != nil {
+			return exitUsage, err
+		}
+	}
+
+	r.process(cache, progress, preview, summary)
+	if r.subcommand == "tui" && r.ctx.Err() == nil && len(r.stats.Findings) > 0 {
+		if err := r.browse(); err != nil {
+			return exitUsage, err
+		}
+	}
+	interrupted := r.ctx.Err() != nil
+	if err := r.finish(cache); err != nil {
+		return exitUsage, err
+	}
+
+	r.scanIgnoredFiles(interrupted)
+	if err := r.stopProfiling(); err != nil {
+		return exitWriteError, err
+	}
+	r.stats.EndTime = time.Now()
+
+	reportStats := r.redactor.redactStats(&r.stats)
+	if f.output == "json" {
+		if err := writeJSONReport(os.Stdout, reportStats); err != nil {
+			return exitWriteError, err
+		}
+	} else {
+		if f.list {
+			printFindings(os.Stdout, reportStats.Findings)
+		}
+		printSummary(os.Stdout, reportStats)
+	}
+
+	// With -watch the summary stays open, and keeps counting, until the
+	// watch ends
+	if summary != nil && (!f.watch || interrupted) {
+		if err := summary.stop(reportStats); err != nil {
+			return exitWriteError, err
+		}
+	}
+
+	// Partial results are not enough for the steps below
+	if interrupted {
+		return exitInterrupted, nil
+	}
+
+	if r.jira != nil {
+		results, err := r.jira.syncModuleIssues(f.jiraProject, r.redactor.redactFindings(r.stats.Findings), r.scannedModules())
+		for _, result := range results {
+			action := "Updated Jira issue"
+			if result.Created {
+				action = "Created Jira issue"
+			} else if result.Closed {
+				action = "Closed Jira issue"
+			}
+			r.logger.Info(action, "key", result.Key, "module", result.Module)
+		}
+		if err != nil {
+			return exitRuntime, err
+		}
+	}
+
+	if preview != nil {
+		preview.finish()
+		r.logger.Info("Scan complete; the preview is still being served. Press Ctrl-C to exit.")
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		<-interrupt
+	}
+
+	if f.watch {
+		return r.watch(summary)
+	}
+
+	if f.baselineWrite || f.baselinePrune {
+		updated := newBaseline(r.stats.Findings)
+		if f.baselinePrune {
+			updated = r.baseline.prune(r.stats.Findings)
+		}
+		if err := writeBaseline(f.baseline, updated); err != nil {
+			return exitWriteError, err
+		}
+		r.logger.Info("Baseline written", "file", f.baseline, "entries", len(updated.Entries))
+		// Files that failed to parse are missing from the baseline
+		return r.fileStatus, nil
+	}
+
+	return r.status(), nil
+}
+
+// discover finds the files to process: those of the -plan, the
+// continuation token, the -files list, or the path arguments, without the
+// files the flags exclude.
+func (r *cleanupRun) discover() (int, error) {
+	f := r.flags
+	roots := r.args
+	if len(r.clones) > 0 {
+		roots = repoRoots(r.clones, roots)
+	}
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+
+	// Expand glob arguments ourselves so quoted patterns work the same in
+	// every shell and in CI configuration.
+	var expanded []string
+	fromGlob := make(map[string]bool)
+	for _, root := range roots {
+		if !hasGlobMeta(root) {
+			expanded = append(expanded, root)
+			continue
+		}
+		matches, err := expandGlob(root)
+		if err != nil {
+			return exitUsage, err
+		}
+		if len(matches) == 0 {
+			return exitUsage, fmt.Errorf("pattern %q matched no paths", root)
+		}
+		for _, match := range matches {
+			fromGlob[match] = true
+		}
+		expanded = append(expanded, matches...)
+	}
+
+	// Single files can be cleaned without pointing the tool at their whole
+	// directory. Other files matched by a glob are dropped.
+	discoveryOptions := r.stats.DiscoveryOptions
+	r.rootIsDir = make(map[string]bool)
+	for _, root := range expanded {
+		info, err := os.Stat(root)
+		if err != nil {
+			return exitUsage, err
+		}
+		if !info.IsDir() && !discoveryOptions.includes(root) {
+			if fromGlob[root] {
+				continue
+			}
+			return exitUsage, fmt.Errorf("%s is not a directory or a Terraform file", root)
+		}
+		r.rootIsDir[root] = info.IsDir()
+		r.roots = append(r.roots, root)
+	}
+
+	var err error
+	if f.continueToken != "" {
+		if r.token, err = loadContinuation(f.continueToken); err != nil {
+			return exitUsage, err
+		}
+	}
+	if f.plan != "" {
+		if r.stats.Selection, err = loadEditPlan(f.plan); err != nil {
+			return exitUsage, err
+		}
+	}
+
+	var discovery *Discovery
+	switch {
+	case r.stats.Selection != nil:
+		discovery = &Discovery{Files: r.stats.Selection.paths()}
+	case r.token != nil:
+		r.logger.Info("Continuing from continuation token", "token", f.continueToken, "files", len(r.token.Files))
+		discovery = r.token.discovery(r.logger)
+	case f.files != "":
+		if discovery, err = listedFiles(f.files, discoveryOptions, r.logger); err != nil {
+			return exitUsage, err
+		}
+		if f.gitDiff != "" {
+			if discovery, err = filterGitDiff(discovery, ".", f.gitDiff, &r.stats); err != nil {
+				return exitRuntime, err
+			}
+		}
+		if f.staged {
+			if discovery, err = filterGitStaged(discovery, ".", &r.stats); err != nil {
+				return exitRuntime, err
+			}
+		}
+	default:
+		discovery = &Discovery{}
+		for _, root := range r.roots {
+			// git commands for a single file run from the file's directory.
+			gitDir := root
+			if r.rootIsDir[root] {
+				r.logger.Info("Scanning directory", "path", root)
+			} else {
+				r.logger.Info("Scanning file", "path", root)
+				gitDir = filepath.Dir(root)
+			}
+			found, err := discoverFilesContext(r.ctx, root, discoveryOptions)
+			if errors.Is(err, context.Canceled) {
+				return exitInterrupted, nil
+			}
+			if err != nil {
+				return exitUsage, fmt.Errorf("error finding Terraform files in %s: %w", root, err)
+			}
+			if r.rootIsDir[root] && !f.noTerraformignore {
+				if found, err = filterTerraformIgnored(found, root); err != nil {
+					return exitUsage, err
+				}
+			}
+			if !f.noGitignore && r.stats.hasGit() {
+				found = filterGitIgnored(found, gitDir, &r.stats)
+			}
+			if f.gitDiff != "" {
+				if found, err = filterGitDiff(found, gitDir, f.gitDiff, &r.stats); err != nil {
+					return exitRuntime, err
+				}
+			}
+			if f.staged {
+				if found, err = filterGitStaged(found, gitDir, &r.stats); err != nil {
+					return exitRuntime, err
+				}
+			}
+			discovery.merge(found)
+		}
+	}
+
+	for _, link := range discovery.Symlinks {
+		r.logger.Debug("Skipping symlink (use -follow-symlinks to process it)", "file", link)
+	}
+	discovery = r.excludeOwnFiles(discovery)
+	if len(f.stateKey) > 0 {
+		if discovery, err = filterStateKeys(discovery, f.stateKey, f.backendConfig, discoveryOptions, &r.stats); err != nil {
+			return exitUsage, err
+		}
+	}
+	r.discovery = discovery
+	r.files = discovery.Files
+
+	// Never write through symlinks or .. segments to files outside the
+	// roots unless explicitly allowed and confirmed.
+	var resolvedRoots []string
+	for _, root := range r.roots {
+		resolved, err := resolveRoot(root)
+		if err != nil {
+			return exitUsage, err
+		}
+		resolvedRoots = append(resolvedRoots, resolved)
+	}
+	r.stats.ResolvedRoots = resolvedRoots
+	r.stats.AllowOutsideRoot = f.allowOutsideRoot
+	inside, outside, err := outsideRoots(r.files, resolvedRoots)
+	if err != nil {
+		return exitUsage, err
+	}
+	if len(outside) > 0 {
+		switch {
+		case !f.allowOutsideRoot:
+			for _, file := range outside {
+				r.logger.Warn("Skipping a file that resolves to a location outside the root (use -allow-outside-root to process it)", "file", file)
+			}
+			r.files = inside
+		case !r.stats.DryRun:
+			fmt.Fprintln(r.msg, "These files resolve to locations outside the root and will be modified:")
+			for _, file := range outside {
+				fmt.Fprintf(r.msg, "  %s\n", file)
+			}
+			if !confirm(os.Stdin, r.msg, "Continue?") {
+				return exitUsage, errors.New("aborted")
+			}
+		}
+	}
+	r.logger.Info("Found Terraform files", "count", len(r.files))
+	return exitOK, nil
+}
+
+// excludeOwnFiles drops the -backup copies and the -archive file, which the
+// run writes itself, from discovery.
+func (r *cleanupRun) excludeOwnFiles(discovery *Discovery) *Discovery {
+	if backup := string(r.flags.backup); backup != "" {
+		discovery = discovery.exclude(func(file string) bool { return inBackupDir(backup, file) })
+	}
+	if archive := r.flags.archive; archive != "" {
+		discovery = discovery.exclude(func(file string) bool { return isArchive(archive, file) })
+	}
+	return discovery
+}
+
+// process processes the files in order until they are done, the run is
+// interrupted, -max-duration runs out, or -fail-fast or the prompt stops
+// it.
+func (r *cleanupRun) process(cache *runCache, progress *progressReporter, preview *previewServer, summary *summaryWriter) {
+	f := r.flags
+	if progress != nil {
+		progress.total = len(r.files)
+	}
+	for i, file := range r.files {
+		// At least one file is processed so that every run makes progress
+		if f.maxDuration > 0 && i > 0 && time.Since(r.stats.StartTime) >= f.maxDuration {
+			r.remaining = r.files[i:]
+			break
+		}
+		if r.ctx.Err() != nil {
+			r.remaining = r.files[i:]
+			break
+		}
+		found := len(r.stats.Findings)
+		skipped := r.stats.RemovedBlocksSkipped
+		var err error
+		if cache != nil && cache.isClean(file) {
+			r.logger.Debug("Skipping unchanged clean file", "file", file)
+			r.stats.FilesProcessed++
+		} else {
+			r.logger.Debug("Processing", "file", file)
+			before := r.stats
+			err = processFile(file, &r.stats)
+			if cache != nil {
+				cache.record(file, err == nil && cleanSince(before, &r.stats))
+			}
+		}
+		if err != nil {
+			if progress != nil {
+				progress.finish()
+			}
+			r.fileFailed(file, err)
+		} else if f.stateDir != "" && !r.stats.DryRun && !r.stats.FormatOnly {
+			if err := recordFileState(f.stateDir, file, r.stats.RemovedBlocksSkipped-skipped, time.Now()); err != nil {
+				r.stats.Warnings = append(r.stats.Warnings, fmt.Sprintf("could not record state: %s", err))
+			}
+		}
+		if preview != nil {
+			preview.addFile(file, r.redactor.redactFindings(r.stats.Findings[found:]))
+		}
+		if summary != nil && summary.due() {
+			summary.update(r.redactor.redactStats(&r.stats))
+		}
+		if progress != nil {
+			progress.update(i+1, time.Now())
+		}
+		if r.stats.Prompter != nil && r.stats.Prompter.quit {
+			if left := len(r.files) - i - 1; left > 0 {
+				r.stats.Warnings = append(r.stats.Warnings, fmt.Sprintf("stopped at the prompt with %d files left unprocessed", left))
+			}
+			break
+		}
+		if err != nil && f.failFast {
+			if left := len(r.files) - i - 1; left > 0 {
+				r.stats.Warnings = append(r.stats.Warnings, fmt.Sprintf("stopped at the first error (-fail-fast) with %d files left unprocessed", left))
+			}
+			break
+		}
+	}
+	if progress != nil {
+		progress.finish()
+	}
+}
+
+// fileFailed records that file could not be processed.
+func (r *cleanupRun) fileFailed(file string, err error) {
+	r.logger.Error("Error processing file", "file", file, "error", err)
+	r.stats.Errors = append(r.stats.Errors, FileError{File: file, Message: err.Error()})
+	r.fileStatus = max(r.fileStatus, fileErrorStatus(err))
+}
+
+// browse lets the user pick, in the tui, which of the blocks the dry run
+// found to delete, and deletes them.
+func (r *cleanupRun) browse() error {
+	selection, err := runTUI(os.Stdin, os.Stdout, r.stats.Findings)
+	if err != nil || selection == nil {
+		return err
+	}
+	inventory := r.stats.Inventory
+	r.stats = r.stats.withoutResults()
+	r.stats.DryRun, r.stats.Selection, r.stats.Inventory = false, selection, nil
+	for _, file := range selection.paths() {
+		if err := processFile(file, &r.stats); err != nil {
+			r.fileFailed(file, err)
+		}
+	}
+	r.stats.Inventory = inventory
+	return nil
+}
+
+// finish writes what the run leaves behind besides the files it processed:
+// the continuation token, the staged, committed, or pushed changes, the
+// cache, the backup manifest, the inventory, and the edits.
+func (r *cleanupRun) finish(cache *runCache) error {
+	f := r.flags
+	continuationFile := f.continueToken
+	if continuationFile == "" {
+		continuationFile = defaultContinuationFile
+	}
+	interrupted := r.ctx.Err() != nil
+	switch {
+	case interrupted && f.maxDuration == 0 && f.continueToken == "":
+		r.stats.Warnings = append(r.stats.Warnings, fmt.Sprintf("interrupted with %d files left unprocessed", len(r.remaining)))
+	case len(r.remaining) > 0:
+		if err := writeContinuation(continuationFile, r.remaining); err != nil {
+			return writeError(err)
+		}
+		reason := "-max-duration reached"
+		if interrupted {
+			reason = "interrupted"
+		}
+		r.stats.Warnings = append(r.stats.Warnings, fmt.Sprintf("%s with %d files left; rerun with -continue %s", reason, len(r.remaining), continuationFile))
+	case r.token != nil:
+		// The token is used up
+		if err := os.Remove(f.continueToken); err != nil {
+			r.stats.Warnings = append(r.stats.Warnings, fmt.Sprintf("could not remove continuation token: %s", err))
+		}
+	}
+	// The rewritten files belong in the commit being made
+	if f.staged && len(r.stats.Written) > 0 {
+		if err := gitAdd(r.stats.Written); err != nil {
+			return runtimeError(err)
+		}
+	}
+	if err := r.publish(); err != nil {
+		return err
+	}
+	if f.plan != "" && !interrupted && len(r.remaining) == 0 {
+		r.stats.Warnings = append(r.stats.Warnings, r.stats.Selection.unmatched()...)
+	}
+
+	if cache != nil {
+		if err := cache.save(); err != nil {
+			r.stats.Warnings = append(r.stats.Warnings, err.Error())
+		}
+	}
+	if len(r.stats.Backups) > 0 {
+		if err := writeBackupManifest(backupManifestFile, r.stats.Backups, r.stats.now()); err != nil {
+			r.stats.Warnings = append(r.stats.Warnings, err.Error())
+		}
+	}
+	if r.stats.Inventory != nil {
+		if err := writeInventory(f.inventory, r.stats.Inventory); err != nil {
+			return writeError(err)
+		}
+	}
+	if r.stats.Edits != nil {
+		if err := writeWorkspaceEdit(f.editsJSON, r.stats.Edits); err != nil {
+			return writeError(err)
+		}
+	}
+	return nil
+}
+
+// publish commits the files the run wrote for -git-commit and -git-branch,
+// and pushes each -repo clone's on its own branch.
+func (r *cleanupRun) publish() error {
+	f := r.flags
+	// Each clone gets its own branch, and is gone once it is pushed
+	for _, clone := range r.clones {
+		if files := clone.written(r.stats.Written); len(files) > 0 && !r.stats.DryRun {
+			branch := f.gitBranch
+			if branch == "" {
+				branch = defaultRepoBranch
+			}
+			opts := publishOptions{message: f.gitCommit, branch: branch, createPR: f.createPR, body: pullRequestBody(r.redactor.redactStats(clone.stats(files, r.stats.Findings)))}
+			pullRequestURL, err := publishChanges(files, opts, r.logger.With("repo", clone.url))
+			if err != nil {
+				r.logger.Error("Error publishing changes", "repo", clone.url, "error", err)
+				r.stats.Errors = append(r.stats.Errors, FileError{File: clone.url, Message: fmt.Sprintf("error publishing changes to %s: %s", clone.url, err)})
+				r.fileStatus = max(r.fileStatus, exitRuntime)
+			} else if pullRequestURL != "" {
+				r.logger.Info("Opened pull request", "repo", clone.url, "url", pullRequestURL)
+			}
+		}
+		if err := os.RemoveAll(clone.dir); err != nil {
+			r.stats.Warnings = append(r.stats.Warnings, fmt.Sprintf("could not remove the clone of %s: %s", clone.url, err))
+		}
+	}
+	if len(r.clones) > 0 || (f.gitCommit == "" && f.gitBranch == "") || len(r.stats.Written) == 0 {
+		return nil
+	}
+	if !r.stats.hasGit() {
+		return errors.New("-git-commit and -git-branch need git, which is not available")
+	}
+	opts := publishOptions{message: f.gitCommit, branch: f.gitBranch, createPR: f.createPR, body: pullRequestBody(r.redactor.redactStats(&r.stats))}
+	pullRequestURL, err := publishChanges(r.stats.Written, opts, r.logger)
+	if err != nil {
+		return runtimeError(err)
+	}
+	if pullRequestURL != "" {
+		r.logger.Info("Opened pull request", "url", pullRequestURL)
+	}
+	return nil
+}
+
+// removeClones removes the -repo clones that are still there.
+func (r *cleanupRun) removeClones() {
+	for _, clone := range r.clones {
+		_ = os.RemoveAll(clone.dir)
+	}
+}
+
+// scanIgnoredFiles reports the removed blocks in the files Terraform's own
+// conventions exclude, unless the run was interrupted.
+func (r *cleanupRun) scanIgnoredFiles(interrupted bool) {
+	for _, file := range r.discovery.Ignored {
+		if interrupted {
+			break
+		}
+		r.logger.Debug("Scanning ignored file", "file", file)
+		if tooLarge, _ := exceedsMaxFileSize(file, r.stats.MaxFileSize); tooLarge {
+			continue
+		}
+		findings, err := scanIgnoredFile(file)
+		if err != nil {
+			r.logger.Warn("Could not scan ignored file", "file", file, "error", err)
+			continue
+		}
+		r.stats.IgnoredFindings = append(r.stats.IgnoredFindings, findings...)
+	}
+}
+
+// watch keeps processing the files under the roots as they change, until
+// the run is interrupted, and then finishes the -summary-file.
+func (r *cleanupRun) watch(summary *summaryWriter) (int, error) {
+	f := r.flags
+	watcher, err := newFileWatcher(r.roots, r.stats.DiscoveryOptions.IncludeDotTerraform, func() ([]string, error) {
+		found := &Discovery{}
+		for _, root := range r.roots {
+			discovered, err := discoverFilesContext(r.ctx, root, r.stats.DiscoveryOptions)
+			if err != nil {
+				return nil, err
+			}
+			if r.rootIsDir[root] && !f.noTerraformignore {
+				if discovered, err = filterTerraformIgnored(discovered, root); err != nil {
+					return nil, err
+				}
+			}
+			if !f.noGitignore && r.stats.hasGit() {
+				gitDir := root
+				if !r.rootIsDir[root] {
+					gitDir = filepath.Dir(root)
+				}
+				discovered = filterGitIgnored(discovered, gitDir, &Stats{})
+			}
+			found.merge(discovered)
+		}
+		return r.excludeOwnFiles(found).Files, nil
+	})
+	if err != nil {
+		return exitUsage, err
+	}
+	defer func() {
+		_ = watcher.close()
+	}()
+	r.logger.Info("Watching for changes; press Ctrl-C to stop")
+
+	// The summary goes on counting from the run's results
+	r.stats.Inventory = nil
+	r.stats.EndTime = time.Time{}
+	watchFiles(r.ctx, watcher, f.watchInterval, &r.stats, os.Stdout, r.logger, func() {
+		if summary != nil {
+			summary.update(r.redactor.redactStats(&r.stats))
+		}
+	})
+	r.stats.EndTime = time.Now()
+	if summary != nil {
+		if err := summary.stop(r.redactor.redactStats(&r.stats)); err != nil {
+			return exitWriteError, err
+		}
+	}
+	return exitOK, nil
+}
+
+// status returns the exit status of a finished run. Files that failed
+// outweigh both the changes and the files left.
+func (r *cleanupRun) status() int {
+	f := r.flags
+	status := exitOK
+	if f.check && r.stats.FormatOnly {
+		if len(r.stats.Reformatted) > 0 {
+			fmt.Fprintf(r.msg, "\nCheck failed: %d files are not formatted\n", len(r.stats.Reformatted))
+			for _, file := range r.stats.Reformatted {
+				fmt.Fprintln(r.msg, file)
+			}
+			status = exitChanges
+		}
+	} else if f.check {
+		failures := r.baseline.unsuppressed(r.stats.Findings)
+		if suppressed := len(r.stats.Findings) - len(failures); suppressed > 0 {
+			fmt.Fprintf(r.msg, "Suppressed by baseline: %d\n", suppressed)
+		}
+		if len(failures) > 0 {
+			fmt.Fprintf(r.msg, "\nCheck failed: %d removed blocks found\n", len(failures))
+			printFindings(r.msg, r.redactor.redactFindings(failures))
+			status = exitChanges
+		}
+	} else if (!r.stats.DryRun || r.stats.FailOnChange) && r.stats.FilesModified > 0 {
+		if r.stats.FailOnChange {
+			fmt.Fprintf(r.msg, "\nFailed: %d files were or would be modified (-fail-on-change)\n", r.stats.FilesModified)
+		}
+		status = exitChanges
+	}
+
+	switch {
+	case r.fileStatus != exitOK:
+		return r.fileStatus
+	case len(r.remaining) > 0:
+		return exitContinue
+	}
+	return status
+}
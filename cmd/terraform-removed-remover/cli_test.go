@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunExitStatus(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-run-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	testFile := filepath.Join(tempDir, "main.tf")
+	content := "resource \"aws_instance\" \"web\" {}\n\nremoved {\n  from = aws_instance.old\n}\n"
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		args     []string
+		expected int
+	}{
+		{name: "dry run", args: []string{"-dry-run", tempDir}, expected: exitOK},
+		{name: "check", args: []string{"-check", tempDir}, expected: exitChanges},
+		{name: "bad flag", args: []string{"-no-such-flag"}, expected: exitUsage},
+		{name: "bad combination", args: []string{"-files", "-", tempDir}, expected: exitUsage},
+		{name: "missing path", args: []string{filepath.Join(tempDir, "missing")}, expected: exitUsage},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"-no-config", "-pure", "-log-level", "error"}, tt.args...)
+			if status := run(args); status != tt.expected {
+				t.Errorf("Expected exit status %d, but got %d", tt.expected, status)
+			}
+		})
+	}
+
+	modifiedContent, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	if string(modifiedContent) != content {
+		t.Errorf("Expected the dry runs to leave the file alone, but got %q", modifiedContent)
+	}
+}
+
+func TestRunExitStatusFailedFiles(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-run-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	invalid := filepath.Join(tempDir, "invalid.tf")
+	if err := os.WriteFile(invalid, []byte("removed {\n  from = aws_instance.old\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	baseline := filepath.Join(tempDir, "baseline.json")
+
+	for _, tt := range []struct {
+		name     string
+		args     []string
+		expected int
+	}{
+		{name: "baseline write", args: []string{"-baseline-suppress", baseline, "-baseline-write", tempDir}, expected: exitParseError},
+		{name: "baseline prune", args: []string{"-baseline-suppress", baseline, "-baseline-prune", tempDir}, expected: exitParseError},
+		{name: "inventory write", args: []string{"-dry-run", "-inventory", filepath.Join(tempDir, "missing", "inventory.json"), tempDir}, expected: exitWriteError},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"-no-config", "-pure", "-log-level", "error"}, tt.args...)
+			if status := run(args); status != tt.expected {
+				t.Errorf("Expected exit status %d, but got %d", tt.expected, status)
+			}
+		})
+	}
+
+	if _, err := os.Stat(baseline); err != nil {
+		t.Errorf("Expected the baseline to be written despite the parse error, but got %v", err)
+	}
+}
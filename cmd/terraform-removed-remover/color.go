@@ -0,0 +1,31 @@
+package main
+
+import "os"
+
+// ANSI escape sequences for colored output.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+	ansiBold   = "\x1b[1m"
+)
+
+// colorEnabled reports whether output to f should be colored: only on a
+// terminal, and never with -no-color or when NO_COLOR is set to anything
+// (https://no-color.org). -pure reads no environment, so it never colors.
+func colorEnabled(f *os.File, noColor, pure bool) bool {
+	if noColor || pure || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return isTerminal(f)
+}
+
+// colorize wraps s in the escape sequence code when color is on.
+func colorize(color bool, code, s string) string {
+	if !color {
+		return s
+	}
+	return code + s + ansiReset
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestColoredUnifiedDiff(t *testing.T) {
+	before := []byte("a\nb\n")
+	after := []byte("a\nc")
+
+	var out bytes.Buffer
+	if err := writeUnifiedDiff(&out, "main.tf", before, after, myersDiff, 1, true); err != nil {
+		t.Fatalf("writeUnifiedDiff failed: %v", err)
+	}
+
+	expected := ansiBold + "--- a/main.tf" + ansiReset + "\n" +
+		ansiBold + "+++ b/main.tf" + ansiReset + "\n" +
+		ansiCyan + "@@ -1,2 +1,2 @@" + ansiReset + "\n" +
+		" a\n" +
+		ansiRed + "-b" + ansiReset + "\n" +
+		ansiGreen + "+c" + ansiReset + "\n" +
+		"\\ No newline at end of file\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, but got %q", expected, out.String())
+	}
+}
+
+func TestColoredSummaryWarnings(t *testing.T) {
+	var out bytes.Buffer
+	printSummary(&out, &Stats{Color: true, Warnings: []string{"a.tf: converted from UTF-16LE to UTF-8"}})
+	if !strings.Contains(out.String(), ansiYellow+"a.tf: converted from UTF-16LE to UTF-8"+ansiReset) {
+		t.Errorf("Expected the warning in yellow, but got %q", out.String())
+	}
+
+	out.Reset()
+	printSummary(&out, &Stats{Warnings: []string{"a.tf: converted from UTF-16LE to UTF-8"}})
+	if strings.Contains(out.String(), "\x1b[") {
+		t.Errorf("Expected no escape sequences without color, but got %q", out.String())
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	pipeReader, pipeWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	defer func() {
+		_ = pipeReader.Close()
+		_ = pipeWriter.Close()
+	}()
+
+	t.Setenv("NO_COLOR", "")
+	if colorEnabled(pipeWriter, false, false) {
+		t.Errorf("Expected no color on a pipe")
+	}
+
+	originalIsTerminal := isTerminal
+	defer func() { isTerminal = originalIsTerminal }()
+	isTerminal = func(*os.File) bool { return true }
+
+	if !colorEnabled(pipeWriter, false, false) {
+		t.Errorf("Expected color on a terminal")
+	}
+	if colorEnabled(pipeWriter, true, false) || colorEnabled(pipeWriter, false, true) {
+		t.Errorf("Expected no color with -no-color or -pure")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if colorEnabled(pipeWriter, false, false) {
+		t.Errorf("Expected no color with NO_COLOR set")
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// withLeadingComments returns blocks with ranges extended to cover the
+// contiguous group of comment lines directly above the block: every block's
+// with all, for -strip-leading-comments, and otherwise only those of blocks
+// whose group holds a remove-after date, which describes only the block. A
+// blank line ends the group, so a comment separated from the block by one
+// is kept.
+func withLeadingComments(content []byte, blocks []removedBlock, all bool) []removedBlock {
+	extended := make([]removedBlock, len(blocks))
+	for i, block := range blocks {
+		if _, ok := removeAfter(block.comment); all || ok {
+			block.start = leadingCommentStart(content, block.start)
+		}
+		extended[i] = block
+	}
+	return extended
+}
+
+// leadingCommentStart returns the offset of the first comment line above
+// the block starting at start, or start when there is none. Only whole-line
+// comments count: #, //, and /* */ comments that begin their line.
+func leadingCommentStart(content []byte, start int) int {
+	lineStart := lineStartOf(content, start)
+	if len(bytes.TrimSpace(content[lineStart:start])) > 0 {
+		return start
+	}
+
+	result := start
+	for lineStart > 0 {
+		prevStart := lineStartOf(content, lineStart-1)
+		line := bytes.TrimSpace(content[prevStart:lineStart])
+		switch {
+		case bytes.HasPrefix(line, []byte("#")), bytes.HasPrefix(line, []byte("//")):
+		case bytes.HasSuffix(line, []byte("*/")):
+			// Find the line opening the comment, which must open nothing else
+			open := bytes.LastIndex(content[:lineStart], []byte("/*"))
+			if open < 0 {
+				return result
+			}
+			prevStart = lineStartOf(content, open)
+			if len(bytes.TrimSpace(content[prevStart:open])) > 0 {
+				return result
+			}
+		default:
+			return result
+		}
+		lineStart = prevStart
+		result = lineStart + indentation(content[lineStart:])
+	}
+	return result
+}
+
+// withTrailingComments returns blocks with each range extended to cover the
+// comments that follow the block, for -strip-trailing-comments, see
+// trailingCommentEnd.
+func withTrailingComments(content []byte, blocks []removedBlock) []removedBlock {
+	extended := make([]removedBlock, len(blocks))
+	for i, block := range blocks {
+		block.end = trailingCommentEnd(content, block.end)
+		extended[i] = block
+	}
+	return extended
+}
+
+// warnTrailingComments records a warning for each of blocks, about to be
+// deleted from filePath, that is followed by a comment that will be left
+// behind.
+func warnTrailingComments(filePath string, content []byte, blocks []removedBlock, stats *Stats) {
+	for _, block := range blocks {
+		if trailingCommentEnd(content, block.end) != block.end {
+			stats.Warnings = append(stats.Warnings, fmt.Sprintf("%s:%d: the comment after the removed block for %s is left behind (use -strip-trailing-comments to delete it)", filePath, block.EndLine, block.Address))
+		}
+	}
+}
+
+// trailingCommentEnd returns the offset just past the comments following
+// the block ending at end, or end when there are none: a comment on the
+// closing brace's line, then a group of whole-line # and // comments
+// directly below it. The group only counts when a blank line or the end of
+// the file follows it; otherwise it introduces whatever comes next.
+func trailingCommentEnd(content []byte, end int) int {
+	lineEnd := lineEndOf(content, end)
+	rest := bytes.TrimSpace(content[end:lineEnd])
+	result := end
+	if len(rest) > 0 {
+		// A /* */ comment must close at the end of the line
+		if !isLineComment(rest) && (!bytes.HasPrefix(rest, []byte("/*")) || bytes.Index(rest, []byte("*/")) != len(rest)-2) {
+			return end
+		}
+		result = end + len(bytes.TrimRight(content[end:lineEnd], " \t\r"))
+	}
+
+	groupEnd := result
+	for next := lineEnd + 1; next < len(content); {
+		nextEnd := lineEndOf(content, next)
+		line := bytes.TrimSpace(content[next:nextEnd])
+		if len(line) == 0 {
+			return groupEnd
+		}
+		if !isLineComment(line) {
+			return result
+		}
+		groupEnd = next + len(bytes.TrimRight(content[next:nextEnd], " \t\r"))
+		next = nextEnd + 1
+	}
+	return groupEnd
+}
+
+// isLineComment reports whether the trimmed line is a # or // comment.
+func isLineComment(line []byte) bool {
+	return bytes.HasPrefix(line, []byte("#")) || bytes.HasPrefix(line, []byte("//"))
+}
+
+// lineEndOf returns the offset of the newline ending the line holding
+// offset, or the length of content on the last line.
+func lineEndOf(content []byte, offset int) int {
+	if i := bytes.IndexByte(content[offset:], '\n'); i >= 0 {
+		return offset + i
+	}
+	return len(content)
+}
+
+// lineStartOf returns the offset of the start of the line holding offset.
+func lineStartOf(content []byte, offset int) int {
+	return bytes.LastIndexByte(content[:offset], '\n') + 1
+}
+
+// indentation returns the number of spaces and tabs line starts with.
+func indentation(line []byte) int {
+	n := 0
+	for n < len(line) && (line[n] == ' ' || line[n] == '\t') {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLeadingCommentStart(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{name: "none", content: "a = 1\nremoved {}\n", expected: "removed {}\n"},
+		{name: "hash and slashes", content: "a = 1\n# why\n// more\nremoved {}\n", expected: "# why\n// more\nremoved {}\n"},
+		{name: "blank line", content: "# kept\n\n# why\nremoved {}\n", expected: "# why\nremoved {}\n"},
+		{name: "block comment", content: "a = 1\n/*\n  why\n*/\nremoved {}\n", expected: "/*\n  why\n*/\nremoved {}\n"},
+		{name: "trailing block comment", content: "a = 1 /* x */\nremoved {}\n", expected: "removed {}\n"},
+		{name: "start of file", content: "  # why\n  removed {}\n", expected: "# why\n  removed {}\n"},
+		{name: "closing brace", content: "resource \"a\" \"b\" {\n  # inside\n}\nremoved {}\n", expected: "removed {}\n"},
+	} {
+		start := len(tt.content) - len("removed {}\n")
+		if actual := tt.content[leadingCommentStart([]byte(tt.content), start):]; actual != tt.expected {
+			t.Errorf("%s: expected %q, but got %q", tt.name, tt.expected, actual)
+		}
+	}
+}
+
+func TestProcessFileStripLeadingComments(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-comments-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := `resource "aws_instance" "web" {}
+
+# Keep this note
+
+# Moved to the shared account
+# in the 2024 migration
+removed {
+  from = aws_instance.old
+}
+`
+	testFile := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	if err := processFile(testFile, &Stats{StripLeadingComments: true}); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+
+	data, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	expected := "resource \"aws_instance\" \"web\" {}\n\n# Keep this note\n\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q, but got %q", expected, string(data))
+	}
+}
+
+func TestTrailingCommentEnd(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		rest     string
+		expected string
+	}{
+		{name: "none", rest: "\n\na = 1\n", expected: "\n\na = 1\n"},
+		{name: "same line", rest: " # cleanup after migration\na = 1\n", expected: "\na = 1\n"},
+		{name: "block comment", rest: " /* done */\na = 1\n", expected: "\na = 1\n"},
+		{name: "code after block comment", rest: " /* done */ a = 1\n", expected: " /* done */ a = 1\n"},
+		{name: "following group", rest: "\n# cleanup\n// after migration\n\na = 1\n", expected: "\n\na = 1\n"},
+		{name: "end of file", rest: " # done\n# really\n", expected: "\n"},
+		{name: "introduces next", rest: " # done\n# about a\na = 1\n", expected: "\n# about a\na = 1\n"},
+	} {
+		content := "removed {}" + tt.rest
+		if actual := content[trailingCommentEnd([]byte(content), len("removed {}")):]; actual != tt.expected {
+			t.Errorf("%s: expected %q, but got %q", tt.name, tt.expected, actual)
+		}
+	}
+}
+
+func TestProcessFileTrailingComments(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-comments-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := `removed {
+  from = aws_instance.old
+} # cleanup after migration
+
+resource "aws_instance" "web" {}
+`
+	testFile := filepath.Join(tempDir, "main.tf")
+	for _, tt := range []struct {
+		strip    bool
+		expected string
+		warnings int
+	}{
+		{strip: false, expected: "# cleanup after migration\n\nresource \"aws_instance\" \"web\" {}\n", warnings: 1},
+		{strip: true, expected: "\nresource \"aws_instance\" \"web\" {}\n", warnings: 0},
+	} {
+		if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+		stats := &Stats{StripTrailingComments: tt.strip}
+		if err := processFile(testFile, stats); err != nil {
+			t.Fatalf("processFile failed: %v", err)
+		}
+		data, err := os.ReadFile(testFile)
+		if err != nil {
+			t.Fatalf("Failed to read test file: %v", err)
+		}
+		if string(data) != tt.expected {
+			t.Errorf("Expected %q, but got %q", tt.expected, string(data))
+		}
+		if len(stats.Warnings) != tt.warnings {
+			t.Errorf("Expected %d warnings, but got %v", tt.warnings, stats.Warnings)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// compatCase is one entry of the compatibility corpus: a pathological input,
+// the exact bytes the tool must turn it into, how many blocks it removes,
+// and how many invalid nested blocks it reports.
+type compatCase struct {
+	Name     string
+	File     string
+	Input    string
+	Expected string
+	Removed  int
+	Nested   int
+}
+
+// compatCorpus holds the inputs most likely to trip up a text-based
+// implementation. It is run by the compat subcommand, so users can check the
+// tool on their platform before pointing it at critical repositories, and by
+// the tests as a regression suite.
+var compatCorpus = []compatCase{
+	{
+		Name: "heredoc containing removed",
+		File: "main.tf",
+		Input: `resource "local_file" "doc" {
+  content = <<EOT
+removed {
+  from = aws_instance.old
+}
+EOT
+}
+
+removed {
+  from = aws_instance.old
+}
+`,
+		Expected: `resource "local_file" "doc" {
+  content = <<EOT
+removed {
+  from = aws_instance.old
+}
+EOT
+}
+
+`,
+		Removed: 1,
+	},
+	{
+		Name: "indented heredoc containing removed",
+		File: "main.tf",
+		Input: `locals {
+  script = <<-EOT
+    removed {
+      from = aws_instance.old
+    }
+  EOT
+}
+`,
+		Expected: `locals {
+  script = <<-EOT
+    removed {
+      from = aws_instance.old
+    }
+  EOT
+}
+`,
+	},
+	{
+		Name: "template literals with braces",
+		File: "main.tf",
+		Input: `locals {
+  a = "removed { from = aws_instance.old }"
+  b = "${jsonencode({ removed = "}" })}"
+  c = "%{ if true }removed {%{ endif }"
+}
+
+removed {
+  from = aws_instance.old
+}
+`,
+		Expected: `locals {
+  a = "removed { from = aws_instance.old }"
+  b = "${jsonencode({ removed = "}" })}"
+  c = "%{if true}removed {%{endif}"
+}
+
+`,
+		Removed: 1,
+	},
+	{
+		Name: "unicode comments",
+		File: "main.tf",
+		Input: `# 古いリソースを削除 🧹
+removed {
+  # ünïcödé inside → the block
+  from = aws_instance.old /* ∞ */
+}
+
+// 保持する
+resource "aws_instance" "web" {}
+`,
+		Expected: `# 古いリソースを削除 🧹
+
+// 保持する
+resource "aws_instance" "web" {}
+`,
+		Removed: 1,
+	},
+	{
+		Name: "deeply nested blocks",
+		File: "main.tf",
+		Input: `resource "aws_instance" "web" {
+  dynamic "ebs" {
+    for_each = []
+    content {
+      lifecycle {
+        removed {
+          from = aws_instance.nested
+        }
+      }
+    }
+  }
+}
+
+removed {
+  from = aws_instance.old
+  lifecycle {
+    destroy = false
+  }
+}
+`,
+		Expected: `resource "aws_instance" "web" {
+  dynamic "ebs" {
+    for_each = []
+    content {
+      lifecycle {
+        removed {
+          from = aws_instance.nested
+        }
+      }
+    }
+  }
+}
+
+`,
+		Removed: 1,
+		Nested:  1,
+	},
+	{
+		Name:     "CRLF line endings",
+		File:     "main.tf",
+		Input:    "locals {}\r\n\r\nremoved {\r\n  from = aws_instance.old\r\n}\r\n",
+		Expected: "locals {}\r\n\r\n",
+		Removed:  1,
+	},
+	{
+		Name:     "UTF-8 byte order mark",
+		File:     "main.tf",
+		Input:    "\ufeffremoved {\n  from = aws_instance.old\n}\nlocals {}\n",
+		Expected: "\ufefflocals {}\n",
+		Removed:  1,
+	},
+	{
+		Name:     "no trailing newline",
+		File:     "main.tf",
+		Input:    "locals {}\n\nremoved {\n  from = aws_instance.old\n}",
+		Expected: "locals {}\n\n",
+		Removed:  1,
+	},
+	{
+		Name: "JSON syntax",
+		File: "main.tf.json",
+		Input: `{
+  "locals": {"removed": "not a block"},
+  "removed": [{"from": "aws_instance.old"}]
+}
+`,
+		Expected: `{
+  "locals": {"removed": "not a block"}
+}
+`,
+		Removed: 1,
+	},
+}
+
+// runCompat runs every case of the corpus in a scratch directory and writes
+// a PASS or FAIL line for each to w. It returns the number of failed cases.
+func runCompat(w io.Writer) (int, error) {
+	dir, err := os.MkdirTemp("", "terraform-removed-remover-compat")
+	if err != nil {
+		return 0, fmt.Errorf("error creating scratch directory: %w", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	failed := 0
+	for i, c := range compatCorpus {
+		caseDir := filepath.Join(dir, fmt.Sprint(i))
+		if err := os.Mkdir(caseDir, 0750); err != nil {
+			return failed, fmt.Errorf("error creating scratch directory: %w", err)
+		}
+		if problem := runCompatCase(filepath.Join(caseDir, c.File), c); problem != "" {
+			failed++
+			fmt.Fprintf(w, "FAIL %s: %s\n", c.Name, problem)
+		} else {
+			fmt.Fprintf(w, "PASS %s\n", c.Name)
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d of %d compatibility cases passed\n", len(compatCorpus)-failed, len(compatCorpus))
+	return failed, nil
+}
+
+// runCompatCase processes one case at path and describes how the result
+// differs from the expectation, or returns an empty string on success.
+func runCompatCase(path string, c compatCase) string {
+	if err := os.WriteFile(path, []byte(c.Input), 0600); err != nil {
+		return err.Error()
+	}
+
+	stats := Stats{}
+	if err := processFile(path, &stats); err != nil {
+		return err.Error()
+	}
+
+	output, err := os.ReadFile(path)
+	if err != nil {
+		return err.Error()
+	}
+	if !bytes.Equal(output, []byte(c.Expected)) {
+		return fmt.Sprintf("expected %q, got %q", c.Expected, output)
+	}
+	if stats.RemovedBlocksRemoved != c.Removed {
+		return fmt.Sprintf("expected %d removed blocks, got %d", c.Removed, stats.RemovedBlocksRemoved)
+	}
+	if len(stats.NestedFindings) != c.Nested {
+		return fmt.Sprintf("expected %d nested blocks reported, got %d", c.Nested, len(stats.NestedFindings))
+	}
+	return ""
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompatCorpus(t *testing.T) {
+	var output strings.Builder
+	failed, err := runCompat(&output)
+	if err != nil {
+		t.Fatalf("runCompat failed: %v", err)
+	}
+	if failed != 0 {
+		t.Errorf("Expected every compatibility case to pass, but %d failed:\n%s", failed, output.String())
+	}
+	if !strings.Contains(output.String(), "PASS heredoc containing removed") {
+		t.Errorf("Expected a PASS line per case, but got:\n%s", output.String())
+	}
+}
@@ -0,0 +1,482 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// configFileName is the configuration file looked for in the current
+// directory and its parents, up to the root of the git repository.
+const configFileName = ".terraform-removed-remover.hcl"
+
+// configurableFlags are the flags a configuration file may set: those that
+// select the files and blocks processed, shape the output written to them,
+// and set the policy for failures. Modes, reports, paths written to or read
+// from outside the tree, and anything reaching git or the network can only
+// be given on the command line, so a file checked into a repository never
+// changes what a run does beyond the tree's own cleanup.
+var configurableFlags = []string{
+	// Selection
+	"only",
+	"exclude-address",
+	"provider",
+	"type-prefix",
+	"module",
+	"ext",
+	"exclude-dir",
+	"max-depth",
+	"no-recursive",
+	"no-terraformignore",
+	"no-gitignore",
+	"include-dot-terraform",
+	"terragrunt",
+	"follow-symlinks",
+	"state-key",
+	"backend-config",
+	"max-file-size",
+	// Formatting
+	"normalize-whitespace",
+	"normalize-all",
+	"fmt-all",
+	"no-format",
+	"max-blank-lines",
+	"final-newline",
+	"line-endings",
+	"preserve-encoding",
+	"strip-leading-comments",
+	"strip-trailing-comments",
+	"tombstone",
+	// Policy
+	"skip-invalid",
+	"fail-fast",
+	"fail-on-change",
+}
+
+// configSetting is an attribute of a configuration file: the flag it sets
+// and the values it sets it to, more than one for a repeatable flag.
+type configSetting struct {
+	name   string
+	values []string
+	rng    hcl.Range
+}
+
+// findConfigFile returns the configuration file that applies in dir, the
+// first one found in dir or, with parents, a parent up to the first
+// directory holding .git, or "" when there is none.
+func findConfigFile(dir string, parents bool) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		candidate := filepath.Join(dir, configFileName)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		if !parents {
+			return "", nil
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// loadConfigFile reads the configuration file at path. Each attribute is
+// named after a flag and holds its value: a string, number, or bool, or for
+// repeatable flags a list of them.
+//
+//	exclude-dir          = ["examples/**"]
+//	normalize-whitespace = true
+//	output               = "json"
+func loadConfigFile(path string) ([]configSetting, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+	file, diags := hclsyntax.ParseConfig(content, path, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("error parsing config file: %s", diags.Error())
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, fmt.Errorf("unexpected body type in %s", path)
+	}
+	if len(body.Blocks) > 0 {
+		block := body.Blocks[0]
+		return nil, fmt.Errorf("%s: unexpected %s block; the config file only holds options", block.DefRange(), block.Type)
+	}
+
+	var settings []configSetting
+	for name, attr := range body.Attributes {
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("error parsing config file: %s", diags.Error())
+		}
+		values, err := configValues(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s %w", attr.NameRange, name, err)
+		}
+		settings = append(settings, configSetting{name: name, values: values, rng: attr.NameRange})
+	}
+	sort.Slice(settings, func(i, j int) bool {
+		return settings[i].rng.Start.Byte < settings[j].rng.Start.Byte
+	})
+	return settings, nil
+}
+
+// configValues returns value as flag values: itself for a primitive, or
+// each element of a list.
+func configValues(value cty.Value) ([]string, error) {
+	if value.IsNull() || !value.IsWhollyKnown() {
+		return nil, errors.New("must have a value")
+	}
+	if value.Type().IsListType() || value.Type().IsTupleType() || value.Type().IsSetType() {
+		var values []string
+		for it := value.ElementIterator(); it.Next(); {
+			_, element := it.Element()
+			s, err := configValue(element)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, s)
+		}
+		return values, nil
+	}
+	s, err := configValue(value)
+	if err != nil {
+		return nil, err
+	}
+	return []string{s}, nil
+}
+
+func configValue(value cty.Value) (string, error) {
+	if value.IsNull() {
+		return "", errors.New("must not be null")
+	}
+	switch value.Type() {
+	case cty.String:
+		return value.AsString(), nil
+	case cty.Bool:
+		return strconv.FormatBool(value.True()), nil
+	case cty.Number:
+		return value.AsBigFloat().Text('f', -1), nil
+	}
+	return "", fmt.Errorf("must be a string, number, or bool, not %s", value.Type().FriendlyName())
+}
+
+// applyConfig sets the flags in flags to the settings of a configuration
+// file, except those given on the command line, which win.
+func applyConfig(flags *flag.FlagSet, settings []configSetting) error {
+	given := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	for _, setting := range settings {
+		f := flags.Lookup(setting.name)
+		if f == nil {
+			return fmt.Errorf("%s: unknown option %q", setting.rng, setting.name)
+		}
+		if !slices.Contains(configurableFlags, setting.name) {
+			return fmt.Errorf("%s: %q can only be given on the command line", setting.rng, setting.name)
+		}
+		if given[setting.name] {
+			continue
+		}
+		if _, repeatable := f.Value.(*stringSliceFlag); !repeatable && len(setting.values) != 1 {
+			return fmt.Errorf("%s: %s takes a single value", setting.rng, setting.name)
+		}
+		// Setting through flags counts the flag as given, for the checks
+		// that look at which flags were
+		for _, value := range setting.values {
+			if err := flags.Set(setting.name, value); err != nil {
+				return fmt.Errorf("%s: invalid value %q for %s: %w", setting.rng, value, setting.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// fileOptions are the options a configuration file nested below the root
+// one can set for the files under its directory.
+type fileOptions struct {
+	// Ignore skips the files. It can only be set per directory.
+	Ignore                bool
+	NoFormat              bool
+	NormalizeWhitespace   bool
+	NormalizeAll          bool
+	PreserveEncoding      bool
+	FinalNewline          string
+	LineEndings           string
+	Only                  []string
+	ExcludeAddress        []*regexp.Regexp
+	Providers             []string
+	TypePrefixes          []string
+	Modules               []string
+	Tombstone             bool
+	StripLeadingComments  bool
+	StripTrailingComments bool
+}
+
+func (s *Stats) fileOptions() fileOptions {
+	return fileOptions{
+		NoFormat:              s.NoFormat,
+		NormalizeWhitespace:   s.NormalizeWhitespace,
+		NormalizeAll:          s.NormalizeAll,
+		PreserveEncoding:      s.PreserveEncoding,
+		FinalNewline:          s.FinalNewline,
+		LineEndings:           s.LineEndings,
+		Only:                  s.Only,
+		ExcludeAddress:        s.ExcludeAddress,
+		Providers:             s.Providers,
+		TypePrefixes:          s.TypePrefixes,
+		Modules:               s.Modules,
+		Tombstone:             s.Tombstone,
+		StripLeadingComments:  s.StripLeadingComments,
+		StripTrailingComments: s.StripTrailingComments,
+	}
+}
+
+func (s *Stats) setFileOptions(o fileOptions) {
+	s.NoFormat = o.NoFormat
+	s.NormalizeWhitespace = o.NormalizeWhitespace
+	s.NormalizeAll = o.NormalizeAll
+	s.PreserveEncoding = o.PreserveEncoding
+	s.FinalNewline = o.FinalNewline
+	s.LineEndings = o.LineEndings
+	s.Only = o.Only
+	s.ExcludeAddress = o.ExcludeAddress
+	s.Providers = o.Providers
+	s.TypePrefixes = o.TypePrefixes
+	s.Modules = o.Modules
+	s.Tombstone = o.Tombstone
+	s.StripLeadingComments = o.StripLeadingComments
+	s.StripTrailingComments = o.StripTrailingComments
+}
+
+// set applies a setting of a nested configuration file to o. Lists replace
+// the ones set further up rather than adding to them.
+func (o *fileOptions) set(setting configSetting) error {
+	flag := func(target *bool) error {
+		if len(setting.values) != 1 {
+			return fmt.Errorf("%s: %s takes a single value", setting.rng, setting.name)
+		}
+		value, err := strconv.ParseBool(setting.values[0])
+		if err != nil {
+			return fmt.Errorf("%s: invalid value %q for %s: %w", setting.rng, setting.values[0], setting.name, err)
+		}
+		*target = value
+		return nil
+	}
+
+	switch setting.name {
+	case "ignore":
+		return flag(&o.Ignore)
+	case "no-format":
+		return flag(&o.NoFormat)
+	case "normalize-whitespace":
+		return flag(&o.NormalizeWhitespace)
+	case "normalize-all":
+		return flag(&o.NormalizeAll)
+	case "preserve-encoding":
+		return flag(&o.PreserveEncoding)
+	case "tombstone":
+		return flag(&o.Tombstone)
+	case "strip-leading-comments":
+		return flag(&o.StripLeadingComments)
+	case "strip-trailing-comments":
+		return flag(&o.StripTrailingComments)
+	case "final-newline":
+		if len(setting.values) != 1 {
+			return fmt.Errorf("%s: %s takes a single value", setting.rng, setting.name)
+		}
+		policy, ok := parseFinalNewline(setting.values[0])
+		if !ok {
+			return fmt.Errorf("%s: invalid final-newline %q: must be always (one), preserve, or never (none)", setting.rng, setting.values[0])
+		}
+		o.FinalNewline = policy
+	case "line-endings":
+		if len(setting.values) != 1 {
+			return fmt.Errorf("%s: %s takes a single value", setting.rng, setting.name)
+		}
+		switch value := setting.values[0]; value {
+		case lineEndingsPreserve, lineEndingsLF, lineEndingsCRLF:
+			o.LineEndings = value
+		default:
+			return fmt.Errorf("%s: invalid line-endings %q: must be lf, crlf, or preserve", setting.rng, value)
+		}
+	case "only":
+		for _, pattern := range setting.values {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("%s: invalid only pattern %q: %w", setting.rng, pattern, err)
+			}
+		}
+		o.Only = setting.values
+	case "exclude-address":
+		o.ExcludeAddress = nil
+		for _, expr := range setting.values {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return fmt.Errorf("%s: invalid exclude-address expression %q: %w", setting.rng, expr, err)
+			}
+			o.ExcludeAddress = append(o.ExcludeAddress, re)
+		}
+	case "provider":
+		o.Providers = setting.values
+	case "type-prefix":
+		o.TypePrefixes = setting.values
+	case "module":
+		for _, module := range setting.values {
+			if address, err := parseAddress(module); err != nil || address.Kind != AddressKindModule {
+				return fmt.Errorf("%s: invalid module address %q: expected a module address like module.networking", setting.rng, module)
+			}
+		}
+		o.Modules = setting.values
+	default:
+		return fmt.Errorf("%s: %q can't be set per directory", setting.rng, setting.name)
+	}
+	return nil
+}
+
+// dirConfigs finds the configuration files nested below the root one, whose
+// options apply to the files under their directory, like .editorconfig: a
+// file gets the options of every configuration file between it and the root
+// one, the closest winning.
+type dirConfigs struct {
+	// root is the configuration file applied through the flags, if any.
+	root string
+
+	mu sync.Mutex
+	// dirs caches the settings of the configuration file in each directory,
+	// nil when it has none.
+	dirs map[string][]configSetting
+}
+
+func newDirConfigs(root string) *dirConfigs {
+	if root != "" {
+		if abs, err := filepath.Abs(root); err == nil {
+			root = abs
+		}
+	}
+	return &dirConfigs{root: root, dirs: map[string][]configSetting{}}
+}
+
+// options returns base with the settings of the configuration files that
+// apply to filePath. The walk up from its directory stops at the root
+// configuration file or the top of the git repository, whose configuration
+// file is never a nested one.
+func (c *dirConfigs) options(filePath string, base fileOptions) (fileOptions, error) {
+	_, chain, err := c.chain(filePath)
+	if err != nil {
+		return base, err
+	}
+
+	options := base
+	for i := len(chain) - 1; i >= 0; i-- {
+		for _, setting := range chain[i] {
+			if err := options.set(setting); err != nil {
+				return base, err
+			}
+		}
+	}
+	return options, nil
+}
+
+// paths returns the nested configuration files that apply to any of files,
+// sorted.
+func (c *dirConfigs) paths(files []string) ([]string, error) {
+	seen := map[string]bool{}
+	for _, file := range files {
+		paths, _, err := c.chain(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			seen[path] = true
+		}
+	}
+	paths := make([]string, 0, len(seen))
+	for path := range seen {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// chain returns the nested configuration files that apply to filePath and
+// their settings, the closest first.
+func (c *dirConfigs) chain(filePath string) ([]string, [][]configSetting, error) {
+	dir, err := filepath.Abs(filepath.Dir(filePath))
+	if err != nil {
+		return nil, nil, err
+	}
+	var paths []string
+	var chain [][]configSetting
+	for {
+		if filepath.Join(dir, configFileName) == c.root {
+			break
+		}
+		// The one at the top of the repository is only ever the root one
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+		settings, err := c.load(dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		if settings != nil {
+			paths = append(paths, filepath.Join(dir, configFileName))
+			chain = append(chain, settings)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return paths, chain, nil
+}
+
+// load returns the settings of the configuration file in dir.
+func (c *dirConfigs) load(dir string) ([]configSetting, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if settings, ok := c.dirs[dir]; ok {
+		return settings, nil
+	}
+	var settings []configSetting
+	candidate := filepath.Join(dir, configFileName)
+	if _, err := os.Stat(candidate); err == nil {
+		if settings, err = loadConfigFile(candidate); err != nil {
+			return nil, err
+		}
+		if settings == nil {
+			settings = []configSetting{}
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	c.dirs[dir] = settings
+	return settings, nil
+}
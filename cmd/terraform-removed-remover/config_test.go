@@ -0,0 +1,189 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfigFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-config-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	nested := filepath.Join(tempDir, "repo", "envs", "prod")
+	if err := os.MkdirAll(nested, 0750); err != nil {
+		t.Fatalf("Failed to create dirs: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(tempDir, "repo", ".git"), 0750); err != nil {
+		t.Fatalf("Failed to create dirs: %v", err)
+	}
+	// Above the repository, so never found from inside it
+	if err := os.WriteFile(filepath.Join(tempDir, configFileName), []byte("dry-run = true\n"), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if found, err := findConfigFile(nested, true); err != nil || found != "" {
+		t.Errorf("Expected no config file inside the repository, but got %q, %v", found, err)
+	}
+
+	configPath := filepath.Join(tempDir, "repo", configFileName)
+	config := `exclude-dir          = ["examples/**", "vendor"]
+normalize-whitespace = true
+final-newline        = "always"
+max-depth            = 3
+`
+	if err := os.WriteFile(configPath, []byte(config), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	found, err := findConfigFile(nested, true)
+	if err != nil || found != configPath {
+		t.Errorf("Expected %q, but got %q, %v", configPath, found, err)
+	}
+
+	settings, err := loadConfigFile(configPath)
+	if err != nil {
+		t.Fatalf("loadConfigFile failed: %v", err)
+	}
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	var excludeDir stringSliceFlag
+	flags.Var(&excludeDir, "exclude-dir", "")
+	normalize := flags.Bool("normalize-whitespace", false, "")
+	finalNewline := flags.String("final-newline", "", "")
+	maxDepth := flags.Int("max-depth", 0, "")
+	if err := flags.Parse([]string{"-final-newline", "never"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if err := applyConfig(flags, settings); err != nil {
+		t.Fatalf("applyConfig failed: %v", err)
+	}
+	if !reflect.DeepEqual([]string(excludeDir), []string{"examples/**", "vendor"}) || !*normalize || *maxDepth != 3 {
+		t.Errorf("Expected the config file's options, but got %v, %v, %v", excludeDir, *normalize, *maxDepth)
+	}
+	if *finalNewline != "never" {
+		t.Errorf("Expected the command line to win, but got final-newline %q", *finalNewline)
+	}
+}
+
+func TestApplyConfigErrors(t *testing.T) {
+	for _, tt := range []struct {
+		config   string
+		expected string
+	}{
+		{config: "bogus = true\n", expected: `unknown option "bogus"`},
+		{config: "config = \"other.hcl\"\n", expected: `"config" can only be given on the command line`},
+		{config: "output = \"json\"\n", expected: `"output" can only be given on the command line`},
+		{config: "dry-run = true\n", expected: `"dry-run" can only be given on the command line`},
+		{config: "audit-log = \"/tmp/audit.jsonl\"\n", expected: `"audit-log" can only be given on the command line`},
+		{config: "final-newline = [\"always\", \"never\"]\n", expected: "takes a single value"},
+		{config: "skip-invalid = \"maybe\"\n", expected: "invalid value"},
+		{config: "final-newline = { policy = \"always\" }\n", expected: "must be a string, number, or bool"},
+		{config: "filters {\n}\n", expected: "unexpected filters block"},
+	} {
+		t.Run(tt.expected, func(t *testing.T) {
+			tempDir, err := os.MkdirTemp("", "terraform-config-test")
+			if err != nil {
+				t.Fatalf("Failed to create temp dir: %v", err)
+			}
+			defer func() {
+				if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+					_ = removeErr // Ignore cleanup errors in tests
+				}
+			}()
+			configPath := filepath.Join(tempDir, configFileName)
+			if err := os.WriteFile(configPath, []byte(tt.config), 0600); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			newCLIFlags(flags)
+			settings, err := loadConfigFile(configPath)
+			if err == nil {
+				err = applyConfig(flags, settings)
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected an error containing %q, but got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestDirConfigs(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-config-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := "removed {\n  from = aws_instance.old\n}\n\nremoved {\n  from = module.vpc.aws_subnet.a\n}\n"
+	configs := map[string]string{
+		"":             "output = \"json\"\n",
+		"modules":      "only = [\"aws_*\"]\n",
+		"modules/net":  "only = [\"module.*\"]\n",
+		"modules/old":  "ignore = true\n",
+		"modules/none": "",
+	}
+	if err := os.Mkdir(filepath.Join(tempDir, ".git"), 0750); err != nil {
+		t.Fatalf("Failed to create dirs: %v", err)
+	}
+	for dir, config := range configs {
+		if err := os.MkdirAll(filepath.Join(tempDir, dir), 0750); err != nil {
+			t.Fatalf("Failed to create dirs: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(tempDir, dir, configFileName), []byte(config), 0600); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(tempDir, dir, "main.tf"), []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+
+	stats := &Stats{DryRun: true, Only: []string{"*"}, DirConfigs: newDirConfigs("")}
+	for _, dir := range []string{"", "modules", "modules/net", "modules/old", "modules/none"} {
+		if err := processFile(filepath.Join(tempDir, dir, "main.tf"), stats); err != nil {
+			t.Fatalf("processFile failed for %q: %v", dir, err)
+		}
+	}
+
+	var found []string
+	for _, finding := range stats.Findings {
+		rel, _ := filepath.Rel(tempDir, finding.File)
+		found = append(found, filepath.ToSlash(rel)+" "+finding.Address)
+	}
+	expected := []string{
+		"main.tf aws_instance.old",
+		"main.tf module.vpc.aws_subnet.a",
+		"modules/main.tf aws_instance.old",
+		"modules/net/main.tf module.vpc.aws_subnet.a",
+		"modules/none/main.tf aws_instance.old",
+	}
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("Expected %v, but got %v", expected, found)
+	}
+	if stats.FilesIgnored != 1 {
+		t.Errorf("Expected 1 ignored file, but got %d", stats.FilesIgnored)
+	}
+	if !reflect.DeepEqual(stats.Only, []string{"*"}) {
+		t.Errorf("Expected the options restored after each file, but got %v", stats.Only)
+	}
+
+	if err := os.WriteFile(filepath.Join(tempDir, "modules", configFileName), []byte("output = \"json\"\n"), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	err = processFile(filepath.Join(tempDir, "modules", "main.tf"), &Stats{DryRun: true, DirConfigs: newDirConfigs("")})
+	if err == nil || !strings.Contains(err.Error(), `"output" can't be set per directory`) {
+		t.Errorf("Expected an error for a global option, but got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// consolidatedFile is the file in each module directory that the
+// consolidate subcommand moves removed blocks into.
+const consolidatedFile = "removed.tf"
+
+// consolidateBlocks appends the source of blocks, about to be deleted from
+// filePath, to the removed.tf next to it, creating it with filePath's
+// permissions if needed. It runs before filePath is rewritten, so a failed
+// run can leave a block in both files but never in neither.
+func consolidateBlocks(filePath string, blocks []removedBlock, content []byte, stats *Stats) error {
+	target := filepath.Join(filepath.Dir(filePath), consolidatedFile)
+
+	existing, err := os.ReadFile(target)
+	exists := err == nil
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error reading %s: %w", target, err)
+	}
+
+	var result bytes.Buffer
+	result.Write(existing)
+	for _, block := range blocks {
+		if result.Len() > 0 {
+			if !bytes.HasSuffix(result.Bytes(), []byte("\n")) {
+				result.WriteByte('\n')
+			}
+			result.WriteByte('\n')
+		}
+		result.Write(content[block.start:block.end])
+		result.WriteByte('\n')
+	}
+	formatted := result.Bytes()
+	if !stats.NoFormat {
+		formatted = hclwrite.Format(formatted)
+	}
+
+	if exists || stats.WriteFile != nil {
+		return writeConfigFile(target, formatted, stats)
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return fmt.Errorf("error writing file %s: %w", target, err)
+	}
+	if err := writeConfigFile(target, formatted, stats); err != nil {
+		return err
+	}
+	if err := os.Chmod(target, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("error writing file %s: %w", target, err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConsolidateMovesBlocksIntoRemovedTF(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-consolidate-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	files := map[string]string{
+		"main.tf":    "resource \"aws_instance\" \"web\" {}\n\nremoved {\n  from = aws_instance.old\n}\n",
+		"storage.tf": "removed {\n    from = aws_s3_bucket.logs\n  lifecycle {\n    destroy = false\n  }\n}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+
+	stats := &Stats{Consolidate: true}
+	for _, name := range []string{"main.tf", "storage.tf", consolidatedFile} {
+		if err := processFile(filepath.Join(tempDir, name), stats); err != nil {
+			t.Fatalf("processFile(%s) failed: %v", name, err)
+		}
+	}
+	if stats.RemovedBlocksRemoved != 2 {
+		t.Errorf("Expected 2 blocks consolidated, but got %d", stats.RemovedBlocksRemoved)
+	}
+
+	expected := map[string]string{
+		"main.tf":        "resource \"aws_instance\" \"web\" {}\n\n",
+		"storage.tf":     "",
+		consolidatedFile: "removed {\n  from = aws_instance.old\n}\n\nremoved {\n  from = aws_s3_bucket.logs\n  lifecycle {\n    destroy = false\n  }\n}\n",
+	}
+	for name, content := range expected {
+		data, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		if string(data) != content {
+			t.Errorf("Expected %s to be %q, but got %q", name, content, string(data))
+		}
+	}
+
+	info, err := os.Stat(filepath.Join(tempDir, consolidatedFile))
+	if err != nil {
+		t.Fatalf("Failed to stat %s: %v", consolidatedFile, err)
+	}
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("Expected %s to get the permissions of main.tf, but got %v", consolidatedFile, info.Mode().Perm())
+	}
+}
+
+func TestConsolidateMovesCommentsAndIgnoresDeletionFilters(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-consolidate-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := "# Waiting on prod\n# terraform-removed-remover:keep\nremoved {\n  from = aws_instance.old\n}\n\n# Dropped in v3\n# remove-after: 2999-01-01\nremoved {\n  from = aws_instance.older\n}\n"
+	filePath := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	root, err := resolveRoot(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to resolve %s: %v", tempDir, err)
+	}
+	stats := &Stats{Consolidate: true, OlderThan: 24 * time.Hour, ResolvedRoots: []string{root}}
+	if err := processFile(filePath, stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	if stats.RemovedBlocksRemoved != 2 {
+		t.Errorf("Expected 2 blocks consolidated, but got %d", stats.RemovedBlocksRemoved)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tempDir, consolidatedFile))
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", consolidatedFile, err)
+	}
+	if string(data) != content {
+		t.Errorf("Expected %s to be %q, but got %q", consolidatedFile, content, string(data))
+	}
+	if data, err = os.ReadFile(filePath); err != nil || len(bytes.TrimSpace(data)) > 0 {
+		t.Errorf("Expected main.tf to be left empty, but got %q (%v)", data, err)
+	}
+}
+
+func TestWriteConfigFileNewFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-consolidate-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	if err := os.Mkdir(filepath.Join(tempDir, "root"), 0755); err != nil {
+		t.Fatalf("Failed to create root: %v", err)
+	}
+	root, err := resolveRoot(filepath.Join(tempDir, "root"))
+	if err != nil {
+		t.Fatalf("Failed to resolve root: %v", err)
+	}
+
+	stats := &Stats{ResolvedRoots: []string{root}, Backup: filepath.Join(tempDir, "backup")}
+	if err := writeConfigFile(filepath.Join(tempDir, "root", consolidatedFile), []byte("removed {}\n"), stats); err != nil {
+		t.Errorf("Expected a new file inside the root to be written, but got %v", err)
+	}
+	if err := writeConfigFile(filepath.Join(tempDir, consolidatedFile), []byte("removed {}\n"), stats); err == nil {
+		t.Errorf("Expected a new file outside the root to be refused")
+	}
+	if len(stats.Written) != 1 || len(stats.Backups) != 0 {
+		t.Errorf("Expected one file written and nothing backed up, but got %v and %v", stats.Written, stats.Backups)
+	}
+}
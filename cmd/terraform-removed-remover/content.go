@@ -0,0 +1,41 @@
+package main
+
+import (
+	"time"
+)
+
+// contentOptions returns the options in base fit for processing content in
+// memory: without results, and without the options that read or write
+// outside the content, such as -backup and -audit-log, or that need the
+// file's git history, such as -older-than and -blame.
+func contentOptions(base Stats) Stats {
+	base = base.withoutResults()
+	base.DryRun = false
+	base.Consolidate = false
+	base.Backup, base.StageDir = "", ""
+	base.AuditLog, base.Archive, base.DiffOutput, base.Edits = nil, nil, nil, nil
+	base.Inventory, base.Selection, base.Prompter = nil, nil, nil
+	base.ResolvedRoots, base.DirConfigs = nil, nil
+	base.OlderThan, base.Blame, base.Owners = 0, false, false
+	return base
+}
+
+// processContent processes content as a file called name, in memory, and
+// returns the result and the stats of processing it. Nothing is read or
+// written, so it works where there is no filesystem, as in the browser;
+// base should come from contentOptions.
+func processContent(name string, content []byte, base Stats) ([]byte, *Stats, error) {
+	result := content
+	stats := base.withoutResults()
+	stats.WriteFile = func(_ string, written []byte) error {
+		result = written
+		return nil
+	}
+	stats.StartTime = time.Now()
+	err := processSource(name, content, &stats)
+	stats.EndTime = time.Now()
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, &stats, nil
+}
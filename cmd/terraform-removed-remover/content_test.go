@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProcessContent(t *testing.T) {
+	content := "removed {\n  from = aws_instance.old\n}\n\nresource \"aws_instance\" \"web\" {}\n"
+	var auditLog bytes.Buffer
+	result, stats, err := processContent("main.tf", []byte(content), contentOptions(Stats{AuditLog: &auditLog, Backup: "bak", OlderThan: time.Hour, Blame: true, Owners: true}))
+	if err != nil {
+		t.Fatalf("processContent failed: %v", err)
+	}
+	expected := "\nresource \"aws_instance\" \"web\" {}\n"
+	if string(result) != expected {
+		t.Errorf("Expected %q, but got %q", expected, string(result))
+	}
+	if stats.RemovedBlocksRemoved != 1 || len(stats.Findings) != 1 || stats.Findings[0].File != "main.tf" {
+		t.Errorf("Expected one block removed from main.tf, but got %+v", stats)
+	}
+	if auditLog.Len() != 0 {
+		t.Errorf("Expected nothing in the audit log, but got %q", auditLog.String())
+	}
+
+	clean := "resource \"aws_instance\" \"web\" {}\n"
+	result, _, err = processContent("main.tf", []byte(clean), contentOptions(Stats{}))
+	if err != nil {
+		t.Fatalf("processContent failed: %v", err)
+	}
+	if string(result) != clean {
+		t.Errorf("Expected a clean file unchanged, but got %q", string(result))
+	}
+
+	_, _, err = processContent("main.tf", []byte("removed {"), contentOptions(Stats{}))
+	var parseErr *parseError
+	if !errors.As(err, &parseErr) {
+		t.Errorf("Expected a parse error, but got %v", err)
+	}
+}
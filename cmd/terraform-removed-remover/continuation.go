@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+)
+
+// defaultContinuationFile is where the continuation token is written when
+// -continue is not given.
+const defaultContinuationFile = ".removed-remover-continue.json"
+
+// continuationToken is written when -max-duration runs out: the files a
+// later run with -continue still has to process, in order.
+type continuationToken struct {
+	ToolVersion string   `json:"tool_version"`
+	Files       []string `json:"files"`
+}
+
+// loadContinuation reads the token at path, or returns nil if there is none.
+func loadContinuation(path string) (*continuationToken, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading continuation token: %w", err)
+	}
+
+	var token continuationToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("error reading continuation token %s: %w", path, err)
+	}
+	if token.ToolVersion != Version {
+		return nil, fmt.Errorf("continuation token %s was written by version %s, not %s", path, token.ToolVersion, Version)
+	}
+	return &token, nil
+}
+
+// discovery returns the files of the token that still exist, warning about
+// the others on logger.
+func (token *continuationToken) discovery(logger *slog.Logger) *Discovery {
+	discovery := &Discovery{}
+	for _, file := range token.Files {
+		if _, err := os.Stat(file); err != nil {
+			logger.Warn("Skipping file from continuation token", "file", file, "error", err)
+			continue
+		}
+		discovery.Files = append(discovery.Files, file)
+	}
+	return discovery
+}
+
+// writeContinuation saves files as the token at path.
+func writeContinuation(path string, files []string) error {
+	data, err := json.MarshalIndent(continuationToken{ToolVersion: Version, Files: files}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing continuation token: %w", err)
+	}
+	return nil
+}
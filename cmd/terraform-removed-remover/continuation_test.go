@@ -0,0 +1,51 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestContinuationToken(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-continuation-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	tokenPath := filepath.Join(tempDir, "token.json")
+	token, err := loadContinuation(tokenPath)
+	if err != nil || token != nil {
+		t.Fatalf("Expected no token before one is written, but got %+v, %v", token, err)
+	}
+
+	existing := filepath.Join(tempDir, "b.tf")
+	if err := os.WriteFile(existing, []byte(""), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	deleted := filepath.Join(tempDir, "a.tf")
+	if err := writeContinuation(tokenPath, []string{deleted, existing}); err != nil {
+		t.Fatalf("writeContinuation failed: %v", err)
+	}
+
+	token, err = loadContinuation(tokenPath)
+	if err != nil {
+		t.Fatalf("loadContinuation failed: %v", err)
+	}
+	if discovery := token.discovery(slog.New(slog.DiscardHandler)); !slices.Equal(discovery.Files, []string{existing}) {
+		t.Errorf("Expected only the files that still exist, but got %v", discovery.Files)
+	}
+
+	if err := os.WriteFile(tokenPath, []byte(`{"tool_version": "0.0.0-old", "files": []}`), 0600); err != nil {
+		t.Fatalf("Failed to write token: %v", err)
+	}
+	if _, err := loadContinuation(tokenPath); err == nil {
+		t.Errorf("Expected error for a token from another version, but got nil")
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// parseFailure returns the error for a file whose content failed to parse,
+// rendering diags the way terraform does: each with its file, line, and
+// column and the offending source lines, so the file can be fixed.
+func parseFailure(filePath string, content []byte, diags hcl.Diagnostics) error {
+	var out bytes.Buffer
+	files := map[string]*hcl.File{filePath: {Bytes: content}}
+	if err := hcl.NewDiagnosticTextWriter(&out, files, 0, false).WriteDiagnostics(diags); err != nil {
+		return fmt.Errorf("error parsing %s: %s", filePath, diags.Error())
+	}
+	// The snippet only names the line; lead with the column as well
+	location := filePath
+	for _, diag := range diags {
+		if diag.Severity == hcl.DiagError && diag.Subject != nil {
+			location = fmt.Sprintf("%s:%d:%d", filePath, diag.Subject.Start.Line, diag.Subject.Start.Column)
+			break
+		}
+	}
+	return fmt.Errorf("error parsing %s:\n%s", location, strings.TrimRight(out.String(), "\n"))
+}
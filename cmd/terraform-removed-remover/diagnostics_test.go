@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func TestParseFailureShowsSnippet(t *testing.T) {
+	content := []byte("resource \"aws_instance\" \"web\" {\n  tags = {\n    Name =\n  }\n}\n")
+	_, diags := hclsyntax.ParseConfig(content, "main.tf", hcl.Pos{Line: 1, Column: 1})
+	if !diags.HasErrors() {
+		t.Fatalf("Expected the test content not to parse")
+	}
+
+	message := parseFailure("main.tf", content, diags).Error()
+	for _, expected := range []string{
+		"error parsing main.tf:3:11:\n",
+		"Error: Invalid expression",
+		"on main.tf line 3:",
+		"   3:     Name =",
+	} {
+		if !strings.Contains(message, expected) {
+			t.Errorf("Expected the message to contain %q, but got:\n%s", expected, message)
+		}
+	}
+}
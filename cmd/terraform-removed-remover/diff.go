@@ -0,0 +1,427 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Diff algorithms for -diff-algorithm. Myers finds a minimal edit script;
+// patience and histogram anchor on distinctive lines first, which keeps
+// hunks aligned with blocks in large generated files where many lines, like
+// closing braces, repeat.
+const (
+	diffMyers     = "myers"
+	diffPatience  = "patience"
+	diffHistogram = "histogram"
+)
+
+// defaultDiffContext is the number of unchanged lines shown around changes.
+const defaultDiffContext = 3
+
+// histogramMaxOccurrences bounds how common a line may be and still anchor a
+// histogram diff, as in git. Regions without such a line fall back to Myers.
+const histogramMaxOccurrences = 64
+
+type diffOpKind int
+
+const (
+	diffEqual diffOpKind = iota
+	diffDelete
+	diffInsert
+)
+
+// diffOp is a single line of an edit script. a and b index the line in the
+// old and new text; only the one matching Kind is meaningful for deletions
+// and insertions.
+type diffOp struct {
+	Kind diffOpKind
+	a    int
+	b    int
+}
+
+// diffFunc computes the edit script turning a into b, whose first lines are
+// line aOff and bOff of the whole texts.
+type diffFunc func(a, b []int, aOff, bOff int) []diffOp
+
+func diffAlgorithm(name string) (diffFunc, bool) {
+	switch name {
+	case diffMyers:
+		return myersDiff, true
+	case diffPatience:
+		return patienceDiff, true
+	case diffHistogram:
+		return histogramDiff, true
+	default:
+		return nil, false
+	}
+}
+
+// splitLines splits content into lines, keeping their terminators.
+func splitLines(content []byte) []string {
+	var lines []string
+	for len(content) > 0 {
+		i := bytes.IndexByte(content, '\n')
+		if i < 0 {
+			lines = append(lines, string(content))
+			break
+		}
+		lines = append(lines, string(content[:i+1]))
+		content = content[i+1:]
+	}
+	return lines
+}
+
+// internLines maps equal lines of both texts to equal integers so the
+// algorithms compare numbers instead of strings.
+func internLines(a, b []string) ([]int, []int) {
+	ids := make(map[string]int)
+	intern := func(lines []string) []int {
+		out := make([]int, len(lines))
+		for i, line := range lines {
+			id, ok := ids[line]
+			if !ok {
+				id = len(ids)
+				ids[line] = id
+			}
+			out[i] = id
+		}
+		return out
+	}
+	return intern(a), intern(b)
+}
+
+func equalOps(aOff, bOff, n int) []diffOp {
+	ops := make([]diffOp, n)
+	for i := range ops {
+		ops[i] = diffOp{Kind: diffEqual, a: aOff + i, b: bOff + i}
+	}
+	return ops
+}
+
+// withCommonAffixes strips the lines a and b start and end with, diffs the
+// rest with diff, and puts the shared lines back around the result.
+func withCommonAffixes(a, b []int, aOff, bOff int, diff diffFunc) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := equalOps(aOff, bOff, prefix)
+	ops = append(ops, diff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], aOff+prefix, bOff+prefix)...)
+	return append(ops, equalOps(aOff+len(a)-suffix, bOff+len(b)-suffix, suffix)...)
+}
+
+// myersDiff is Myers' O(ND) algorithm in its linear space variant: it
+// finds the middle snake of the edit script, where the searches from both
+// ends meet, and recurses on either side of it. Keeping every round of a
+// single search instead takes memory proportional to D·(N+M), gigabytes for
+// large files that differ throughout.
+func myersDiff(a, b []int, aOff, bOff int) []diffOp {
+	return withCommonAffixes(a, b, aOff, bOff, myersDiffStripped)
+}
+
+// myersDiffStripped is myersDiff for texts that neither start nor end with
+// the same line, so a single change leaves one of them empty.
+func myersDiffStripped(a, b []int, aOff, bOff int) []diffOp {
+	if len(a) == 0 || len(b) == 0 {
+		var ops []diffOp
+		for i := range a {
+			ops = append(ops, diffOp{Kind: diffDelete, a: aOff + i})
+		}
+		for j := range b {
+			ops = append(ops, diffOp{Kind: diffInsert, b: bOff + j})
+		}
+		return ops
+	}
+
+	x, y, u, v := middleSnake(a, b)
+	ops := myersDiff(a[:x], b[:y], aOff, bOff)
+	ops = append(ops, equalOps(aOff+x, bOff+y, u-x)...)
+	return append(ops, myersDiff(a[u:], b[v:], aOff+u, bOff+v)...)
+}
+
+// middleSnake returns the run of equal lines from (x, y) to (u, v) in the
+// middle of a minimal edit script turning a into b. It searches forward from
+// the start and backward from the end, one edit at a time, until the
+// furthest reaching paths overlap; the backward search measures x from the
+// end of a. Either side of the snake holds about half of the edits.
+func middleSnake(a, b []int) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	// The searches meet before either makes more than half of the n+m
+	// edits, which bounds the diagonals they reach.
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	forward := make([]int, 2*maxD+3)
+	backward := make([]int, 2*maxD+3)
+
+	for d := 0; ; d++ {
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && a[u] == b[v] {
+				u++
+				v++
+			}
+			forward[offset+k] = u
+			if back := delta - k; odd && back >= -(d-1) && back <= d-1 && u+backward[offset+back] >= n {
+				return x, y, u, v
+			}
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var bx int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				bx = backward[offset+k+1]
+			} else {
+				bx = backward[offset+k-1] + 1
+			}
+			by := bx - k
+			ex, ey := bx, by
+			for ex < n && ey < m && a[n-1-ex] == b[m-1-ey] {
+				ex++
+				ey++
+			}
+			backward[offset+k] = ex
+			if fwd := delta - k; !odd && fwd >= -d && fwd <= d && ex+forward[offset+fwd] >= n {
+				return n - ex, m - ey, n - bx, m - by
+			}
+		}
+	}
+}
+
+// patienceDiff matches the lines that occur exactly once in both texts,
+// keeps the longest run of those matches that is in order in both, and
+// recurses between them. Regions without unique lines use Myers.
+func patienceDiff(a, b []int, aOff, bOff int) []diffOp {
+	return withCommonAffixes(a, b, aOff, bOff, func(a, b []int, aOff, bOff int) []diffOp {
+		countA := make(map[int]int)
+		for _, line := range a {
+			countA[line]++
+		}
+		countB := make(map[int]int)
+		posB := make(map[int]int)
+		for j, line := range b {
+			countB[line]++
+			posB[line] = j
+		}
+
+		// Unique matches in the order of a, as positions in b
+		var matchA, matchB []int
+		for i, line := range a {
+			if countA[line] == 1 && countB[line] == 1 {
+				matchA = append(matchA, i)
+				matchB = append(matchB, posB[line])
+			}
+		}
+		if len(matchA) == 0 {
+			return myersDiff(a, b, aOff, bOff)
+		}
+
+		anchors := longestIncreasing(matchB)
+		var ops []diffOp
+		prevA, prevB := 0, 0
+		for _, idx := range anchors {
+			i, j := matchA[idx], matchB[idx]
+			ops = append(ops, patienceDiff(a[prevA:i], b[prevB:j], aOff+prevA, bOff+prevB)...)
+			ops = append(ops, diffOp{Kind: diffEqual, a: aOff + i, b: bOff + j})
+			prevA, prevB = i+1, j+1
+		}
+		return append(ops, patienceDiff(a[prevA:], b[prevB:], aOff+prevA, bOff+prevB)...)
+	})
+}
+
+// longestIncreasing returns the indices of a longest strictly increasing
+// subsequence of values, by patience sorting.
+func longestIncreasing(values []int) []int {
+	var tails []int // index of the smallest tail of each pile
+	prev := make([]int, len(values))
+	for i, value := range values {
+		lo, hi := 0, len(tails)
+		for lo < hi {
+			mid := (lo + hi) / 2
+			if values[tails[mid]] < value {
+				lo = mid + 1
+			} else {
+				hi = mid
+			}
+		}
+		prev[i] = -1
+		if lo > 0 {
+			prev[i] = tails[lo-1]
+		}
+		if lo == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[lo] = i
+		}
+	}
+
+	result := make([]int, len(tails))
+	for i, k := len(tails)-1, tails[len(tails)-1]; i >= 0; i, k = i-1, prev[k] {
+		result[i] = k
+	}
+	return result
+}
+
+// histogramDiff anchors on the line of b that occurs least often in a,
+// extends that match as far as both texts agree, and recurses on both
+// sides, like git's histogram diff. Regions without a line rare enough to
+// anchor on use Myers.
+func histogramDiff(a, b []int, aOff, bOff int) []diffOp {
+	return withCommonAffixes(a, b, aOff, bOff, func(a, b []int, aOff, bOff int) []diffOp {
+		countA := make(map[int]int)
+		firstA := make(map[int]int)
+		for i := len(a) - 1; i >= 0; i-- {
+			countA[a[i]]++
+			firstA[a[i]] = i
+		}
+
+		bestA, bestB, bestCount := -1, -1, 0
+		for j, line := range b {
+			if count := countA[line]; count > 0 && (bestCount == 0 || count < bestCount) {
+				bestA, bestB, bestCount = firstA[line], j, count
+			}
+		}
+		if bestCount == 0 || bestCount > histogramMaxOccurrences {
+			return myersDiff(a, b, aOff, bOff)
+		}
+
+		start, end := 0, 1
+		for bestA-start > 0 && bestB-start > 0 && a[bestA-start-1] == b[bestB-start-1] {
+			start++
+		}
+		for bestA+end < len(a) && bestB+end < len(b) && a[bestA+end] == b[bestB+end] {
+			end++
+		}
+		lo, hi := bestA-start, bestA+end
+		loB, hiB := bestB-start, bestB+end
+
+		ops := histogramDiff(a[:lo], b[:loB], aOff, bOff)
+		ops = append(ops, equalOps(aOff+lo, bOff+loB, hi-lo)...)
+		return append(ops, histogramDiff(a[hi:], b[hiB:], aOff+hi, bOff+hiB)...)
+	})
+}
+
+// writeFileDiff writes the diff of a file to stats.DiffOutput using the
+// configured algorithm and context.
+func writeFileDiff(filePath string, before, after []byte, stats *Stats) error {
+	name := stats.DiffAlgorithm
+	if name == "" {
+		name = diffMyers
+	}
+	diff, ok := diffAlgorithm(name)
+	if !ok {
+		return fmt.Errorf("unknown diff algorithm %q", name)
+	}
+	if err := writeUnifiedDiff(stats.DiffOutput, filepath.ToSlash(filePath), before, after, diff, stats.DiffContext, stats.Color); err != nil {
+		return fmt.Errorf("error writing diff for %s: %w", filePath, err)
+	}
+	return nil
+}
+
+// writeUnifiedDiff writes the changes from before to after as a unified diff
+// with context lines of context around each change, colored like git's when
+// color is on.
+func writeUnifiedDiff(w io.Writer, name string, before, after []byte, diff diffFunc, context int, color bool) error {
+	linesA, linesB := splitLines(before), splitLines(after)
+	a, b := internLines(linesA, linesB)
+	ops := diff(a, b, 0, 0)
+
+	// Positions in both texts before each op, for hunk headers
+	posA := make([]int, len(ops)+1)
+	posB := make([]int, len(ops)+1)
+	for i, op := range ops {
+		posA[i+1], posB[i+1] = posA[i], posB[i]
+		if op.Kind != diffInsert {
+			posA[i+1]++
+		}
+		if op.Kind != diffDelete {
+			posB[i+1]++
+		}
+	}
+
+	var out bytes.Buffer
+	name = strings.TrimPrefix(name, "/")
+	fmt.Fprintf(&out, "%s\n%s\n", colorize(color, ansiBold, "--- a/"+name), colorize(color, ansiBold, "+++ b/"+name))
+	for i := 0; i < len(ops); {
+		if ops[i].Kind == diffEqual {
+			i++
+			continue
+		}
+
+		start := max(0, i-context)
+		end := i
+		for {
+			for end < len(ops) && ops[end].Kind != diffEqual {
+				end++
+			}
+			next := end
+			for next < len(ops) && ops[next].Kind == diffEqual && next-end < 2*context {
+				next++
+			}
+			if next < len(ops) && ops[next].Kind != diffEqual {
+				end = next
+				continue
+			}
+			end = min(len(ops), end+context)
+			break
+		}
+
+		header := fmt.Sprintf("@@ -%s +%s @@", hunkRange(posA[start], posA[end]), hunkRange(posB[start], posB[end]))
+		out.WriteString(colorize(color, ansiCyan, header) + "\n")
+		for _, op := range ops[start:end] {
+			switch op.Kind {
+			case diffEqual:
+				writeDiffLine(&out, ' ', linesA[op.a], color, "")
+			case diffDelete:
+				writeDiffLine(&out, '-', linesA[op.a], color, ansiRed)
+			case diffInsert:
+				writeDiffLine(&out, '+', linesB[op.b], color, ansiGreen)
+			}
+		}
+		i = end
+	}
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// hunkRange formats the start,count pair of a hunk header, where an empty
+// range names the line before it.
+func hunkRange(from, to int) string {
+	count := to - from
+	if count == 0 {
+		return fmt.Sprintf("%d,0", from)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", from+1)
+	}
+	return fmt.Sprintf("%d,%d", from+1, count)
+}
+
+func writeDiffLine(out *bytes.Buffer, prefix byte, line string, color bool, code string) {
+	text, terminated := strings.CutSuffix(line, "\n")
+	text = string(prefix) + text
+	if code != "" {
+		text = colorize(color, code, text)
+	}
+	out.WriteString(text)
+	out.WriteByte('\n')
+	if !terminated {
+		out.WriteString("\\ No newline at end of file\n")
+	}
+}
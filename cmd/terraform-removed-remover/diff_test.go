@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// applyOps rebuilds the new text from an edit script, checking that the
+// script walks both texts in order.
+func applyOps(t *testing.T, a, b []int, ops []diffOp) []int {
+	t.Helper()
+
+	var out []int
+	i, j := 0, 0
+	for _, op := range ops {
+		switch op.Kind {
+		case diffEqual:
+			if op.a != i || op.b != j || a[i] != b[j] {
+				t.Fatalf("Invalid equal op %+v at a=%d b=%d", op, i, j)
+			}
+			out = append(out, a[i])
+			i++
+			j++
+		case diffDelete:
+			if op.a != i {
+				t.Fatalf("Invalid delete op %+v at a=%d", op, i)
+			}
+			i++
+		case diffInsert:
+			if op.b != j {
+				t.Fatalf("Invalid insert op %+v at b=%d", op, j)
+			}
+			out = append(out, b[j])
+			j++
+		}
+	}
+	if i != len(a) || j != len(b) {
+		t.Fatalf("Edit script stops at a=%d b=%d of %d and %d lines", i, j, len(a), len(b))
+	}
+	return out
+}
+
+func TestDiffAlgorithmsProduceValidScripts(t *testing.T) {
+	// A fixed linear congruential generator keeps the cases reproducible
+	seed := uint32(1)
+	next := func(n int) int {
+		seed = seed*1664525 + 1013904223
+		return int(seed>>16) % n
+	}
+	for _, name := range []string{diffMyers, diffPatience, diffHistogram} {
+		diff, ok := diffAlgorithm(name)
+		if !ok {
+			t.Fatalf("Expected %s to be a known algorithm", name)
+		}
+		for n := 0; n < 200; n++ {
+			a := make([]int, next(30))
+			for i := range a {
+				a[i] = next(6)
+			}
+			b := make([]int, next(30))
+			for i := range b {
+				b[i] = next(6)
+			}
+
+			out := applyOps(t, a, b, diff(a, b, 0, 0))
+			if len(out) != len(b) {
+				t.Fatalf("%s: expected %v, but got %v", name, b, out)
+			}
+		}
+	}
+
+	if _, ok := diffAlgorithm("minimal"); ok {
+		t.Errorf("Expected minimal to be an unknown algorithm")
+	}
+}
+
+func TestMyersDiffIsMinimal(t *testing.T) {
+	a, b := internLines(splitLines([]byte("a\nb\nc\na\nb\nb\na\n")), splitLines([]byte("c\nb\na\nb\na\nc\n")))
+	changes := 0
+	for _, op := range myersDiff(a, b, 0, 0) {
+		if op.Kind != diffEqual {
+			changes++
+		}
+	}
+	if changes != 5 {
+		t.Errorf("Expected 5 changed lines, but got %d", changes)
+	}
+}
+
+func TestMyersDiffIsMinimalOnRandomTexts(t *testing.T) {
+	seed := uint32(7)
+	next := func(n int) int {
+		seed = seed*1664525 + 1013904223
+		return int(seed>>16) % n
+	}
+	for n := 0; n < 200; n++ {
+		a := make([]int, next(40))
+		for i := range a {
+			a[i] = next(4)
+		}
+		b := make([]int, next(40))
+		for i := range b {
+			b[i] = next(4)
+		}
+
+		// The longest common subsequence, by dynamic programming
+		lcs := make([][]int, len(a)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+
+		ops := myersDiff(a, b, 0, 0)
+		applyOps(t, a, b, ops)
+		changes := 0
+		for _, op := range ops {
+			if op.Kind != diffEqual {
+				changes++
+			}
+		}
+		if expected := len(a) + len(b) - 2*lcs[0][0]; changes != expected {
+			t.Fatalf("Expected %d changed lines for %v and %v, but got %d", expected, a, b, changes)
+		}
+	}
+}
+
+func TestMyersDiffLargeFileMemory(t *testing.T) {
+	// Every other line changes, so the edit script is as long as the file.
+	// Keeping every round of the search took gigabytes for such files.
+	const lines = 10000
+	a := make([]int, lines)
+	b := make([]int, lines)
+	for i := range a {
+		a[i], b[i] = i, i
+		if i%2 == 1 {
+			b[i] = lines + i
+		}
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	ops := myersDiff(a, b, 0, 0)
+	runtime.ReadMemStats(&after)
+
+	applyOps(t, a, b, ops)
+	if len(ops) != lines+lines/2 {
+		t.Errorf("Expected %d ops, but got %d", lines+lines/2, len(ops))
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 64<<20 {
+		t.Errorf("Expected the diff to allocate at most 64 MiB, but it allocated %d MiB", allocated>>20)
+	}
+}
+
+func TestWriteUnifiedDiff(t *testing.T) {
+	before := []byte("a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\n")
+	after := []byte("a\nc\nd\ne\nf\ng\nh\ni\nj\nK\nk")
+
+	var out bytes.Buffer
+	if err := writeUnifiedDiff(&out, "main.tf", before, after, myersDiff, 1, false); err != nil {
+		t.Fatalf("writeUnifiedDiff failed: %v", err)
+	}
+
+	expected := `--- a/main.tf
++++ b/main.tf
+@@ -1,3 +1,2 @@
+ a
+-b
+ c
+@@ -10,2 +9,3 @@
+ j
+-k
++K
++k
+\ No newline at end of file
+`
+	if out.String() != expected {
+		t.Errorf("Expected diff:\n%s\nBut got:\n%s", expected, out.String())
+	}
+
+	out.Reset()
+	if err := writeUnifiedDiff(&out, "main.tf", before, after, myersDiff, 4, false); err != nil {
+		t.Fatalf("writeUnifiedDiff failed: %v", err)
+	}
+	if strings.Count(out.String(), "@@ -") != 1 {
+		t.Errorf("Expected nearby changes to share a hunk with more context, but got:\n%s", out.String())
+	}
+}
+
+func TestPatienceDiffAlignsBlocks(t *testing.T) {
+	// Myers pairs the closing braces of different blocks; patience anchors
+	// on the unique block headers and deletes the removed block whole.
+	before := []byte("resource \"a\" \"x\" {\n}\n\nremoved {\n}\n\nresource \"b\" \"y\" {\n}\n")
+	after := []byte("resource \"a\" \"x\" {\n}\n\nresource \"b\" \"y\" {\n}\n")
+
+	var out bytes.Buffer
+	if err := writeUnifiedDiff(&out, "main.tf", before, after, patienceDiff, 0, false); err != nil {
+		t.Fatalf("writeUnifiedDiff failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "-removed {\n-}\n-\n") {
+		t.Errorf("Expected the removed block to be deleted as one run, but got:\n%s", out.String())
+	}
+}
+
+func TestDiffOutputInDryRun(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-diff-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := "locals {}\n\nremoved {\n  from = aws_instance.old\n}\n"
+	testFile := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	var out bytes.Buffer
+	stats := Stats{DryRun: true, DiffOutput: &out, DiffAlgorithm: diffHistogram, DiffContext: defaultDiffContext}
+	if err := processFile(testFile, &stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "-removed {\n-  from = aws_instance.old\n-}\n") {
+		t.Errorf("Expected the removed block in the diff, but got:\n%s", out.String())
+	}
+	unchanged, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	if string(unchanged) != content {
+		t.Errorf("Expected the dry run to leave the file untouched, but got:\n%s", unchanged)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// directivePrefix starts every directive comment, as in
+// "# terraform-removed-remover:keep".
+const directivePrefix = "terraform-removed-remover:"
+
+// directiveKeep above a removed block pins it: the block is never deleted,
+// though the consolidate subcommand still moves it.
+const directiveKeep = "keep"
+
+// directiveIgnoreFile in the first comment group of a file excludes the
+// whole file from processing.
+const directiveIgnoreFile = "ignore-file"
+
+// removeAfterPrefix starts the comment line giving the date after which a
+// removed block may be deleted, as in "# remove-after: 2025-03-01".
+const removeAfterPrefix = "remove-after:"
+
+// commentLines returns the text of each line of a comment group without its
+// comment markers.
+func commentLines(comment string) []string {
+	var lines []string
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(line)
+		for _, marker := range []string{"#", "//", "/*", "*"} {
+			line = strings.TrimPrefix(line, marker)
+		}
+		lines = append(lines, strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), "*/")))
+	}
+	return lines
+}
+
+// commentDirectives returns the directives in a comment group, the names
+// following directivePrefix at the start of a comment line. Anything after
+// the name, such as the reason a block is kept, is ignored.
+func commentDirectives(comment string) []string {
+	var directives []string
+	for _, line := range commentLines(comment) {
+		name, ok := strings.CutPrefix(line, directivePrefix)
+		if !ok {
+			continue
+		}
+		if fields := strings.Fields(name); len(fields) > 0 {
+			directives = append(directives, fields[0])
+		}
+	}
+	return directives
+}
+
+// removeAfter returns the date of the remove-after line in comment, as
+// written, and whether there is one.
+func removeAfter(comment string) (string, bool) {
+	for _, line := range commentLines(comment) {
+		if value, ok := strings.CutPrefix(line, removeAfterPrefix); ok {
+			if fields := strings.Fields(value); len(fields) > 0 {
+				return fields[0], true
+			}
+			return "", true
+		}
+	}
+	return "", false
+}
+
+// hasDirective reports whether comment holds the directive name.
+func hasDirective(comment, name string) bool {
+	for _, directive := range commentDirectives(comment) {
+		if directive == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoresFile reports whether the file at filePath opts out of processing
+// with an ignore-file directive: in the comment group at the top of an HCL
+// file, or in the top-level "//" member of a JSON file.
+func ignoresFile(filePath string, content []byte) bool {
+	if !bytes.Contains(content, []byte(directivePrefix+directiveIgnoreFile)) {
+		return false
+	}
+	if isJSONConfig(filePath) {
+		return hasDirective(jsonBlockComment(content), directiveIgnoreFile)
+	}
+	return hasDirective(firstCommentGroup(content), directiveIgnoreFile)
+}
+
+// firstCommentGroup returns the whole-line comments at the top of content,
+// after any blank lines, up to the first blank line or line of code.
+func firstCommentGroup(content []byte) string {
+	start := 0
+	for start < len(content) {
+		end := lineEndOf(content, start)
+		if len(bytes.TrimSpace(content[start:end])) > 0 {
+			break
+		}
+		start = end + 1
+	}
+	if start >= len(content) {
+		return ""
+	}
+
+	end := start
+	for end < len(content) {
+		lineEnd := lineEndOf(content, end)
+		line := bytes.TrimSpace(content[end:lineEnd])
+		if bytes.HasPrefix(line, []byte("/*")) {
+			// A block comment runs to the line that closes it
+			closing := bytes.Index(content[end:], []byte("*/"))
+			if closing < 0 {
+				break
+			}
+			lineEnd = lineEndOf(content, end+closing)
+		} else if !isLineComment(line) {
+			break
+		}
+		end = lineEnd + 1
+	}
+	return string(content[start:min(end, len(content))])
+}
+
+// jsonBlockComment returns the "//" member of a removed block object, the
+// comment of Terraform's JSON syntax, which may be a string or an array of
+// strings.
+func jsonBlockComment(object []byte) string {
+	var block struct {
+		Comment json.RawMessage `json:"//"`
+	}
+	if err := json.Unmarshal(object, &block); err != nil || block.Comment == nil {
+		return ""
+	}
+	var comment string
+	if err := json.Unmarshal(block.Comment, &comment); err == nil {
+		return comment
+	}
+	var lines []string
+	if err := json.Unmarshal(block.Comment, &lines); err == nil {
+		return strings.Join(lines, "\n")
+	}
+	return ""
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommentDirectives(t *testing.T) {
+	for _, tt := range []struct {
+		comment  string
+		expected []string
+	}{
+		{comment: "", expected: nil},
+		{comment: "# terraform-removed-remover:keep\n", expected: []string{"keep"}},
+		{comment: "# Waiting on prod\n// terraform-removed-remover:keep until July\n", expected: []string{"keep"}},
+		{comment: "/*\n * terraform-removed-remover:keep\n */\n", expected: []string{"keep"}},
+		{comment: "# see terraform-removed-remover:keep\n", expected: nil},
+	} {
+		if actual := commentDirectives(tt.comment); !slices.Equal(actual, tt.expected) {
+			t.Errorf("commentDirectives(%q) = %v, expected %v", tt.comment, actual, tt.expected)
+		}
+	}
+}
+
+func TestProcessFileKeepDirective(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-directive-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	kept := `# Waiting on the prod apply
+# terraform-removed-remover:keep
+removed {
+  from = aws_instance.pinned
+}
+`
+	files := map[string]string{
+		"main.tf": kept + "\nremoved {\n  from = aws_instance.old\n}\n",
+		"main.tf.json": `{
+  "removed": [
+    {"//": "terraform-removed-remover:keep", "from": "aws_s3_bucket.pinned"},
+    {"from": "aws_s3_bucket.old"}
+  ]
+}
+`,
+	}
+	stats := &Stats{}
+	for name, content := range files {
+		testFile := filepath.Join(tempDir, name)
+		if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+		if err := processFile(testFile, stats); err != nil {
+			t.Fatalf("processFile failed: %v", err)
+		}
+	}
+	if stats.RemovedBlocksRemoved != 2 || stats.RemovedBlocksSkipped != 2 {
+		t.Errorf("Expected 2 blocks removed and 2 kept, but got %d and %d", stats.RemovedBlocksRemoved, stats.RemovedBlocksSkipped)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tempDir, "main.tf"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	if string(data) != kept+"\n" {
+		t.Errorf("Expected %q, but got %q", kept+"\n", string(data))
+	}
+}
+
+func TestIgnoresFile(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		file     string
+		content  string
+		expected bool
+	}{
+		{name: "first line", file: "main.tf", content: "# terraform-removed-remover:ignore-file\nremoved {}\n", expected: true},
+		{name: "in first group", file: "main.tf", content: "\n# Generated by tfgen, do not edit\n// terraform-removed-remover:ignore-file\nremoved {}\n", expected: true},
+		{name: "block comment", file: "main.tf", content: "/*\n  Example only.\n  terraform-removed-remover:ignore-file\n*/\nremoved {}\n", expected: true},
+		{name: "after blank line", file: "main.tf", content: "# Example\n\n# terraform-removed-remover:ignore-file\nremoved {}\n", expected: false},
+		{name: "after code", file: "main.tf", content: "locals {}\n# terraform-removed-remover:ignore-file\n", expected: false},
+		{name: "json", file: "main.tf.json", content: `{"//": "terraform-removed-remover:ignore-file", "removed": {"from": "a.b"}}`, expected: true},
+		{name: "json block", file: "main.tf.json", content: `{"removed": {"//": "terraform-removed-remover:ignore-file", "from": "a.b"}}`, expected: false},
+	} {
+		if actual := ignoresFile(tt.file, []byte(tt.content)); actual != tt.expected {
+			t.Errorf("%s: ignoresFile = %v, expected %v", tt.name, actual, tt.expected)
+		}
+	}
+}
+
+func TestProcessFileIgnoreFileDirective(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-directive-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := "# terraform-removed-remover:ignore-file\nremoved {\n  from = aws_instance.example\n}\n"
+	testFile := filepath.Join(tempDir, "example.tf")
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	stats := &Stats{}
+	if err := processFile(testFile, stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	if stats.FilesIgnored != 1 || stats.FilesProcessed != 0 || len(stats.Findings) != 0 {
+		t.Errorf("Expected the file to be ignored, but got %+v", stats)
+	}
+	data, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	if string(data) != content {
+		t.Errorf("Expected the file to be left alone, but got %q", string(data))
+	}
+}
+
+func TestIsExpired(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.Local)
+	for _, tt := range []struct {
+		comment  string
+		expected bool
+		expiring int
+		warnings int
+	}{
+		{comment: "", expected: true},
+		{comment: "# remove-after: 2025-02-28\n", expected: true},
+		{comment: "# Dropped in v3\n# remove-after: 2025-03-01\n", expected: false, expiring: 1},
+		{comment: "// remove-after: 2025-04-15 one release later\n", expected: false},
+		{comment: "# remove-after: March\n", expected: false, warnings: 1},
+	} {
+		stats := &Stats{StartTime: now, ExpiringWithin: 30 * 24 * time.Hour}
+		block := removedBlock{Address: "aws_instance.old", Line: 3, comment: tt.comment}
+		if actual := isExpired("main.tf", block, stats); actual != tt.expected {
+			t.Errorf("isExpired(%q) = %v, expected %v", tt.comment, actual, tt.expected)
+		}
+		if len(stats.Expiring) != tt.expiring || len(stats.Warnings) != tt.warnings {
+			t.Errorf("%q: expected %d expiring and %d warnings, but got %v and %v", tt.comment, tt.expiring, tt.warnings, stats.Expiring, stats.Warnings)
+		}
+	}
+
+	stats := &Stats{StartTime: now, Pure: true}
+	if isExpired("main.tf", removedBlock{comment: "# remove-after: 2020-01-01\n"}, stats) {
+		t.Errorf("Expected -pure to keep blocks with a remove-after date")
+	}
+}
+
+func TestProcessFileRemoveAfter(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-directive-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	pending := "# remove-after: 2025-06-01\nremoved {\n  from = aws_instance.pending\n}\n"
+	content := pending + "\n# remove-after: 2025-01-01\nremoved {\n  from = aws_instance.expired\n}\n"
+	testFile := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	stats := &Stats{StartTime: time.Date(2025, 5, 20, 0, 0, 0, 0, time.Local), ExpiringWithin: 30 * 24 * time.Hour}
+	if err := processFile(testFile, stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+
+	data, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	if string(data) != pending+"\n" {
+		t.Errorf("Expected %q, but got %q", pending+"\n", string(data))
+	}
+
+	var out bytes.Buffer
+	printSummary(&out, stats)
+	if !strings.Contains(out.String(), testFile+":2: aws_instance.pending after 2025-06-01") {
+		t.Errorf("Expected the pending block in the summary, but got %q", out.String())
+	}
+}
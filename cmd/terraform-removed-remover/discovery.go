@@ -0,0 +1,371 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// Discovery holds the Terraform files found under a root directory.
+type Discovery struct {
+	// Files are processed normally.
+	Files []string
+	// Ignored are files Terraform itself would not load, such as hidden
+	// files and anything inside a hidden directory like .terraform. They are
+	// scanned for reporting only.
+	Ignored []string
+	// Symlinks are the symbolic links the walk skipped because
+	// FollowSymlinks is off.
+	Symlinks []string
+}
+
+// defaultExtensions are the file suffixes processed when no -ext is given:
+// Terraform's, in native and JSON syntax, and the OpenTofu-only variants
+// that OpenTofu 1.8 and later load alongside them.
+var defaultExtensions = []string{".tf", jsonConfigSuffix, ".tofu", tofuJSONConfigSuffix}
+
+// DiscoveryOptions control which files the walk picks up.
+type DiscoveryOptions struct {
+	// Extensions are the file suffixes to process, e.g. ".tf" or ".tfpart".
+	// Empty means defaultExtensions.
+	Extensions []string
+	// LimitDepth restricts the walk to MaxDepth directory levels below the
+	// root. With a MaxDepth of 0 only files directly in the root are found.
+	LimitDepth bool
+	MaxDepth   int
+	// IncludeDotTerraform walks .terraform directories and processes the
+	// files inside them like any other. By default they are skipped: they
+	// hold vendored module copies that terraform init overwrites.
+	IncludeDotTerraform bool
+	// ExcludeDirs are doublestar patterns, relative to the root, for
+	// directories that are never walked, e.g. examples/**.
+	ExcludeDirs []string
+	// Terragrunt also picks up terragrunt.hcl files, whose generate blocks
+	// are cleaned by processTerragruntFile.
+	Terragrunt bool
+	// FollowSymlinks walks symlinked directories and processes symlinked
+	// files. Every real directory is walked once, which breaks cycles, and
+	// files reached through several links are processed once. By default
+	// symlinks are skipped.
+	FollowSymlinks bool
+}
+
+// includes reports whether path is a file the walk should pick up.
+func (o DiscoveryOptions) includes(path string) bool {
+	return o.hasExtension(path) || (o.Terragrunt && isTerragruntConfig(path))
+}
+
+// excludesDir reports whether rel, a slash-separated directory path relative
+// to the root, matches one of the ExcludeDirs patterns.
+func (o DiscoveryOptions) excludesDir(rel string) bool {
+	for _, pattern := range o.ExcludeDirs {
+		if matchDoublestar(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasExtension reports whether path ends in one of the configured suffixes.
+func (o DiscoveryOptions) hasExtension(path string) bool {
+	extensions := o.Extensions
+	if len(extensions) == 0 {
+		extensions = defaultExtensions
+	}
+	for _, ext := range extensions {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func findTerraformFiles(rootDir string) ([]string, error) {
+	discovery, err := discoverFiles(rootDir, DiscoveryOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return discovery.Files, nil
+}
+
+func discoverFiles(rootDir string, opts DiscoveryOptions) (*Discovery, error) {
+	return discoverFilesContext(context.Background(), rootDir, opts)
+}
+
+// discoverFilesContext is discoverFiles that stops walking when ctx is done,
+// returning what was found so far and ctx.Err().
+func discoverFilesContext(ctx context.Context, rootDir string, opts DiscoveryOptions) (*Discovery, error) {
+	discovery := &Discovery{}
+
+	// Explicitly given roots are always resolved; walks run over the real
+	// directories, reporting paths under the name they were reached by.
+	realRoot, err := filepath.EvalSymlinks(rootDir)
+	if err != nil {
+		return discovery, fmt.Errorf("error accessing path %s: %w", rootDir, err)
+	}
+	visitedDirs := map[string]bool{realRoot: true}
+	seenFiles := make(map[string]bool)
+
+	// Links are followed after the regular walk, so files and directories
+	// reachable both ways are reported under their own paths.
+	type link struct{ path, target string }
+	var pendingDirs, pendingFiles []link
+
+	addFile := func(path string) {
+		if !opts.includes(path) {
+			return
+		}
+		if opts.FollowSymlinks {
+			if real, err := filepath.EvalSymlinks(path); err == nil {
+				if seenFiles[real] {
+					return
+				}
+				seenFiles[real] = true
+			}
+		}
+
+		if isIgnoredPath(rootDir, path, opts.IncludeDotTerraform) {
+			discovery.Ignored = append(discovery.Ignored, path)
+		} else {
+			discovery.Files = append(discovery.Files, path)
+		}
+	}
+
+	visit := func(path string, info os.FileInfo) error {
+		if info.Mode()&os.ModeSymlink != 0 {
+			if !opts.FollowSymlinks {
+				discovery.Symlinks = append(discovery.Symlinks, path)
+				return nil
+			}
+			target, err := filepath.EvalSymlinks(path)
+			if err != nil {
+				// Dangling links have nothing to process
+				return nil
+			}
+			targetInfo, err := os.Stat(target)
+			if err != nil {
+				return fmt.Errorf("error accessing path %s: %w", path, err)
+			}
+			if targetInfo.IsDir() {
+				pendingDirs = append(pendingDirs, link{path, target})
+			} else {
+				pendingFiles = append(pendingFiles, link{path, target})
+			}
+			return nil
+		}
+
+		if info.IsDir() {
+			if info.Name() == ".terraform" && path != rootDir && !opts.IncludeDotTerraform {
+				return filepath.SkipDir
+			}
+			if opts.LimitDepth && pathDepth(rootDir, path) > opts.MaxDepth {
+				return filepath.SkipDir
+			}
+			if rel, err := filepath.Rel(rootDir, path); err == nil && rel != "." && opts.excludesDir(filepath.ToSlash(rel)) {
+				return filepath.SkipDir
+			}
+			if opts.FollowSymlinks && path != rootDir {
+				// Cycles and second links to the same tree stop here
+				if real, err := filepath.EvalSymlinks(path); err == nil {
+					if visitedDirs[real] {
+						return filepath.SkipDir
+					}
+					visitedDirs[real] = true
+				}
+			}
+			return nil
+		}
+
+		addFile(path)
+		return nil
+	}
+
+	// walk runs over realRoot, reporting paths below displayRoot, the name
+	// it was reached by.
+	walk := func(displayRoot, realRoot string) error {
+		return filepath.Walk(realRoot, func(path string, info os.FileInfo, err error) error {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			rel, relErr := filepath.Rel(realRoot, path)
+			if relErr != nil {
+				return relErr
+			}
+			displayPath := filepath.Join(displayRoot, rel)
+			if rel == "." {
+				displayPath = displayRoot
+			}
+			if err != nil {
+				return fmt.Errorf("error accessing path %s: %w", displayPath, err)
+			}
+			return visit(displayPath, info)
+		})
+	}
+
+	if err := walk(rootDir, realRoot); err != nil {
+		return discovery, err
+	}
+	for len(pendingDirs) > 0 {
+		next := pendingDirs[0]
+		pendingDirs = pendingDirs[1:]
+		if err := walk(next.path, next.target); err != nil {
+			return discovery, err
+		}
+	}
+	for _, file := range pendingFiles {
+		addFile(file.path)
+	}
+	return discovery, nil
+}
+
+// pathDepth returns how many directory levels path is below rootDir.
+func pathDepth(rootDir, path string) int {
+	rel, err := filepath.Rel(rootDir, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(filepath.ToSlash(rel), "/") + 1
+}
+
+// merge appends the files of other that d does not already contain, so
+// overlapping roots like envs and envs/prod process each file once.
+func (d *Discovery) merge(other *Discovery) {
+	seen := make(map[string]bool, len(d.Files)+len(d.Ignored))
+	for _, file := range d.Files {
+		seen[filepath.Clean(file)] = true
+	}
+	for _, file := range d.Ignored {
+		seen[filepath.Clean(file)] = true
+	}
+
+	for _, file := range other.Files {
+		if !seen[filepath.Clean(file)] {
+			seen[filepath.Clean(file)] = true
+			d.Files = append(d.Files, file)
+		}
+	}
+	for _, file := range other.Ignored {
+		if !seen[filepath.Clean(file)] {
+			seen[filepath.Clean(file)] = true
+			d.Ignored = append(d.Ignored, file)
+		}
+	}
+	d.Symlinks = append(d.Symlinks, other.Symlinks...)
+}
+
+// exclude returns a copy of d without the files for which excluded returns
+// true.
+func (d *Discovery) exclude(excluded func(file string) bool) *Discovery {
+	filtered := &Discovery{Symlinks: d.Symlinks}
+	for _, file := range d.Files {
+		if !excluded(file) {
+			filtered.Files = append(filtered.Files, file)
+		}
+	}
+	for _, file := range d.Ignored {
+		if !excluded(file) {
+			filtered.Ignored = append(filtered.Ignored, file)
+		}
+	}
+	return filtered
+}
+
+// readFileList reads newline-separated file paths, as printed by
+// `git diff --name-only` or `find`, keeping only files with a configured
+// extension. Blank lines and duplicates are dropped.
+func readFileList(r io.Reader, opts DiscoveryOptions) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		path := strings.TrimSpace(scanner.Text())
+		if path == "" || !opts.includes(path) || seen[path] {
+			continue
+		}
+		seen[path] = true
+		files = append(files, path)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading file list: %w", err)
+	}
+	return files, nil
+}
+
+// isIgnoredByTerraform reports whether Terraform would skip path when loading
+// configuration: hidden files, editor backup files, and anything inside a
+// hidden directory such as .terraform.
+func isIgnoredByTerraform(rootDir, path string) bool {
+	return isIgnoredPath(rootDir, path, false)
+}
+
+// isIgnoredPath is isIgnoredByTerraform, optionally treating .terraform
+// directories as regular ones.
+func isIgnoredPath(rootDir, path string, allowDotTerraform bool) bool {
+	rel, err := filepath.Rel(rootDir, path)
+	if err != nil {
+		return false
+	}
+
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i, part := range parts {
+		if allowDotTerraform && part == ".terraform" && i < len(parts)-1 {
+			continue
+		}
+		if strings.HasPrefix(part, ".") && part != "." && part != ".." {
+			return true
+		}
+		if i == len(parts)-1 && (strings.HasSuffix(part, "~") || (strings.HasPrefix(part, "#") && strings.HasSuffix(part, "#"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// scanIgnoredFile reports the removed blocks in a file without modifying it.
+func scanIgnoredFile(filePath string) ([]Finding, error) {
+	raw, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file %s: %w", filePath, err)
+	}
+
+	content, _, err := decodeContent(raw)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding %s: %w", filePath, err)
+	}
+
+	var blocks []removedBlock
+	if isJSONConfig(filePath) {
+		members, err := findJSONRemovedBlocks(content)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", filePath, err)
+		}
+		for _, member := range members {
+			blocks = append(blocks, member.blocks...)
+		}
+	} else {
+		syntaxFile, diags := hclsyntax.ParseConfig(content, filePath, hcl.Pos{Line: 1, Column: 1})
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("error parsing %s: %s", filePath, diags.Error())
+		}
+
+		syntaxBody, ok := syntaxFile.Body.(*hclsyntax.Body)
+		if !ok {
+			return nil, fmt.Errorf("unexpected body type in %s", filePath)
+		}
+		blocks = findRemovedBlocks(syntaxBody, content)
+	}
+
+	var findings []Finding
+	for _, block := range blocks {
+		findings = append(findings, Finding{File: filePath, Address: block.Address, Line: block.Line})
+	}
+	return findings, nil
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiscoverFilesSeparatesIgnoredFiles(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-discovery-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	removedContent := `
+removed {
+  from = aws_instance.old
+  lifecycle {
+    destroy = false
+  }
+}
+`
+	files := map[string]string{
+		"main.tf":                        removedContent,
+		".hidden.tf":                     removedContent,
+		".terraform/modules/vpc/main.tf": removedContent,
+		"modules/app/main.tf":            "",
+		"modules/app/.terraform/providers/old.tf": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if mkdirErr := os.MkdirAll(filepath.Dir(path), 0750); mkdirErr != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, mkdirErr)
+		}
+		if writeErr := os.WriteFile(path, []byte(content), 0600); writeErr != nil {
+			t.Fatalf("Failed to write file %s: %v", name, writeErr)
+		}
+	}
+
+	discovery, err := discoverFiles(tempDir, DiscoveryOptions{})
+	if err != nil {
+		t.Fatalf("discoverFiles failed: %v", err)
+	}
+
+	if len(discovery.Files) != 2 {
+		t.Errorf("Expected 2 processable files, but found %d: %v", len(discovery.Files), discovery.Files)
+	}
+	// .terraform directories are skipped entirely, so only .hidden.tf is
+	// reported as ignored.
+	if len(discovery.Ignored) != 1 {
+		t.Errorf("Expected 1 ignored file, but found %d: %v", len(discovery.Ignored), discovery.Ignored)
+	}
+
+	discovery, err = discoverFiles(tempDir, DiscoveryOptions{IncludeDotTerraform: true})
+	if err != nil {
+		t.Fatalf("discoverFiles failed: %v", err)
+	}
+	if len(discovery.Files) != 4 || len(discovery.Ignored) != 1 {
+		t.Errorf("Expected .terraform files to be processed with IncludeDotTerraform, but got files %v and ignored %v", discovery.Files, discovery.Ignored)
+	}
+
+	findings, err := scanIgnoredFile(filepath.Join(tempDir, ".hidden.tf"))
+	if err != nil {
+		t.Fatalf("scanIgnoredFile failed: %v", err)
+	}
+	if len(findings) != 1 || findings[0].Address != "aws_instance.old" {
+		t.Errorf("Expected one finding for aws_instance.old, but got %v", findings)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tempDir, ".hidden.tf"))
+	if err != nil {
+		t.Fatalf("Failed to read ignored file: %v", err)
+	}
+	if string(content) != removedContent {
+		t.Errorf("scanIgnoredFile modified the file, but it shouldn't have")
+	}
+}
+
+func TestIsIgnoredByTerraform(t *testing.T) {
+	tests := []struct {
+		root     string
+		path     string
+		expected bool
+	}{
+		{root: ".", path: "main.tf", expected: false},
+		{root: ".", path: ".main.tf", expected: true},
+		{root: ".", path: filepath.Join(".terraform", "modules", "x", "main.tf"), expected: true},
+		{root: "envs", path: filepath.Join("envs", "prod", "main.tf"), expected: false},
+		{root: "../repo", path: filepath.Join("..", "repo", "main.tf"), expected: false},
+	}
+
+	for _, tt := range tests {
+		if got := isIgnoredByTerraform(tt.root, tt.path); got != tt.expected {
+			t.Errorf("isIgnoredByTerraform(%q, %q) = %v, expected %v", tt.root, tt.path, got, tt.expected)
+		}
+	}
+}
+
+func TestReadFileList(t *testing.T) {
+	input := "main.tf\n\nREADME.md\r\n  modules/vpc/main.tf  \nmain.tf\n"
+
+	files, err := readFileList(strings.NewReader(input), DiscoveryOptions{})
+	if err != nil {
+		t.Fatalf("readFileList failed: %v", err)
+	}
+
+	expected := []string{"main.tf", "modules/vpc/main.tf"}
+	if !slices.Equal(files, expected) {
+		t.Errorf("Expected %v, but got %v", expected, files)
+	}
+}
+
+func TestDiscoverFilesSingleFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-discovery-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	for _, name := range []string{"main.tf", "other.tf"} {
+		if writeErr := os.WriteFile(filepath.Join(tempDir, name), []byte(""), 0600); writeErr != nil {
+			t.Fatalf("Failed to write file %s: %v", name, writeErr)
+		}
+	}
+
+	target := filepath.Join(tempDir, "main.tf")
+	discovery, err := discoverFiles(target, DiscoveryOptions{})
+	if err != nil {
+		t.Fatalf("discoverFiles failed: %v", err)
+	}
+
+	if !slices.Equal(discovery.Files, []string{target}) {
+		t.Errorf("Expected only %s, but got %v", target, discovery.Files)
+	}
+}
+
+func TestDiscoverFilesCustomExtensions(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-discovery-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	for _, name := range []string{"main.tf", "network.hcl2", "generated.tfpart", "notes.txt"} {
+		if writeErr := os.WriteFile(filepath.Join(tempDir, name), []byte(""), 0600); writeErr != nil {
+			t.Fatalf("Failed to write file %s: %v", name, writeErr)
+		}
+	}
+
+	discovery, err := discoverFiles(tempDir, DiscoveryOptions{Extensions: []string{".hcl2", ".tfpart"}})
+	if err != nil {
+		t.Fatalf("discoverFiles failed: %v", err)
+	}
+
+	expected := []string{filepath.Join(tempDir, "generated.tfpart"), filepath.Join(tempDir, "network.hcl2")}
+	if !slices.Equal(discovery.Files, expected) {
+		t.Errorf("Expected %v, but got %v", expected, discovery.Files)
+	}
+}
+
+func TestDiscoverFilesDefaultExtensions(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-discovery-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	for _, name := range []string{"main.tf", "main.tf.json", "main.tofu", "main.tofu.json", "package.json", "notes.tofu.bak"} {
+		if writeErr := os.WriteFile(filepath.Join(tempDir, name), []byte(""), 0600); writeErr != nil {
+			t.Fatalf("Failed to write file %s: %v", name, writeErr)
+		}
+	}
+
+	discovery, err := discoverFiles(tempDir, DiscoveryOptions{})
+	if err != nil {
+		t.Fatalf("discoverFiles failed: %v", err)
+	}
+
+	expected := []string{
+		filepath.Join(tempDir, "main.tf"),
+		filepath.Join(tempDir, "main.tf.json"),
+		filepath.Join(tempDir, "main.tofu"),
+		filepath.Join(tempDir, "main.tofu.json"),
+	}
+	if !slices.Equal(discovery.Files, expected) {
+		t.Errorf("Expected %v, but got %v", expected, discovery.Files)
+	}
+}
+
+func TestDiscoveryMergeDeduplicates(t *testing.T) {
+	discovery := &Discovery{Files: []string{"envs/prod/main.tf"}}
+	discovery.merge(&Discovery{
+		Files:   []string{"envs/prod/./main.tf", "modules/vpc/main.tf"},
+		Ignored: []string{"envs/.hidden.tf"},
+	})
+	discovery.merge(&Discovery{Ignored: []string{"envs/.hidden.tf"}})
+
+	if !slices.Equal(discovery.Files, []string{"envs/prod/main.tf", "modules/vpc/main.tf"}) {
+		t.Errorf("Expected duplicate files to be merged, but got %v", discovery.Files)
+	}
+	if !slices.Equal(discovery.Ignored, []string{"envs/.hidden.tf"}) {
+		t.Errorf("Expected duplicate ignored files to be merged, but got %v", discovery.Ignored)
+	}
+}
+
+func TestDiscoverFilesDepthLimit(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-discovery-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	for _, name := range []string{"main.tf", "modules/vpc/main.tf", "modules/vpc/nested/main.tf"} {
+		path := filepath.Join(tempDir, name)
+		if mkdirErr := os.MkdirAll(filepath.Dir(path), 0750); mkdirErr != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, mkdirErr)
+		}
+		if writeErr := os.WriteFile(path, []byte(""), 0600); writeErr != nil {
+			t.Fatalf("Failed to write file %s: %v", name, writeErr)
+		}
+	}
+
+	tests := []struct {
+		maxDepth int
+		expected int
+	}{
+		{0, 1},
+		{1, 1},
+		{2, 2},
+		{3, 3},
+	}
+	for _, tt := range tests {
+		discovery, err := discoverFiles(tempDir, DiscoveryOptions{LimitDepth: true, MaxDepth: tt.maxDepth})
+		if err != nil {
+			t.Fatalf("discoverFiles failed: %v", err)
+		}
+		if len(discovery.Files) != tt.expected {
+			t.Errorf("With max depth %d, expected %d files, but found %d: %v", tt.maxDepth, tt.expected, len(discovery.Files), discovery.Files)
+		}
+	}
+}
+
+func TestDiscoverFilesExcludeDirs(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-discovery-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	for _, name := range []string{"main.tf", "examples/basic/main.tf", "modules/vpc/main.tf", "modules/vpc/test/fixtures/main.tf"} {
+		path := filepath.Join(tempDir, name)
+		if mkdirErr := os.MkdirAll(filepath.Dir(path), 0750); mkdirErr != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, mkdirErr)
+		}
+		if writeErr := os.WriteFile(path, []byte(""), 0600); writeErr != nil {
+			t.Fatalf("Failed to write file %s: %v", name, writeErr)
+		}
+	}
+
+	discovery, err := discoverFiles(tempDir, DiscoveryOptions{ExcludeDirs: []string{"examples/**", "**/test"}})
+	if err != nil {
+		t.Fatalf("discoverFiles failed: %v", err)
+	}
+
+	expected := []string{filepath.Join(tempDir, "main.tf"), filepath.Join(tempDir, "modules", "vpc", "main.tf")}
+	if !slices.Equal(discovery.Files, expected) {
+		t.Errorf("Expected %v, but got %v", expected, discovery.Files)
+	}
+}
+
+func TestDiscoverFilesSymlinks(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-discovery-symlink-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	// root/
+	//   main.tf
+	//   alias.tf -> main.tf
+	//   modules/vpc/vpc.tf
+	//   modules/vpc/loop -> ..        (a cycle)
+	//   linked -> ../shared           (outside the root)
+	//   also-vpc -> modules/vpc       (a second link to the same tree)
+	root := filepath.Join(tempDir, "root")
+	for _, dir := range []string{filepath.Join(root, "modules", "vpc"), filepath.Join(tempDir, "shared")} {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+	}
+	for _, name := range []string{filepath.Join(root, "main.tf"), filepath.Join(root, "modules", "vpc", "vpc.tf"), filepath.Join(tempDir, "shared", "shared.tf")} {
+		if err := os.WriteFile(name, []byte(""), 0600); err != nil {
+			t.Fatalf("Failed to write file %s: %v", name, err)
+		}
+	}
+	links := map[string]string{
+		filepath.Join(root, "alias.tf"):               "main.tf",
+		filepath.Join(root, "modules", "vpc", "loop"): "..",
+		filepath.Join(root, "linked"):                 filepath.Join("..", "shared"),
+		filepath.Join(root, "also-vpc"):               filepath.Join("modules", "vpc"),
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skipf("Symlinks are not supported: %v", err)
+		}
+	}
+
+	discovery, err := discoverFiles(root, DiscoveryOptions{})
+	if err != nil {
+		t.Fatalf("discoverFiles failed: %v", err)
+	}
+	expected := []string{filepath.Join(root, "main.tf"), filepath.Join(root, "modules", "vpc", "vpc.tf")}
+	if !slices.Equal(discovery.Files, expected) {
+		t.Errorf("Expected symlinks to be skipped by default, %v, but got %v", expected, discovery.Files)
+	}
+	if len(discovery.Symlinks) != len(links) {
+		t.Errorf("Expected %d skipped symlinks, but got %v", len(links), discovery.Symlinks)
+	}
+
+	discovery, err = discoverFiles(root, DiscoveryOptions{FollowSymlinks: true})
+	if err != nil {
+		t.Fatalf("discoverFiles failed: %v", err)
+	}
+	// Files behind links come after the regular walk
+	expected = []string{
+		filepath.Join(root, "main.tf"),
+		filepath.Join(root, "modules", "vpc", "vpc.tf"),
+		filepath.Join(root, "linked", "shared.tf"),
+	}
+	if !slices.Equal(discovery.Files, expected) {
+		t.Errorf("Expected each real file once, %v, but got %v", expected, discovery.Files)
+	}
+}
+
+func TestDiscoverFilesContextCanceled(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-discovery-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	if writeErr := os.WriteFile(filepath.Join(tempDir, "main.tf"), []byte(""), 0600); writeErr != nil {
+		t.Fatalf("Failed to write file: %v", writeErr)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := discoverFilesContext(ctx, tempDir, DiscoveryOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, but got %v", err)
+	}
+}
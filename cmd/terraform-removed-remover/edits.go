@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+)
+
+// Position, Range, and TextEdit are the Language Server Protocol types of
+// the same names. Lines are zero-based and characters count UTF-16 code
+// units, the protocol's default position encoding.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// WorkspaceEdit is the -edits-json file: the changes the run makes, or
+// would make in a dry run, as an LSP WorkspaceEdit, so an editor can apply
+// them to its buffers as a code action.
+type WorkspaceEdit struct {
+	// Changes maps the file URI of every file the run changes to its edits,
+	// which refer to the file as it was before the run.
+	Changes map[string][]TextEdit `json:"changes"`
+}
+
+func newWorkspaceEdit() *WorkspaceEdit {
+	return &WorkspaceEdit{Changes: map[string][]TextEdit{}}
+}
+
+// add records the edits turning before, the content of filePath, into
+// after: one per run of changed lines, found with diff.
+func (w *WorkspaceEdit) add(filePath string, before, after []byte, diff diffFunc) error {
+	edits := textEdits(before, after, diff)
+	if len(edits) == 0 {
+		return nil
+	}
+	uri, err := fileURI(filePath)
+	if err != nil {
+		return fmt.Errorf("error recording edits for %s: %w", filePath, err)
+	}
+	w.Changes[uri] = edits
+	return nil
+}
+
+// textEdits returns the edits turning before into after, replacing whole
+// lines.
+func textEdits(before, after []byte, diff diffFunc) []TextEdit {
+	linesA, linesB := splitLines(before), splitLines(after)
+	a, b := internLines(linesA, linesB)
+	ops := diff(a, b, 0, 0)
+
+	var edits []TextEdit
+	line := 0
+	for i := 0; i < len(ops); {
+		if ops[i].Kind == diffEqual {
+			line++
+			i++
+			continue
+		}
+		start := line
+		var text strings.Builder
+		for ; i < len(ops) && ops[i].Kind != diffEqual; i++ {
+			if ops[i].Kind == diffDelete {
+				line++
+			} else {
+				text.WriteString(linesB[ops[i].b])
+			}
+		}
+		edits = append(edits, TextEdit{
+			Range:   Range{Start: linePosition(linesA, start), End: linePosition(linesA, line)},
+			NewText: text.String(),
+		})
+	}
+	return edits
+}
+
+// linePosition returns the position of the start of line i of lines, or
+// of the end of the text when i is past its last line.
+func linePosition(lines []string, i int) Position {
+	if i == len(lines) && i > 0 && !strings.HasSuffix(lines[i-1], "\n") {
+		// The last line has no line break to start a line after it
+		return Position{Line: i - 1, Character: len(utf16.Encode([]rune(lines[i-1])))}
+	}
+	return Position{Line: i}
+}
+
+// fileURI returns the file URI of filePath.
+func fileURI(filePath string) (string, error) {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", err
+	}
+	abs = filepath.ToSlash(abs)
+	if !strings.HasPrefix(abs, "/") {
+		// Windows paths start with a drive letter
+		abs = "/" + abs
+	}
+	return (&url.URL{Scheme: "file", Path: abs}).String(), nil
+}
+
+// writeFileEdits records the change from before to after of filePath in
+// stats.Edits using the configured diff algorithm.
+func writeFileEdits(filePath string, before, after []byte, stats *Stats) error {
+	name := stats.DiffAlgorithm
+	if name == "" {
+		name = diffMyers
+	}
+	diff, ok := diffAlgorithm(name)
+	if !ok {
+		return fmt.Errorf("unknown diff algorithm %q", name)
+	}
+	return stats.Edits.add(filePath, before, after, diff)
+}
+
+func writeWorkspaceEdit(path string, edits *WorkspaceEdit) error {
+	data, err := json.MarshalIndent(edits, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing edits: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTextEdits(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		before   string
+		after    string
+		expected []TextEdit
+	}{
+		{
+			name:   "deleted lines",
+			before: "a\nb\nc\n",
+			after:  "a\nc\n",
+			expected: []TextEdit{
+				{Range: Range{Start: Position{Line: 1}, End: Position{Line: 2}}},
+			},
+		},
+		{
+			name:   "replaced and inserted lines",
+			before: "a\nb\nc\n",
+			after:  "x\nb\nc\nd\n",
+			expected: []TextEdit{
+				{Range: Range{Start: Position{Line: 0}, End: Position{Line: 1}}, NewText: "x\n"},
+				{Range: Range{Start: Position{Line: 3}, End: Position{Line: 3}}, NewText: "d\n"},
+			},
+		},
+		{
+			name:   "last line without a line break",
+			before: "a\né\U0001F600",
+			after:  "a\n",
+			expected: []TextEdit{
+				{Range: Range{Start: Position{Line: 1}, End: Position{Line: 1, Character: 3}}},
+			},
+		},
+		{
+			name:   "unchanged",
+			before: "a\n",
+			after:  "a\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := textEdits([]byte(tt.before), []byte(tt.after), myersDiff)
+			if !reflect.DeepEqual(actual, tt.expected) {
+				t.Errorf("Expected %+v, but got %+v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestProcessFileEdits(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-edits-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := "resource \"aws_instance\" \"web\" {}\n\nremoved {\n  from = aws_instance.old\n}\n"
+	testFile := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	clean := filepath.Join(tempDir, "clean.tf")
+	if err := os.WriteFile(clean, []byte("resource \"aws_instance\" \"db\" {}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	stats := &Stats{DryRun: true, Edits: newWorkspaceEdit()}
+	for _, file := range []string{testFile, clean} {
+		if err := processFile(file, stats); err != nil {
+			t.Fatalf("processFile failed: %v", err)
+		}
+	}
+
+	uri, err := fileURI(testFile)
+	if err != nil {
+		t.Fatalf("fileURI failed: %v", err)
+	}
+	expected := map[string][]TextEdit{
+		uri: {{Range: Range{Start: Position{Line: 2}, End: Position{Line: 5}}}},
+	}
+	if !reflect.DeepEqual(stats.Edits.Changes, expected) {
+		t.Errorf("Expected %+v, but got %+v", expected, stats.Edits.Changes)
+	}
+
+	editsFile := filepath.Join(tempDir, "edits.json")
+	if err := writeWorkspaceEdit(editsFile, stats.Edits); err != nil {
+		t.Fatalf("writeWorkspaceEdit failed: %v", err)
+	}
+	data, err := os.ReadFile(editsFile)
+	if err != nil {
+		t.Fatalf("Failed to read edits: %v", err)
+	}
+	var written WorkspaceEdit
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("Expected a WorkspaceEdit, but got %q: %v", data, err)
+	}
+	if !reflect.DeepEqual(written.Changes, expected) {
+		t.Errorf("Expected %+v, but got %+v", expected, written.Changes)
+	}
+}
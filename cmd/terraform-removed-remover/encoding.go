@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding names reported in warnings.
+const (
+	encodingUTF8    = "UTF-8"
+	encodingUTF16LE = "UTF-16LE"
+	encodingUTF16BE = "UTF-16BE"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// fileEncoding describes how the bytes of a file map to the UTF-8 text that
+// is parsed and edited.
+type fileEncoding struct {
+	Name string
+	BOM  bool
+}
+
+func (e fileEncoding) isUTF16() bool {
+	return e.Name == encodingUTF16LE || e.Name == encodingUTF16BE
+}
+
+// byteOrder is implemented by binary.LittleEndian and binary.BigEndian.
+type byteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+func (e fileEncoding) byteOrder() byteOrder {
+	if e.Name == encodingUTF16BE {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+// detectEncoding identifies UTF-16 files by their byte order mark or, without
+// one, by the NUL bytes that ASCII text produces in UTF-16. Everything else is
+// treated as UTF-8, with or without its byte order mark.
+func detectEncoding(raw []byte) fileEncoding {
+	switch {
+	case bytes.HasPrefix(raw, bomUTF8):
+		return fileEncoding{Name: encodingUTF8, BOM: true}
+	case bytes.HasPrefix(raw, bomUTF16LE):
+		return fileEncoding{Name: encodingUTF16LE, BOM: true}
+	case bytes.HasPrefix(raw, bomUTF16BE):
+		return fileEncoding{Name: encodingUTF16BE, BOM: true}
+	}
+
+	sample := raw
+	if len(sample) > 512 {
+		sample = sample[:512]
+	}
+	if len(sample) < 2 || len(sample)%2 != 0 {
+		return fileEncoding{Name: encodingUTF8}
+	}
+
+	var evenZeros, oddZeros int
+	for i, b := range sample {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenZeros++
+		} else {
+			oddZeros++
+		}
+	}
+
+	half := len(sample) / 2
+	switch {
+	case oddZeros*10 >= half*4 && evenZeros*10 < half:
+		return fileEncoding{Name: encodingUTF16LE}
+	case evenZeros*10 >= half*4 && oddZeros*10 < half:
+		return fileEncoding{Name: encodingUTF16BE}
+	}
+	return fileEncoding{Name: encodingUTF8}
+}
+
+// decodeContent converts raw file bytes to UTF-8 for parsing. A UTF-8 byte
+// order mark is stripped, since the HCL parser rejects it.
+func decodeContent(raw []byte) ([]byte, fileEncoding, error) {
+	encoding := detectEncoding(raw)
+	if !encoding.isUTF16() {
+		if encoding.BOM {
+			return raw[len(bomUTF8):], encoding, nil
+		}
+		return raw, encoding, nil
+	}
+
+	data := raw
+	if encoding.BOM {
+		data = data[2:]
+	}
+	if len(data)%2 != 0 {
+		return nil, encoding, fmt.Errorf("truncated %s content", encoding.Name)
+	}
+
+	// Decode straight into UTF-8 so large files are not held several times
+	order := encoding.byteOrder()
+	content := make([]byte, 0, len(data)/2)
+	for i := 0; i < len(data); i += 2 {
+		r := rune(order.Uint16(data[i:]))
+		if utf16.IsSurrogate(r) {
+			decoded := utf8.RuneError
+			if i+4 <= len(data) {
+				if pair := utf16.DecodeRune(r, rune(order.Uint16(data[i+2:]))); pair != utf8.RuneError {
+					decoded = pair
+					i += 2
+				}
+			}
+			r = decoded
+		}
+		content = utf8.AppendRune(content, r)
+	}
+	return content, encoding, nil
+}
+
+// isText reports whether content is text the byte offsets of the parser can
+// be trusted on: valid UTF-8 without the NUL bytes of binary files.
+func isText(content []byte) bool {
+	return utf8.Valid(content) && bytes.IndexByte(content, 0) < 0
+}
+
+// encodeContent converts UTF-8 content back to encoding, restoring its byte
+// order mark.
+func encodeContent(content []byte, encoding fileEncoding) []byte {
+	if !encoding.isUTF16() {
+		if encoding.BOM {
+			return append(append([]byte{}, bomUTF8...), content...)
+		}
+		return content
+	}
+
+	var result []byte
+	if encoding.BOM {
+		if encoding.Name == encodingUTF16BE {
+			result = append(result, bomUTF16BE...)
+		} else {
+			result = append(result, bomUTF16LE...)
+		}
+	}
+
+	order := encoding.byteOrder()
+	for len(content) > 0 {
+		r, size := utf8.DecodeRune(content)
+		content = content[size:]
+		for _, unit := range utf16.Encode([]rune{r}) {
+			result = order.AppendUint16(result, unit)
+		}
+	}
+	return result
+}
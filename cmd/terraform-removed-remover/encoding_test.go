@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeEncodeRoundTrip(t *testing.T) {
+	text := "# café \U0001F600\nremoved {\n  from = aws_instance.old\n}\n"
+
+	for _, encoding := range []fileEncoding{
+		{Name: encodingUTF8, BOM: true},
+		{Name: encodingUTF16LE, BOM: true},
+		{Name: encodingUTF16BE, BOM: true},
+		{Name: encodingUTF16LE},
+		{Name: encodingUTF16BE},
+	} {
+		t.Run(encoding.Name, func(t *testing.T) {
+			raw := encodeContent([]byte(text), encoding)
+
+			decoded, detected, err := decodeContent(raw)
+			if err != nil {
+				t.Fatalf("decodeContent failed: %v", err)
+			}
+			if detected != encoding {
+				t.Errorf("Detected %+v, expected %+v", detected, encoding)
+			}
+			if string(decoded) != text {
+				t.Errorf("Decoded %q, expected %q", decoded, text)
+			}
+			if !bytes.Equal(encodeContent(decoded, detected), raw) {
+				t.Errorf("Re-encoding did not reproduce the original bytes")
+			}
+		})
+	}
+}
+
+func TestDetectEncodingUTF8(t *testing.T) {
+	for _, input := range []string{"", "a", "removed {}\n", "café\n"} {
+		if got := detectEncoding([]byte(input)); got.Name != encodingUTF8 {
+			t.Errorf("detectEncoding(%q) = %+v, expected UTF-8", input, got)
+		}
+	}
+}
+
+func TestProcessFileUTF16(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-utf16-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := "resource \"aws_instance\" \"web\" {\n  ami = \"ami-123456\"\n}\n\nremoved {\n  from = aws_instance.old\n}\n"
+	encoding := fileEncoding{Name: encodingUTF16LE, BOM: true}
+
+	t.Run("converted to UTF-8 by default", func(t *testing.T) {
+		testFile := filepath.Join(tempDir, "converted.tf")
+		if err := os.WriteFile(testFile, encodeContent([]byte(content), encoding), 0600); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+
+		stats := Stats{StartTime: time.Now()}
+		if err := processFile(testFile, &stats); err != nil {
+			t.Fatalf("processFile failed: %v", err)
+		}
+
+		modifiedContent, err := os.ReadFile(testFile)
+		if err != nil {
+			t.Fatalf("Failed to read modified file: %v", err)
+		}
+		if detectEncoding(modifiedContent).Name != encodingUTF8 || strings.Contains(string(modifiedContent), "removed {") {
+			t.Errorf("Expected UTF-8 output without removed blocks, got %q", modifiedContent)
+		}
+		if len(stats.Warnings) != 1 || !strings.Contains(stats.Warnings[0], "UTF-16LE") {
+			t.Errorf("Expected a conversion warning, got %v", stats.Warnings)
+		}
+	})
+
+	t.Run("preserved with PreserveEncoding", func(t *testing.T) {
+		testFile := filepath.Join(tempDir, "preserved.tf")
+		if err := os.WriteFile(testFile, encodeContent([]byte(content), encoding), 0600); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+
+		stats := Stats{StartTime: time.Now(), PreserveEncoding: true}
+		if err := processFile(testFile, &stats); err != nil {
+			t.Fatalf("processFile failed: %v", err)
+		}
+
+		modifiedContent, err := os.ReadFile(testFile)
+		if err != nil {
+			t.Fatalf("Failed to read modified file: %v", err)
+		}
+		decoded, detected, err := decodeContent(modifiedContent)
+		if err != nil {
+			t.Fatalf("decodeContent failed: %v", err)
+		}
+		if detected != encoding {
+			t.Errorf("Expected encoding %+v to be preserved, got %+v", encoding, detected)
+		}
+		if strings.Contains(string(decoded), "removed {") || !strings.Contains(string(decoded), "aws_instance") {
+			t.Errorf("Unexpected content %q", decoded)
+		}
+		if len(stats.Warnings) != 0 {
+			t.Errorf("Expected no warnings, got %v", stats.Warnings)
+		}
+	})
+}
+
+func TestProcessFileUTF8BOM(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-bom-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	for _, tt := range []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "main.tf",
+			content:  "removed {\n  from = aws_instance.old\n}\nresource \"aws_instance\" \"web\" {}\n",
+			expected: "resource \"aws_instance\" \"web\" {}\n",
+		},
+		{
+			name:     "main.tf.json",
+			content:  "{\n  \"removed\": [{\"from\": \"aws_instance.old\"}],\n  \"resource\": {}\n}\n",
+			expected: "{\n  \"resource\": {}\n}\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := filepath.Join(tempDir, tt.name)
+			if err := os.WriteFile(testFile, append(append([]byte{}, bomUTF8...), tt.content...), 0600); err != nil {
+				t.Fatalf("Failed to write test file: %v", err)
+			}
+
+			stats := Stats{StartTime: time.Now()}
+			if err := processFile(testFile, &stats); err != nil {
+				t.Fatalf("processFile failed: %v", err)
+			}
+			if stats.RemovedBlocksRemoved != 1 {
+				t.Errorf("Expected 1 removed block, but got %d", stats.RemovedBlocksRemoved)
+			}
+
+			modifiedContent, err := os.ReadFile(testFile)
+			if err != nil {
+				t.Fatalf("Failed to read modified file: %v", err)
+			}
+			if expected := "\ufeff" + tt.expected; string(modifiedContent) != expected {
+				t.Errorf("Expected %q, but got %q", expected, modifiedContent)
+			}
+		})
+	}
+}
+
+func TestProcessFileNotUTF8(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-not-utf8-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	for name, content := range map[string][]byte{
+		"latin1.tf": []byte("# caf\xe9\nremoved {\n  from = aws_instance.old\n}\n"),
+		"binary.tf": []byte("removed {\x00\x01\x02\n  from = aws_instance.old\n}\n"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			testFile := filepath.Join(tempDir, name)
+			if err := os.WriteFile(testFile, content, 0600); err != nil {
+				t.Fatalf("Failed to write test file: %v", err)
+			}
+
+			stats := Stats{StartTime: time.Now()}
+			if err := processFile(testFile, &stats); err != nil {
+				t.Fatalf("processFile failed: %v", err)
+			}
+			if stats.FilesNotUTF8 != 1 || stats.FilesProcessed != 0 || stats.RemovedBlocksRemoved != 0 {
+				t.Errorf("Expected the file to be skipped, but got %+v", stats)
+			}
+			if len(stats.Warnings) != 1 || !strings.Contains(stats.Warnings[0], "not valid UTF-8") {
+				t.Errorf("Expected a warning about the encoding, but got %q", stats.Warnings)
+			}
+
+			unchanged, err := os.ReadFile(testFile)
+			if err != nil {
+				t.Fatalf("Failed to read test file: %v", err)
+			}
+			if !bytes.Equal(unchanged, content) {
+				t.Errorf("Expected the file to be left alone, but got %q", unchanged)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import "errors"
+
+// Exit statuses, so scripts can tell a run with nothing to do from one that
+// changed files and from one that failed part way through.
+const (
+	exitOK = 0
+	// exitChanges means files were changed, or with -check would be.
+	exitChanges = 1
+	// exitParseError means at least one file could not be parsed.
+	exitParseError = 2
+	// exitWriteError means at least one file could not be read or written,
+	// or an output of the run, such as a report, could not be written.
+	exitWriteError = 3
+	// exitUsage means the run could not be carried out at all, e.g. because
+	// of invalid flags or inputs; EX_USAGE from sysexits.h.
+	exitUsage = 64
+	// exitRuntime means a step that depends on another service failed, such
+	// as a git push or a request to GitHub or Jira; EX_UNAVAILABLE from
+	// sysexits.h.
+	exitRuntime = 69
+	// exitContinue is the exit status of a run that stopped at
+	// -max-duration with files left, EX_TEMPFAIL from sysexits.h.
+	exitContinue = 75
+	// exitInterrupted is the exit status of a run stopped by SIGINT or
+	// SIGTERM, the status shells report for a process killed by SIGINT.
+	exitInterrupted = 130
+)
+
+// FileError records a file that could not be processed.
+type FileError struct {
+	File    string
+	Message string
+}
+
+// parseError marks an error processing a file whose content could not be
+// parsed, as opposed to one that could not be read or written.
+type parseError struct {
+	err error
+}
+
+func (e *parseError) Error() string {
+	return e.err.Error()
+}
+
+func (e *parseError) Unwrap() error {
+	return e.err
+}
+
+// statusError marks an error that ends the run with status rather than
+// exitUsage.
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+// writeError marks err, from writing a file, for exitWriteError.
+func writeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &statusError{status: exitWriteError, err: err}
+}
+
+// runtimeError marks err, from git or a remote service, for exitRuntime.
+func runtimeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &statusError{status: exitRuntime, err: err}
+}
+
+// errorStatus returns the exit status of a run that failed with err: the
+// one it is marked with, or fallback.
+func errorStatus(err error, fallback int) int {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.status
+	}
+	return fallback
+}
+
+// fileErrorStatus returns the exit status for an error processing a file.
+func fileErrorStatus(err error) int {
+	var parseErr *parseError
+	if errors.As(err, &parseErr) {
+		return exitParseError
+	}
+	return exitWriteError
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileErrorStatus(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-exit-status-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	invalid := filepath.Join(tempDir, "invalid.tf")
+	if err := os.WriteFile(invalid, []byte("removed {\n  from = aws_instance.old\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	err = processFile(invalid, &Stats{DryRun: true})
+	if err == nil {
+		t.Fatalf("Expected error for an invalid file, but got nil")
+	}
+	if status := fileErrorStatus(err); status != exitParseError {
+		t.Errorf("Expected status %d for a parse error, but got %d", exitParseError, status)
+	}
+
+	err = processFile(filepath.Join(tempDir, "missing.tf"), &Stats{DryRun: true})
+	if err == nil {
+		t.Fatalf("Expected error for a missing file, but got nil")
+	}
+	if status := fileErrorStatus(err); status != exitWriteError {
+		t.Errorf("Expected status %d for a read error, but got %d", exitWriteError, status)
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "unmarked", err: errors.New("bad input"), expected: exitUsage},
+		{name: "write", err: writeError(errors.New("disk full")), expected: exitWriteError},
+		{name: "runtime", err: runtimeError(errors.New("push rejected")), expected: exitRuntime},
+		{name: "wrapped", err: fmt.Errorf("publishing: %w", runtimeError(errors.New("push rejected"))), expected: exitRuntime},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := errorStatus(tt.err, exitUsage); status != tt.expected {
+				t.Errorf("Expected status %d, but got %d", tt.expected, status)
+			}
+		})
+	}
+	if err := writeError(nil); err != nil {
+		t.Errorf("Expected writeError(nil) to be nil, but got %v", err)
+	}
+}
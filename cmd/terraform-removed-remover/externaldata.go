@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// runExternalDataSource implements the protocol of Terraform's external data
+// source: a JSON object of string values is read from stdin and a JSON object
+// of string values is written to stdout. The scan always runs in dry-run mode.
+//
+// The only supported query key is "path", the directory to scan, which
+// defaults to the working directory.
+func runExternalDataSource(stdin io.Reader, stdout io.Writer, stats *Stats) error {
+	query := map[string]string{}
+	if err := json.NewDecoder(stdin).Decode(&query); err != nil && err != io.EOF {
+		return fmt.Errorf("error decoding query: %w", err)
+	}
+
+	rootDir := query["path"]
+	if rootDir == "" {
+		rootDir = "."
+	}
+
+	info, err := os.Stat(rootDir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", rootDir)
+	}
+
+	stats.DryRun = true
+	discovery, err := discoverFiles(rootDir, stats.DiscoveryOptions)
+	if err != nil {
+		return fmt.Errorf("error finding Terraform files: %w", err)
+	}
+
+	for _, file := range discovery.Files {
+		if err := processFile(file, stats); err != nil {
+			return err
+		}
+	}
+
+	result := map[string]string{
+		"files_scanned":             strconv.Itoa(stats.FilesProcessed),
+		"files_with_removed_blocks": strconv.Itoa(stats.FilesModified),
+		"removed_blocks":            strconv.Itoa(stats.RemovedBlocksRemoved),
+	}
+	if err := json.NewEncoder(stdout).Encode(result); err != nil {
+		return fmt.Errorf("error writing result: %w", err)
+	}
+	return nil
+}
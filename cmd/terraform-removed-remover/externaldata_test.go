@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunExternalDataSource(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-external-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := `
+removed {
+  from = aws_instance.old
+  lifecycle {
+    destroy = false
+  }
+}
+`
+	testFile := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "clean.tf"), []byte("locals {}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	query, err := json.Marshal(map[string]string{"path": tempDir})
+	if err != nil {
+		t.Fatalf("Failed to encode query: %v", err)
+	}
+
+	var stdout bytes.Buffer
+	stats := Stats{}
+	if err := runExternalDataSource(bytes.NewReader(query), &stdout, &stats); err != nil {
+		t.Fatalf("runExternalDataSource failed: %v", err)
+	}
+
+	var result map[string]string
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("Result is not a JSON object of strings: %v\n%s", err, stdout.String())
+	}
+
+	expected := map[string]string{
+		"files_scanned":             "2",
+		"files_with_removed_blocks": "1",
+		"removed_blocks":            "1",
+	}
+	for key, value := range expected {
+		if result[key] != value {
+			t.Errorf("Expected %s to be %q, but got %q", key, value, result[key])
+		}
+	}
+
+	modifiedContent, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	if string(modifiedContent) != content {
+		t.Errorf("External data source mode modified the file, but it shouldn't have")
+	}
+}
+
+func TestRunExternalDataSourceErrors(t *testing.T) {
+	stats := Stats{}
+	if err := runExternalDataSource(strings.NewReader(`{"path": "/non-existent-dir"}`), &bytes.Buffer{}, &stats); err == nil {
+		t.Errorf("Expected error for non-existent path, but got nil")
+	}
+	if err := runExternalDataSource(strings.NewReader(`{"path": 1}`), &bytes.Buffer{}, &stats); err == nil {
+		t.Errorf("Expected error for non-string query value, but got nil")
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// shouldRemoveBlock reports whether block passes the address filters in stats.
+// Blocks pinned with a keep directive and exclusions always win; a block must
+// then satisfy every configured include filter.
+func shouldRemoveBlock(block removedBlock, stats *Stats) bool {
+	// The keep directive only guards against deletion, not consolidation.
+	if hasDirective(block.comment, directiveKeep) && !stats.Consolidate {
+		return false
+	}
+
+	for _, re := range stats.ExcludeAddress {
+		if re.MatchString(block.Address) {
+			return false
+		}
+	}
+
+	if len(stats.Only) > 0 && !matchesAnyPattern(stats.Only, block.Address) {
+		return false
+	}
+
+	if stats.AllowedAddresses != nil && !stats.AllowedAddresses[block.Address] {
+		return false
+	}
+
+	if len(stats.Providers) > 0 || len(stats.TypePrefixes) > 0 || len(stats.Modules) > 0 {
+		address, err := parseAddress(block.Address)
+		if err != nil {
+			return false
+		}
+		if (len(stats.Providers) > 0 || len(stats.TypePrefixes) > 0) && !matchesResourceType(address, stats) {
+			return false
+		}
+		if len(stats.Modules) > 0 && !matchesAnyModule(address, stats.Modules) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchesAnyPattern(patterns []string, address string) bool {
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, address); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesResourceType reports whether address targets a resource or data
+// source matching one of the -provider or -type-prefix filters, or a
+// provider configuration matching -provider.
+func matchesResourceType(address Address, stats *Stats) bool {
+	for _, provider := range stats.Providers {
+		if address.Provider() == provider {
+			return true
+		}
+	}
+	if address.Kind != AddressKindResource && address.Kind != AddressKindData {
+		return false
+	}
+	for _, prefix := range stats.TypePrefixes {
+		if strings.HasPrefix(address.Type, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAnyModule reports whether address targets one of the given module
+// calls or something inside it. Root module addresses never match.
+func matchesAnyModule(address Address, modules []string) bool {
+	for _, module := range modules {
+		filter, err := parseAddress(module)
+		if err != nil || len(filter.ModulePath) > len(address.ModulePath) {
+			continue
+		}
+		if slices.Equal(filter.ModulePath, address.ModulePath[:len(filter.ModulePath)]) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadAddressFile reads an allowlist of addresses, one per line. Blank lines
+// and lines starting with # are ignored.
+func loadAddressFile(filePath string) (map[string]bool, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading address file %s: %w", filePath, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	addresses := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := parseAddress(line); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", filePath, lineNumber, err)
+		}
+		addresses[line] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading address file %s: %w", filePath, err)
+	}
+
+	return addresses, nil
+}
+
+// isOldEnough reports whether block passes the -older-than filter. The age of
+// a block is the author time of the commit that last touched its first line.
+// Blocks whose age cannot be determined, including uncommitted ones, are kept.
+func isOldEnough(filePath string, block *removedBlock, stats *Stats) bool {
+	if stats.OlderThan == 0 {
+		return true
+	}
+	if !stats.hasGit() {
+		return false
+	}
+
+	info, err := blockBlame(filePath, block)
+	if err != nil {
+		stats.Warnings = append(stats.Warnings, fmt.Sprintf("%s:%d: keeping %s, could not determine its age: %s", filePath, block.Line, block.Address, err))
+		return false
+	}
+	if info.Uncommitted() {
+		return false
+	}
+
+	now := stats.StartTime
+	if now.IsZero() {
+		now = time.Now()
+	}
+	return now.Sub(info.Time) >= stats.OlderThan
+}
+
+// isExpired reports whether block, in filePath, passes its remove-after
+// date: blocks without one always do, and blocks with one only from the day
+// after it. With -expiring, blocks yet to expire within that window are
+// recorded in stats.Expiring. Blocks with an invalid date are kept, and so
+// are all blocks with one under -pure, which never reads the clock.
+func isExpired(filePath string, block removedBlock, stats *Stats) bool {
+	value, ok := removeAfter(block.comment)
+	if !ok {
+		return true
+	}
+	if stats.Pure {
+		return false
+	}
+	date, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		stats.Warnings = append(stats.Warnings, fmt.Sprintf("%s:%d: keeping %s, its remove-after date %q is not a YYYY-MM-DD date", filePath, block.Line, block.Address, value))
+		return false
+	}
+
+	now := stats.StartTime
+	if now.IsZero() {
+		now = time.Now()
+	}
+	expiry := date.AddDate(0, 0, 1)
+	if !now.Before(expiry) {
+		return true
+	}
+	if stats.ExpiringWithin > 0 && expiry.Sub(now) <= stats.ExpiringWithin {
+		stats.Expiring = append(stats.Expiring, ExpiringFinding{
+			Finding:     Finding{File: filePath, Address: block.Address, Line: block.Line},
+			RemoveAfter: value,
+		})
+	}
+	return false
+}
+
+// parseAge parses a duration that, in addition to the units understood by
+// time.ParseDuration, accepts whole days (90d) and weeks (2w).
+func parseAge(value string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if number, ok := strings.CutSuffix(value, suffix); ok {
+			n, err := strconv.Atoi(number)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid age %q", value)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q", value)
+	}
+	return d, nil
+}
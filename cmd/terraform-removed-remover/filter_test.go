@@ -0,0 +1,234 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProviderAndTypePrefixFilters(t *testing.T) {
+	tests := []struct {
+		name         string
+		address      string
+		providers    []string
+		typePrefixes []string
+		expected     bool
+	}{
+		{name: "provider match", address: "aws_instance.old", providers: []string{"aws"}, expected: true},
+		{name: "provider mismatch", address: "google_compute_instance.old", providers: []string{"aws"}, expected: false},
+		{name: "provider does not match longer prefix", address: "awscc_s3_bucket.old", providers: []string{"aws"}, expected: false},
+		{name: "provider inside module", address: "module.app.aws_instance.old", providers: []string{"aws"}, expected: true},
+		{name: "type prefix match", address: "aws_s3_bucket.logs", typePrefixes: []string{"aws_s3_"}, expected: true},
+		{name: "type prefix mismatch", address: "aws_instance.old", typePrefixes: []string{"aws_s3_"}, expected: false},
+		{name: "either filter matches", address: "azurerm_vm.old", providers: []string{"aws"}, typePrefixes: []string{"azurerm_"}, expected: true},
+		{name: "module address never matches", address: "module.legacy", providers: []string{"aws"}, expected: false},
+		{name: "data source provider match", address: "data.aws_ami.ubuntu", providers: []string{"aws"}, expected: true},
+		{name: "data source type prefix match", address: "data.aws_ami.ubuntu", typePrefixes: []string{"aws_a"}, expected: true},
+		{name: "provider configuration match", address: "provider.aws.west", providers: []string{"aws"}, expected: true},
+		{name: "provider configuration never matches type prefix", address: "provider.aws", typePrefixes: []string{"aws"}, expected: false},
+		{name: "unknown kind never matches", address: "ephemeral.aws_secret.token", providers: []string{"aws"}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := Stats{Providers: tt.providers, TypePrefixes: tt.typePrefixes}
+			if got := shouldRemoveBlock(removedBlock{Address: tt.address}, &stats); got != tt.expected {
+				t.Errorf("shouldRemoveBlock(%q) = %v, expected %v", tt.address, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIncludeFiltersCombine(t *testing.T) {
+	stats := Stats{Only: []string{"*.old*"}, Providers: []string{"aws"}}
+
+	if !shouldRemoveBlock(removedBlock{Address: "aws_instance.old_web"}, &stats) {
+		t.Errorf("Expected block matching both filters to be removed")
+	}
+	if shouldRemoveBlock(removedBlock{Address: "google_compute_instance.old_web"}, &stats) {
+		t.Errorf("Expected block failing the provider filter to be kept")
+	}
+	if shouldRemoveBlock(removedBlock{Address: "aws_instance.web"}, &stats) {
+		t.Errorf("Expected block failing the -only filter to be kept")
+	}
+}
+
+func TestModuleFilter(t *testing.T) {
+	tests := []struct {
+		address  string
+		modules  []string
+		expected bool
+	}{
+		{address: "module.networking.aws_vpc.main", modules: []string{"module.networking"}, expected: true},
+		{address: "module.networking.module.subnets.aws_subnet.a", modules: []string{"module.networking"}, expected: true},
+		{address: "module.networking", modules: []string{"module.networking"}, expected: true},
+		{address: "module.networking_v2.aws_vpc.main", modules: []string{"module.networking"}, expected: false},
+		{address: "module.app.aws_instance.web", modules: []string{"module.networking"}, expected: false},
+		{address: "aws_instance.web", modules: []string{"module.networking"}, expected: false},
+		{address: "module.networking.aws_vpc.main", modules: []string{"module.networking.module.subnets"}, expected: false},
+		{address: "module.app.aws_instance.web", modules: []string{"module.networking", "module.app"}, expected: true},
+	}
+
+	for _, tt := range tests {
+		stats := Stats{Modules: tt.modules}
+		if got := shouldRemoveBlock(removedBlock{Address: tt.address}, &stats); got != tt.expected {
+			t.Errorf("shouldRemoveBlock(%q) with modules %v = %v, expected %v", tt.address, tt.modules, got, tt.expected)
+		}
+	}
+}
+
+func TestAddressFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-address-file-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	addressFile := filepath.Join(tempDir, "addresses.txt")
+	content := `# Approved in CHG-1234
+aws_instance.old
+
+  module.legacy.aws_s3_bucket.logs
+`
+	if err := os.WriteFile(addressFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write address file: %v", err)
+	}
+
+	addresses, err := loadAddressFile(addressFile)
+	if err != nil {
+		t.Fatalf("loadAddressFile failed: %v", err)
+	}
+	if len(addresses) != 2 {
+		t.Errorf("Expected 2 addresses, but got %d: %v", len(addresses), addresses)
+	}
+
+	stats := Stats{AllowedAddresses: addresses}
+	if !shouldRemoveBlock(removedBlock{Address: "module.legacy.aws_s3_bucket.logs"}, &stats) {
+		t.Errorf("Expected listed address to be removed")
+	}
+	if shouldRemoveBlock(removedBlock{Address: "aws_instance.old_web"}, &stats) {
+		t.Errorf("Expected unlisted address to be kept")
+	}
+
+	empty := Stats{AllowedAddresses: map[string]bool{}}
+	if shouldRemoveBlock(removedBlock{Address: "aws_instance.old"}, &empty) {
+		t.Errorf("Expected an empty address file to remove nothing")
+	}
+
+	invalidFile := filepath.Join(tempDir, "invalid.txt")
+	if err := os.WriteFile(invalidFile, []byte("aws_instance.old\nnot-an-address\n"), 0600); err != nil {
+		t.Fatalf("Failed to write address file: %v", err)
+	}
+	if _, err := loadAddressFile(invalidFile); err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("Expected error pointing at line 2, but got %v", err)
+	}
+
+	if _, err := loadAddressFile(filepath.Join(tempDir, "missing.txt")); err == nil {
+		t.Errorf("Expected error for missing address file, but got nil")
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := map[string]time.Duration{
+		"90d": 90 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"36h": 36 * time.Hour,
+		"0d":  0,
+	}
+	for input, expected := range tests {
+		got, err := parseAge(input)
+		if err != nil {
+			t.Errorf("parseAge(%q) returned error: %v", input, err)
+			continue
+		}
+		if got != expected {
+			t.Errorf("parseAge(%q) = %v, expected %v", input, got, expected)
+		}
+	}
+
+	for _, input := range []string{"", "d", "ninety days", "-1d", "1.5d"} {
+		if _, err := parseAge(input); err == nil {
+			t.Errorf("parseAge(%q) expected error, got nil", input)
+		}
+	}
+}
+
+func TestOlderThanFilter(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-older-than-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	initGitRepo(t, tempDir)
+
+	testFile := filepath.Join(tempDir, "main.tf")
+	oldBlock := "removed {\n  from = aws_instance.old\n}\n"
+	if err := os.WriteFile(testFile, []byte(oldBlock), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	runGit(t, tempDir, "add", "main.tf")
+	runGit(t, tempDir, "commit", "-q", "-m", "old block", "--date", "2020-01-01T00:00:00Z")
+
+	content := oldBlock + "\nremoved {\n  from = aws_instance.recent\n}\n"
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	runGit(t, tempDir, "commit", "-q", "-am", "recent block")
+
+	stats := Stats{StartTime: time.Now(), OlderThan: 90 * 24 * time.Hour}
+	if err := processFile(testFile, &stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+
+	if stats.RemovedBlocksRemoved != 1 || stats.RemovedBlocksSkipped != 1 {
+		t.Errorf("Expected 1 removed and 1 skipped block, but got %d removed and %d skipped", stats.RemovedBlocksRemoved, stats.RemovedBlocksSkipped)
+	}
+
+	modifiedContent, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read modified file: %v", err)
+	}
+	if strings.Contains(string(modifiedContent), "aws_instance.old") || !strings.Contains(string(modifiedContent), "aws_instance.recent") {
+		t.Errorf("Expected only the old block to be removed:\n%s", modifiedContent)
+	}
+}
+
+func TestOlderThanOutsideGitKeepsBlocks(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-older-than-nogit-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	testFile := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(testFile, []byte("removed {\n  from = aws_instance.old\n}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	stats := Stats{DryRun: true, OlderThan: time.Hour}
+	if err := processFile(testFile, &stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+
+	if stats.RemovedBlocksRemoved != 0 {
+		t.Errorf("Expected block with unknown age to be kept")
+	}
+	if len(stats.Warnings) != 1 {
+		t.Errorf("Expected a warning about the unknown age, but got %v", stats.Warnings)
+	}
+}
@@ -0,0 +1,479 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// cliFlags holds the values of the command-line flags, in the order they
+// are registered.
+type cliFlags struct {
+	help                  bool
+	config                string
+	noConfig              bool
+	version               bool
+	dryRun                bool
+	verbose               bool
+	normalize             bool
+	fmtAll                bool
+	maxBlankLines         int
+	noFormat              bool
+	normalizeAll          bool
+	finalNewline          string
+	lineEndings           string
+	preserveEncoding      bool
+	repo                  stringSliceFlag
+	only                  stringSliceFlag
+	excludeAddress        stringSliceFlag
+	provider              stringSliceFlag
+	typePrefix            stringSliceFlag
+	module                stringSliceFlag
+	addressFile           string
+	expiring              string
+	olderThan             string
+	list                  bool
+	diff                  bool
+	diffAlgorithm         string
+	diffContext           int
+	blame                 bool
+	ext                   stringSliceFlag
+	maxDepth              int
+	noRecursive           bool
+	excludeDir            stringSliceFlag
+	noTerraformignore     bool
+	noGitignore           bool
+	includeDotTerraform   bool
+	terragrunt            bool
+	followSymlinks        bool
+	allowOutsideRoot      bool
+	files                 string
+	plan                  string
+	owners                bool
+	gitDiff               string
+	gitCommit             string
+	gitBranch             string
+	createPR              bool
+	staged                bool
+	check                 bool
+	baseline              string
+	baselineWrite         bool
+	baselinePrune         bool
+	external              bool
+	auditLog              string
+	stripLeadingComments  bool
+	stripTrailingComments bool
+	tombstone             bool
+	archive               string
+	stageDeletes          string
+	redactConfig          string
+	stateKey              stringSliceFlag
+	backendConfig         stringSliceFlag
+	stateDir              string
+	jiraProject           string
+	servePreview          string
+	summaryFile           string
+	summaryInterval       time.Duration
+	watch                 bool
+	watchInterval         time.Duration
+	worker                bool
+	cache                 string
+	editsJSON             string
+	inventory             string
+	maxFileSize           string
+	cpuProfile            string
+	memProfile            string
+	maxDuration           time.Duration
+	continueToken         string
+	progress              string
+	pure                  bool
+	logLevel              string
+	logFormat             string
+	logFile               string
+	backup                backupFlag
+	skipInvalid           bool
+	interactive           bool
+	failFast              bool
+	failOnChange          bool
+	noColor               bool
+	output                string
+	listen                string
+	grpcListen            string
+}
+
+// newCLIFlags registers the command-line flags on fs.
+func newCLIFlags(fs *flag.FlagSet) *cliFlags {
+	f := &cliFlags{}
+	fs.BoolVar(&f.help, "help", false, "Display help information")
+	fs.StringVar(&f.config, "config", "", "Read default options from this HCL file instead of the "+configFileName+" found in the current directory or a parent")
+	fs.BoolVar(&f.noConfig, "no-config", false, "Do not read a configuration file")
+	fs.BoolVar(&f.version, "version", false, "Display version information")
+	fs.BoolVar(&f.dryRun, "dry-run", false, "Run without modifying files")
+	fs.BoolVar(&f.verbose, "verbose", false, "Log each file processed, the same as -log-level debug")
+	fs.BoolVar(&f.normalize, "normalize-whitespace", false, "Normalize whitespace after removing removed blocks")
+	fs.BoolVar(&f.fmtAll, "fmt-all", false, "Format every file, not only files where removed blocks were deleted")
+	fs.IntVar(&f.maxBlankLines, "max-blank-lines", defaultMaxBlankLines, "Most consecutive blank lines -normalize-all, and -normalize-whitespace in fmt, keep, and -normalize-whitespace leaves where blocks were removed")
+	fs.BoolVar(&f.noFormat, "no-format", false, "Never format files; only cut out the removed blocks, leaving the rest as it was")
+	fs.BoolVar(&f.normalizeAll, "normalize-all", false, "Normalize whitespace in every file, even those without removed blocks")
+	fs.StringVar(&f.finalNewline, "final-newline", "", "End-of-file newline policy: always (one), preserve, or never (none) (default: as formatted)")
+	fs.StringVar(&f.lineEndings, "line-endings", lineEndingsPreserve, "Line endings of the files written: lf, crlf, or preserve to keep each line's")
+	fs.BoolVar(&f.preserveEncoding, "preserve-encoding", false, "Write UTF-16 files back as UTF-16 instead of converting them to UTF-8")
+	fs.Var(&f.repo, "repo", "Clone this git repository, clean it, and push the changes on a new branch; path arguments are relative to it (repeatable)")
+	fs.Var(&f.only, "only", "Only remove blocks whose from address matches this glob pattern (repeatable)")
+	fs.Var(&f.excludeAddress, "exclude-address", "Never remove blocks whose from address matches this regular expression (repeatable)")
+	fs.Var(&f.provider, "provider", "Only remove blocks for resources of this provider, e.g. aws (repeatable)")
+	fs.Var(&f.typePrefix, "type-prefix", "Only remove blocks for resource types with this prefix, e.g. aws_ (repeatable)")
+	fs.Var(&f.module, "module", "Only remove blocks targeting this module call, e.g. module.networking (repeatable)")
+	fs.StringVar(&f.addressFile, "address-file", "", "Only remove blocks whose from address is listed in this file, one per line")
+	fs.StringVar(&f.expiring, "expiring", "", "List blocks whose remove-after date passes within this long, e.g. 30d")
+	fs.StringVar(&f.olderThan, "older-than", "", "Only remove blocks committed at least this long ago according to git blame, e.g. 90d")
+	fs.BoolVar(&f.list, "list", false, "List removed blocks that would be removed without modifying files")
+	fs.BoolVar(&f.diff, "diff", false, "Print a unified diff of every file that is, or with -dry-run would be, changed")
+	fs.StringVar(&f.diffAlgorithm, "diff-algorithm", diffMyers, "Algorithm for -diff: myers, patience, or histogram")
+	fs.IntVar(&f.diffContext, "diff-context", defaultDiffContext, "Number of unchanged lines shown around each change in -diff output")
+	fs.BoolVar(&f.blame, "blame", false, "Include the commit, author, and date that introduced each block in reports (uses git blame)")
+	fs.Var(&f.ext, "ext", "File extension to process, e.g. .tfpart (repeatable, default .tf)")
+	fs.IntVar(&f.maxDepth, "max-depth", -1, "Descend at most this many directory levels below each root directory (0 means the root only)")
+	fs.BoolVar(&f.noRecursive, "no-recursive", false, "Only process files directly in each root directory, same as -max-depth 0")
+	fs.Var(&f.excludeDir, "exclude-dir", "Never walk directories matching this glob pattern relative to each root, e.g. 'examples/**' (repeatable)")
+	fs.BoolVar(&f.noTerraformignore, "no-terraformignore", false, "Process files excluded by the .terraformignore file of each root directory")
+	fs.BoolVar(&f.noGitignore, "no-gitignore", false, "Process files excluded by .gitignore, which are skipped by default inside git repositories")
+	fs.BoolVar(&f.includeDotTerraform, "include-dot-terraform", false, "Process files inside .terraform directories, which are skipped by default")
+	fs.BoolVar(&f.terragrunt, "terragrunt", false, "Also clean removed blocks inside the generate blocks of terragrunt.hcl files")
+	fs.BoolVar(&f.followSymlinks, "follow-symlinks", false, "Walk symlinked directories and process symlinked files, which are skipped by default")
+	fs.BoolVar(&f.allowOutsideRoot, "allow-outside-root", false, "Process files that resolve, through symlinks or .. segments, to locations outside the given roots, after confirmation")
+	fs.StringVar(&f.files, "files", "", "Read newline-separated file paths from this file, or - for stdin, instead of walking a directory")
+	fs.StringVar(&f.plan, "plan", "", "Only delete the removed blocks listed in this JSON edit plan, processing only the files it names")
+	fs.BoolVar(&f.owners, "owners", false, "Include the last committer and commit of each block in JSON output (uses git blame)")
+	fs.StringVar(&f.gitDiff, "git-diff", "", "Only process files changed in this git diff range, e.g. origin/main...HEAD")
+	fs.StringVar(&f.gitCommit, "git-commit", "", "Commit the files the run modified, and only those, with this message")
+	fs.StringVar(&f.gitBranch, "git-branch", "", "Commit the files the run modified on this new branch and push it to origin")
+	fs.BoolVar(&f.createPR, "create-pr", false, "With -git-branch, open a GitHub pull request for the pushed branch (uses GITHUB_TOKEN, or the gh CLI)")
+	fs.BoolVar(&f.staged, "staged", false, "Only process files staged in git, and stage them again once rewritten, for use as a pre-commit hook")
+	fs.BoolVar(&f.check, "check", false, "Report removed blocks without modifying files and exit 1 if any are found")
+	fs.StringVar(&f.baseline, "baseline-suppress", "", "Baseline file of known removed blocks that -check does not fail on")
+	fs.BoolVar(&f.baselineWrite, "baseline-write", false, "Regenerate the -baseline-suppress file from the current scan")
+	fs.BoolVar(&f.baselinePrune, "baseline-prune", false, "Drop -baseline-suppress entries for blocks that no longer exist")
+	fs.BoolVar(&f.external, "external-data-source", false, "Run as a Terraform external data source: read a JSON query on stdin and print counts as JSON")
+	fs.StringVar(&f.auditLog, "audit-log", "", "Append a JSON record of every deleted block to this file")
+	fs.BoolVar(&f.stripLeadingComments, "strip-leading-comments", false, "Also delete the comment lines directly above each deleted block")
+	fs.BoolVar(&f.stripTrailingComments, "strip-trailing-comments", false, "Also delete the comments following each deleted block")
+	fs.BoolVar(&f.tombstone, "tombstone", false, "Replace every deleted block with a comment naming its address and the date")
+	fs.StringVar(&f.archive, "archive", "", "Append the source of every deleted block to this file")
+	fs.StringVar(&f.stageDeletes, "stage-deletes", "", "Copy every deleted block into this directory as its own .tf file")
+	fs.StringVar(&f.redactConfig, "redact-config", "", "JSON file of regex redaction rules applied to addresses and messages in reports")
+	fs.Var(&f.stateKey, "state-key", "Only process root modules whose backend state key matches this glob pattern, and the local modules they call, e.g. prod/networking.tfstate (repeatable)")
+	fs.Var(&f.backendConfig, "backend-config", "Backend configuration -state-key and doctor resolve state keys with, as a key=value pair or a file of attributes like terraform init -backend-config, optionally prefixed with a root module directory and a colon (repeatable)")
+	fs.StringVar(&f.stateDir, "state-dir", "", "Record the last-clean time and tool version of every processed file in this sidecar directory, e.g. .trr-state")
+	fs.StringVar(&f.jiraProject, "jira-project", "", "Open or update a Jira issue per module listing its removed blocks, and close it once the module is clean, e.g. INFRA (uses JIRA_URL and JIRA_TOKEN)")
+	fs.StringVar(&f.servePreview, "serve-preview", "", "With -dry-run, serve a web page listing prospective changes at this address while scanning, e.g. localhost:8080")
+	fs.StringVar(&f.summaryFile, "summary-file", "", "Keep a JSON summary of the run in progress up to date in this file")
+	fs.DurationVar(&f.summaryInterval, "summary-interval", defaultSummaryInterval, "How often -summary-file is rewritten during a run")
+	fs.BoolVar(&f.watch, "watch", false, "After the run, keep processing files as they are added or changed until interrupted")
+	fs.DurationVar(&f.watchInterval, "watch-interval", defaultWatchInterval, "How long -watch waits after a file notification for changes to settle before processing them")
+	fs.BoolVar(&f.worker, "persistent_worker", false, "Run as a Bazel persistent worker using the JSON worker protocol on stdin and stdout")
+	fs.StringVar(&f.cache, "cache", "", "Remember the files found clean in this file and skip them on later runs while they are unchanged, e.g. .removed-remover-cache")
+	fs.StringVar(&f.editsJSON, "edits-json", "", "Write the changes the run makes, or would make with -dry-run, to this file as an LSP WorkspaceEdit of text edits per file")
+	fs.StringVar(&f.inventory, "inventory", "", "Write an inventory of the files scanned, providers and modules seen, and block type counts to this JSON file")
+	fs.StringVar(&f.maxFileSize, "max-file-size", "", "Skip files larger than this size with a warning instead of loading them, e.g. 10MB")
+	fs.StringVar(&f.cpuProfile, "cpuprofile", "", "Write a CPU profile of discovery and processing to this file, for go tool pprof")
+	fs.StringVar(&f.memProfile, "memprofile", "", "Write a heap profile taken after processing to this file, for go tool pprof")
+	fs.DurationVar(&f.maxDuration, "max-duration", 0, "Stop starting new files after this long and write a continuation token for -continue, e.g. 5m")
+	fs.StringVar(&f.continueToken, "continue", "", "Resume from this continuation token, if it exists; -max-duration writes the next one here (default "+defaultContinuationFile+")")
+	fs.StringVar(&f.progress, "progress", "auto", "Show how many files have been processed on stderr: auto (only on a terminal), on, or off")
+	fs.BoolVar(&f.pure, "pure", false, "Run as a hermetic filter: no network, git, or environment access, and deterministic output")
+	fs.StringVar(&f.logLevel, "log-level", "", "Lowest level of messages to log: debug, info, warn, or error (default info, or debug with -verbose)")
+	fs.StringVar(&f.logFormat, "log-format", "text", "Format of log messages: text or json")
+	fs.StringVar(&f.logFile, "log-file", "", "Append log messages to this file instead of printing them, at -log-level debug unless given")
+	fs.Var(&f.backup, "backup", "Keep the original of each modified file: -backup for a .bak copy next to it, -backup=.orig for another suffix, or -backup=dir for a copy below dir")
+	fs.BoolVar(&f.skipInvalid, "skip-invalid", false, "Skip files that can't be parsed with a warning instead of failing them, e.g. intentionally broken fixtures")
+	fs.BoolVar(&f.interactive, "interactive", false, "Show each removed block and ask before deleting it")
+	fs.BoolVar(&f.failFast, "fail-fast", false, "Stop at the first file that can't be processed instead of reporting every failure at the end")
+	fs.BoolVar(&f.failOnChange, "fail-on-change", false, "Exit with status 1 if any file was or, with -dry-run, would be modified, including by formatting")
+	fs.BoolVar(&f.noColor, "no-color", false, "Don't color the diff and summary, even on a terminal (also set by NO_COLOR)")
+	fs.StringVar(&f.output, "output", "text", "Output format for the summary: text or json")
+	fs.StringVar(&f.listen, "listen", defaultServeAddr, "Address the serve subcommand listens on")
+	fs.StringVar(&f.grpcListen, "grpc-listen", "", "Address the serve subcommand also serves the gRPC API on, e.g. localhost:9090")
+	return f
+}
+
+// validate checks the flags of a run that processes files, as parsed by
+// flags, for values and combinations that can't work, before anything is
+// read or written.
+func (f *cliFlags) validate(subcommand string, flags *flag.FlagSet) error {
+	formatOnly := subcommand == "fmt"
+	doctor := subcommand == "doctor"
+	browse := subcommand == "tui"
+	args := flags.Args()
+
+	if f.files != "" && len(args) > 0 {
+		return errors.New("-files cannot be combined with path arguments")
+	}
+	if f.watch {
+		switch {
+		case f.watchInterval <= 0:
+			return errors.New("-watch-interval must be positive")
+		case f.check || f.list || f.output == "json" || f.baselineWrite || f.baselinePrune || f.interactive || f.servePreview != "" || browse || doctor:
+			return errors.New("-watch cannot be combined with -check, -list, -output json, -baseline-write, -baseline-prune, -interactive, -serve-preview, tui, or doctor")
+		case f.files != "" || f.plan != "" || f.gitDiff != "" || f.maxDuration > 0 || f.continueToken != "" || f.external || f.worker:
+			return errors.New("-watch walks the given paths and cannot be combined with -files, -plan, -git-diff, -max-duration, -continue, or the external data source and worker modes")
+		}
+	}
+	if (f.gitCommit != "" || f.gitBranch != "") && (f.dryRun || f.check || f.list || f.staged || f.watch || browse || doctor) {
+		return errors.New("-git-commit and -git-branch cannot be combined with -dry-run, -check, -list, -staged, -watch, tui, or doctor")
+	}
+	if f.createPR && f.gitBranch == "" && len(f.repo) == 0 {
+		return errors.New("-create-pr needs -git-branch")
+	}
+	// A continued run would not know the plan and delete every block
+	if f.plan != "" && (f.files != "" || len(args) > 0 || f.maxDuration > 0 || f.continueToken != "" || browse || f.external || f.worker) {
+		return errors.New("-plan names the files to process and cannot be combined with path arguments, -files, -max-duration, -continue, tui, or the external data source and worker modes")
+	}
+	if len(f.repo) > 0 {
+		switch {
+		case f.files != "" || f.plan != "" || f.staged || f.gitDiff != "" || f.continueToken != "" || f.maxDuration > 0:
+			return errors.New("-repo cannot be combined with -files, -plan, -staged, -git-diff, -continue, or -max-duration")
+		case f.watch || f.interactive || browse || doctor || f.external || f.worker:
+			return errors.New("-repo cannot be combined with -watch, -interactive, tui, doctor, or the external data source and worker modes")
+		}
+	}
+	if f.maxBlankLines < 1 {
+		return errors.New("-max-blank-lines must be at least 1")
+	}
+	if f.noFormat && (f.fmtAll || formatOnly) {
+		return errors.New("-no-format cannot be combined with -fmt-all or the fmt subcommand")
+	}
+	// The edits refer to the files as they were before a single pass
+	if f.editsJSON != "" && (f.watch || browse || len(f.repo) > 0 || f.external || f.worker) {
+		return errors.New("-edits-json cannot be combined with -watch, -repo, tui, or the external data source and worker modes")
+	}
+
+	for _, ext := range f.ext {
+		if ext == "" || ext == "." {
+			return fmt.Errorf("invalid -ext value %q", ext)
+		}
+	}
+	for _, pattern := range f.stateKey {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid -state-key pattern %q: %w", pattern, err)
+		}
+	}
+	if len(f.backendConfig) > 0 && len(f.stateKey) == 0 && subcommand != "doctor" {
+		return errors.New("-backend-config requires -state-key or the doctor subcommand")
+	}
+	for _, item := range f.backendConfig {
+		_, setting := splitBackendConfigScope(item)
+		if name, _, ok := strings.Cut(setting, "="); setting == "" || ok && strings.TrimSpace(name) == "" {
+			return fmt.Errorf("invalid -backend-config value %q", item)
+		}
+	}
+	for _, pattern := range f.excludeDir {
+		if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+			return fmt.Errorf("invalid -exclude-dir pattern %q: %w", pattern, err)
+		}
+	}
+	for _, pattern := range f.only {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid -only pattern %q: %w", pattern, err)
+		}
+	}
+	for _, module := range f.module {
+		if address, err := parseAddress(module); err != nil || address.Kind != AddressKindModule {
+			return fmt.Errorf("invalid -module address %q: expected a module address like module.networking", module)
+		}
+	}
+	if _, ok := parseFinalNewline(f.finalNewline); !ok {
+		return fmt.Errorf("invalid -final-newline %q: must be always (one), preserve, or never (none)", f.finalNewline)
+	}
+	switch f.lineEndings {
+	case lineEndingsPreserve, lineEndingsLF, lineEndingsCRLF:
+	default:
+		return fmt.Errorf("invalid -line-endings %q: must be lf, crlf, or preserve", f.lineEndings)
+	}
+
+	if _, ok := diffAlgorithm(f.diffAlgorithm); !ok {
+		return fmt.Errorf("invalid -diff-algorithm %q: must be myers, patience, or histogram", f.diffAlgorithm)
+	}
+	if f.diffContext < 0 {
+		return errors.New("-diff-context must not be negative")
+	}
+	if f.diff && (f.output == "json" || f.external || f.worker) {
+		return errors.New("-diff cannot be combined with -output json or -external-data-source")
+	}
+	// A dry run doesn't count files that only need formatting as modified,
+	// so their diffs and edits would be cached away.
+	if f.diff && f.cache != "" {
+		return errors.New("-diff cannot be combined with -cache")
+	}
+	if f.editsJSON != "" && f.cache != "" {
+		return errors.New("-edits-json cannot be combined with -cache")
+	}
+	// The prompts read stdin and need the terminal to themselves
+	if f.interactive || browse {
+		switch {
+		case f.dryRun || f.check || f.list:
+			return errors.New("-interactive and tui cannot be combined with -dry-run, -check, or -list")
+		case f.files == "-" || f.external || f.worker:
+			return errors.New("-interactive and tui need stdin for their prompts")
+		case browse && (!isTerminal(os.Stdin) || !isTerminal(os.Stdout)):
+			return errors.New("the tui subcommand needs a terminal")
+		}
+	}
+	if f.maxDuration < 0 {
+		return errors.New("-max-duration must not be negative")
+	}
+	if (f.maxDuration > 0 || f.continueToken != "") && (f.baselineWrite || f.baselinePrune) {
+		return errors.New("-baseline-write and -baseline-prune need a complete run and cannot be combined with -max-duration or -continue")
+	}
+	// Files skipped through the cache would be missing from the inventory.
+	if f.inventory != "" && f.cache != "" {
+		return errors.New("-inventory cannot be combined with -cache")
+	}
+	if f.servePreview != "" && !f.dryRun {
+		return errors.New("-serve-preview requires -dry-run")
+	}
+	if (f.baselineWrite || f.baselinePrune) && f.baseline == "" {
+		return errors.New("-baseline-write and -baseline-prune require -baseline-suppress")
+	}
+	if f.grpcListen != "" && subcommand != "serve" {
+		return errors.New("-grpc-listen requires the serve subcommand")
+	}
+	if subcommand == "serve" && len(args) > 0 {
+		return errors.New("the serve subcommand takes no paths; files are sent to POST /process")
+	}
+	if subcommand == "serve" {
+		var conflicts []string
+		flags.Visit(func(fl *flag.Flag) {
+			if slices.Contains(serveRejectedFlags, fl.Name) {
+				conflicts = append(conflicts, fl.Name)
+			}
+		})
+		if len(conflicts) > 0 {
+			return fmt.Errorf("the serve subcommand cannot be combined with -%s", strings.Join(conflicts, ", -"))
+		}
+	}
+	return nil
+}
+
+// newStats returns the options of a run set by the flags, parsing the
+// values validate does not, such as regular expressions and ages.
+func (f *cliFlags) newStats(subcommand string) (Stats, error) {
+	var extensions []string
+	for _, ext := range f.ext {
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		extensions = append(extensions, ext)
+	}
+	discoveryOptions := DiscoveryOptions{
+		Extensions:          extensions,
+		IncludeDotTerraform: f.includeDotTerraform,
+		ExcludeDirs:         f.excludeDir,
+		Terragrunt:          f.terragrunt,
+		FollowSymlinks:      f.followSymlinks,
+	}
+	if f.noRecursive {
+		discoveryOptions.LimitDepth = true
+	} else if f.maxDepth >= 0 {
+		discoveryOptions.LimitDepth = true
+		discoveryOptions.MaxDepth = f.maxDepth
+	}
+
+	var excludeAddress []*regexp.Regexp
+	for _, expr := range f.excludeAddress {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return Stats{}, fmt.Errorf("invalid -exclude-address expression %q: %w", expr, err)
+		}
+		excludeAddress = append(excludeAddress, re)
+	}
+
+	var allowedAddresses map[string]bool
+	if f.addressFile != "" {
+		var err error
+		if allowedAddresses, err = loadAddressFile(f.addressFile); err != nil {
+			return Stats{}, err
+		}
+	}
+
+	var maxFileSize int64
+	if f.maxFileSize != "" {
+		var err error
+		if maxFileSize, err = parseSize(f.maxFileSize); err != nil {
+			return Stats{}, fmt.Errorf("invalid -max-file-size value: %w", err)
+		}
+	}
+
+	var olderThan time.Duration
+	if f.olderThan != "" {
+		var err error
+		if olderThan, err = parseAge(f.olderThan); err != nil {
+			return Stats{}, fmt.Errorf("invalid -older-than value: %w", err)
+		}
+	}
+
+	var expiringWithin time.Duration
+	if f.expiring != "" {
+		var err error
+		if expiringWithin, err = parseAge(f.expiring); err != nil {
+			return Stats{}, fmt.Errorf("invalid -expiring value: %w", err)
+		}
+	}
+
+	finalNewline, _ := parseFinalNewline(f.finalNewline)
+	stats := Stats{
+		StartTime:             time.Now(),
+		DryRun:                f.dryRun || f.check || f.list || f.baselineWrite || f.baselinePrune || f.jiraProject != "" || subcommand == "doctor" || subcommand == "tui",
+		NormalizeWhitespace:   f.normalize,
+		NormalizeAll:          f.normalizeAll,
+		FmtAll:                f.fmtAll,
+		MaxBlankLines:         f.maxBlankLines,
+		NoFormat:              f.noFormat,
+		PreserveEncoding:      f.preserveEncoding,
+		FinalNewline:          finalNewline,
+		LineEndings:           f.lineEndings,
+		MaxFileSize:           maxFileSize,
+		DiffAlgorithm:         f.diffAlgorithm,
+		DiffContext:           f.diffContext,
+		Only:                  f.only,
+		ExcludeAddress:        excludeAddress,
+		Providers:             f.provider,
+		TypePrefixes:          f.typePrefix,
+		Modules:               f.module,
+		AllowedAddresses:      allowedAddresses,
+		OlderThan:             olderThan,
+		ExpiringWithin:        expiringWithin,
+		Blame:                 f.blame,
+		Owners:                f.owners,
+		StageDir:              f.stageDeletes,
+		DiscoveryOptions:      discoveryOptions,
+		FormatOnly:            subcommand == "fmt",
+		Consolidate:           subcommand == "consolidate",
+		Tombstone:             f.tombstone,
+		StripLeadingComments:  f.stripLeadingComments,
+		StripTrailingComments: f.stripTrailingComments,
+		FailOnChange:          f.failOnChange,
+		SkipInvalid:           f.skipInvalid,
+		Backup:                string(f.backup),
+		Pure:                  f.pure,
+	}
+	if f.diff {
+		stats.DiffOutput = os.Stdout
+	}
+	if f.output != "json" {
+		stats.Color = colorEnabled(os.Stdout, f.noColor, f.pure)
+	}
+	if f.editsJSON != "" {
+		stats.Edits = newWorkspaceEdit()
+	}
+	if f.inventory != "" {
+		stats.Inventory = newInventory()
+	}
+	return stats, nil
+}
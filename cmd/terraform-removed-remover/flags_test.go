@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestValidateFlags(t *testing.T) {
+	for _, tt := range []struct {
+		args       []string
+		subcommand string
+		expected   string
+	}{
+		{args: []string{"-dry-run", "."}},
+		{args: []string{"-files", "list.txt", "."}, expected: "-files cannot be combined with path arguments"},
+		{args: []string{"-watch", "-check"}, expected: "-watch cannot be combined"},
+		{args: []string{"-max-blank-lines", "0"}, expected: "-max-blank-lines must be at least 1"},
+		{args: []string{"-only", "["}, expected: "invalid -only pattern"},
+		{args: []string{"-module", "aws_instance.web"}, expected: "invalid -module address"},
+		{args: []string{"-backend-config", "backend.hcl"}, expected: "-backend-config requires -state-key or the doctor subcommand"},
+		{args: []string{"-backend-config", "backend.hcl"}, subcommand: "doctor"},
+		{args: []string{"-state-key", "prod/*", "-backend-config", "=prod"}, expected: "invalid -backend-config value"},
+		{args: []string{"-state-key", "prod/*", "-backend-config", "envs/prod:"}, expected: "invalid -backend-config value"},
+		{args: []string{"-final-newline", "twice"}, expected: "invalid -final-newline"},
+		{args: []string{"-no-format"}, subcommand: "fmt", expected: "-no-format cannot be combined"},
+		{args: []string{"."}, subcommand: "serve", expected: "the serve subcommand takes no paths"},
+		{args: []string{"-grpc-listen", "localhost:9090"}, expected: "-grpc-listen requires the serve subcommand"},
+		{args: []string{"-grpc-listen", "localhost:9090"}, subcommand: "serve"},
+		{args: []string{"-older-than", "90d", "-audit-log", "audit.jsonl"}, subcommand: "serve", expected: "the serve subcommand cannot be combined with -audit-log, -older-than"},
+		{args: []string{"-dry-run", "-fmt-all", "-edits-json", "edits.json", "-cache", ".cache"}, expected: "-edits-json cannot be combined with -cache"},
+	} {
+		t.Run(strings.Join(append([]string{tt.subcommand}, tt.args...), " "), func(t *testing.T) {
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			f := newCLIFlags(flags)
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+
+			err := f.validate(tt.subcommand, flags)
+			switch {
+			case tt.expected == "" && err != nil:
+				t.Errorf("Expected no error, but got %v", err)
+			case tt.expected != "" && (err == nil || !strings.Contains(err.Error(), tt.expected)):
+				t.Errorf("Expected an error containing %q, but got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestNewStats(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	f := newCLIFlags(flags)
+	if err := flags.Parse([]string{"-check", "-ext", "tfpart", "-older-than", "30d", "-exclude-address", "^module\\.", "-final-newline", "one"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	stats, err := f.newStats("")
+	if err != nil {
+		t.Fatalf("newStats failed: %v", err)
+	}
+	if !stats.DryRun {
+		t.Errorf("Expected -check to be a dry run")
+	}
+	if len(stats.DiscoveryOptions.Extensions) != 1 || stats.DiscoveryOptions.Extensions[0] != ".tfpart" {
+		t.Errorf("Expected extensions [.tfpart], but got %v", stats.DiscoveryOptions.Extensions)
+	}
+	if stats.OlderThan.Hours() != 30*24 {
+		t.Errorf("Expected -older-than of 30 days, but got %s", stats.OlderThan)
+	}
+	if len(stats.ExcludeAddress) != 1 || !stats.ExcludeAddress[0].MatchString("module.vpc") {
+		t.Errorf("Expected an -exclude-address expression matching module.vpc, but got %v", stats.ExcludeAddress)
+	}
+	if stats.FinalNewline != "always" {
+		t.Errorf("Expected final newline policy always, but got %q", stats.FinalNewline)
+	}
+
+	if err := flags.Set("exclude-address", "("); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, err := f.newStats(""); err == nil || !strings.Contains(err.Error(), "invalid -exclude-address") {
+		t.Errorf("Expected an invalid -exclude-address error, but got %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for forgeClient. GitHub asks clients that hit a secondary rate
+// limit without a Retry-After header to wait at least a minute.
+const (
+	forgeMaxRetries         = 5
+	forgeBaseDelay          = time.Second
+	forgeMaxWait            = 15 * time.Minute
+	forgeSecondaryRateDelay = time.Minute
+)
+
+// forgeClient is the HTTP client shared by every integration that talks to
+// GitHub, GitLab, or similar APIs. It waits out primary and secondary rate
+// limits, retries transient failures with exponential backoff, and pauses
+// all requests once the remaining quota reaches zero, so bot runs across
+// many repositories stay within the limits.
+type forgeClient struct {
+	httpClient *http.Client
+	maxRetries int
+	baseDelay  time.Duration
+	maxWait    time.Duration
+
+	// sleep and now are replaced in tests.
+	sleep func(time.Duration)
+	now   func() time.Time
+
+	mu         sync.Mutex
+	pauseUntil time.Time
+}
+
+func newForgeClient() *forgeClient {
+	return &forgeClient{
+		httpClient: &http.Client{Timeout: time.Minute},
+		maxRetries: forgeMaxRetries,
+		baseDelay:  forgeBaseDelay,
+		maxWait:    forgeMaxWait,
+		sleep:      time.Sleep,
+		now:        time.Now,
+	}
+}
+
+// Do sends req, retrying when the server reports a rate limit or a transient
+// error. Requests with a body must be replayable, which is the case for
+// requests built by http.NewRequest from a bytes or strings reader.
+func (c *forgeClient) Do(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		c.waitForQuota()
+
+		if attempt > 0 && req.Body != nil {
+			if req.GetBody == nil {
+				return nil, fmt.Errorf("cannot retry %s %s: request body is not replayable", req.Method, req.URL)
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			if attempt >= c.maxRetries {
+				return nil, err
+			}
+			c.sleep(c.backoff(attempt))
+			continue
+		}
+
+		c.recordQuota(resp)
+
+		wait, retry, err := c.retryDelay(resp, attempt)
+		if err != nil || !retry {
+			return resp, err
+		}
+		if attempt >= c.maxRetries {
+			return resp, nil
+		}
+		if wait > c.maxWait {
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("%s %s: rate limited for %v, longer than the %v limit", req.Method, req.URL, wait, c.maxWait)
+		}
+
+		_ = resp.Body.Close()
+		c.sleep(wait)
+	}
+}
+
+// retryDelay decides whether resp should be retried and how long to wait.
+func (c *forgeClient) retryDelay(resp *http.Response, attempt int) (time.Duration, bool, error) {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		if wait, ok := c.rateLimitWait(resp.Header); ok {
+			return wait, true, nil
+		}
+		return c.backoff(attempt), true, nil
+
+	case resp.StatusCode == http.StatusForbidden:
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			wait, _ := c.rateLimitWait(resp.Header)
+			return wait, true, nil
+		}
+
+		// Secondary rate limits are reported as 403 with an explanatory
+		// body; keep the body readable for callers if it's something else.
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return 0, false, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		if !strings.Contains(strings.ToLower(string(body)), "secondary rate limit") && resp.Header.Get("Retry-After") == "" {
+			return 0, false, nil
+		}
+		if wait, ok := c.rateLimitWait(resp.Header); ok {
+			return wait, true, nil
+		}
+		return forgeSecondaryRateDelay, true, nil
+
+	case resp.StatusCode >= 500:
+		return c.backoff(attempt), true, nil
+	}
+
+	return 0, false, nil
+}
+
+// rateLimitWait reads the wait time from Retry-After or from the reset time
+// used by GitHub (X-RateLimit-Reset) and GitLab (RateLimit-Reset).
+func (c *forgeClient) rateLimitWait(header http.Header) (time.Duration, bool) {
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if date, err := http.ParseTime(value); err == nil {
+			return max(date.Sub(c.now()), 0), true
+		}
+	}
+
+	if reset, ok := resetTime(header); ok {
+		return max(reset.Sub(c.now()), 0), true
+	}
+	return 0, false
+}
+
+// recordQuota pauses future requests until the reset time once the server
+// reports that no requests remain.
+func (c *forgeClient) recordQuota(resp *http.Response) {
+	remaining := resp.Header.Get("X-RateLimit-Remaining")
+	if remaining == "" {
+		remaining = resp.Header.Get("RateLimit-Remaining")
+	}
+	if remaining != "0" {
+		return
+	}
+
+	if reset, ok := resetTime(resp.Header); ok {
+		c.mu.Lock()
+		if reset.After(c.pauseUntil) {
+			c.pauseUntil = reset
+		}
+		c.mu.Unlock()
+	}
+}
+
+func (c *forgeClient) waitForQuota() {
+	c.mu.Lock()
+	wait := c.pauseUntil.Sub(c.now())
+	c.mu.Unlock()
+
+	if wait > 0 {
+		c.sleep(min(wait, c.maxWait))
+	}
+}
+
+// backoff returns an exponentially growing delay with up to 50% jitter.
+func (c *forgeClient) backoff(attempt int) time.Duration {
+	delay := c.baseDelay << min(attempt, 10)
+	// #nosec G404 -- jitter does not need a cryptographic source
+	return delay + time.Duration(rand.Int63n(int64(delay)/2+1))
+}
+
+func resetTime(header http.Header) (time.Time, bool) {
+	value := header.Get("X-RateLimit-Reset")
+	if value == "" {
+		value = header.Get("RateLimit-Reset")
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(seconds, 0), true
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestForgeClient returns a client whose clock only advances when it
+// sleeps, recording each sleep instead of waiting.
+func newTestForgeClient(now time.Time) (*forgeClient, *[]time.Duration) {
+	var sleeps []time.Duration
+	client := newForgeClient()
+	client.baseDelay = 10 * time.Millisecond
+	client.now = func() time.Time { return now }
+	client.sleep = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+		now = now.Add(d)
+	}
+	return client, &sleeps
+}
+
+func TestForgeClientRetriesRateLimits(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name      string
+		respond   func(w http.ResponseWriter)
+		wantSleep time.Duration
+	}{
+		{
+			name: "429 with Retry-After",
+			respond: func(w http.ResponseWriter) {
+				w.Header().Set("Retry-After", "7")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			wantSleep: 7 * time.Second,
+		},
+		{
+			name: "GitHub primary rate limit",
+			respond: func(w http.ResponseWriter) {
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(30*time.Second).Unix(), 10))
+				w.WriteHeader(http.StatusForbidden)
+			},
+			wantSleep: 30 * time.Second,
+		},
+		{
+			name: "GitHub secondary rate limit",
+			respond: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = io.WriteString(w, `{"message": "You have exceeded a secondary rate limit."}`)
+			},
+			wantSleep: forgeSecondaryRateDelay,
+		},
+		{
+			name: "GitLab rate limit",
+			respond: func(w http.ResponseWriter) {
+				w.Header().Set("RateLimit-Reset", strconv.FormatInt(now.Add(12*time.Second).Unix(), 10))
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			wantSleep: 12 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if string(body) != "payload" {
+					t.Errorf("Request body was not replayed, got %q", body)
+				}
+				if atomic.AddInt32(&calls, 1) == 1 {
+					tt.respond(w)
+					return
+				}
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer server.Close()
+
+			client, sleeps := newTestForgeClient(now)
+			req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("payload"))
+			if err != nil {
+				t.Fatalf("Failed to build request: %v", err)
+			}
+
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do failed: %v", err)
+			}
+			_ = resp.Body.Close()
+
+			if resp.StatusCode != http.StatusCreated {
+				t.Errorf("Expected the retried request to succeed, got %d", resp.StatusCode)
+			}
+			if len(*sleeps) != 1 || (*sleeps)[0] != tt.wantSleep {
+				t.Errorf("Expected a single sleep of %v, got %v", tt.wantSleep, *sleeps)
+			}
+		})
+	}
+}
+
+func TestForgeClientBacksOffOnServerErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client, sleeps := newTestForgeClient(time.Now())
+	client.maxRetries = 3
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadGateway || calls != 4 {
+		t.Errorf("Expected 4 attempts ending in 502, got %d attempts and status %d", calls, resp.StatusCode)
+	}
+	for i := 1; i < len(*sleeps); i++ {
+		if (*sleeps)[i] < (*sleeps)[i-1] {
+			t.Errorf("Expected growing backoff, got %v", *sleeps)
+		}
+	}
+}
+
+func TestForgeClientDoesNotRetryPlainForbidden(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = io.WriteString(w, "Resource not accessible by integration")
+	}))
+	defer server.Close()
+
+	client, _ := newTestForgeClient(time.Now())
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if calls != 1 || !strings.Contains(string(body), "not accessible") {
+		t.Errorf("Expected a single attempt with the body preserved, got %d attempts and body %q", calls, body)
+	}
+}
+
+func TestForgeClientPausesWhenQuotaExhausted(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(20*time.Second).Unix(), 10))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, sleeps := newTestForgeClient(now)
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatalf("Failed to build request: %v", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Do failed: %v", err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	if len(*sleeps) != 1 || (*sleeps)[0] != 20*time.Second {
+		t.Errorf("Expected the second request to wait for the reset, got %v", *sleeps)
+	}
+}
+
+func TestForgeClientGivesUpOnLongWaits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Retry-After", "86400")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client, _ := newTestForgeClient(time.Now())
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	if _, err := client.Do(req); err == nil {
+		t.Errorf("Expected an error when the rate limit outlasts maxWait, but got nil")
+	}
+}
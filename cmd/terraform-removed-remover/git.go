@@ -0,0 +1,388 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// uncommittedSHA is the commit git blame reports for lines that are not yet
+// committed.
+const uncommittedSHA = "0000000000000000000000000000000000000000"
+
+// BlameInfo describes the commit that last touched a line.
+type BlameInfo struct {
+	Commit string
+	Author string
+	Time   time.Time
+	// Committer, CommitterEmail and CommitTime describe who committed the
+	// change, which can differ from the author for applied patches.
+	Committer      string
+	CommitterEmail string
+	CommitTime     time.Time
+}
+
+// Uncommitted reports whether the line has not been committed yet.
+func (b *BlameInfo) Uncommitted() bool {
+	return b.Commit == uncommittedSHA
+}
+
+// gitBlameLine runs git blame for a single line of filePath.
+func gitBlameLine(filePath string, line int) (*BlameInfo, error) {
+	dir, base := filepath.Split(filePath)
+	if dir == "" {
+		dir = "."
+	}
+
+	lineRange := fmt.Sprintf("%d,%d", line, line)
+	cmd := exec.Command("git", "-C", dir, "blame", "--porcelain", "-L", lineRange, "--", base)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git blame %s:%d failed: %s", filePath, line, strings.TrimSpace(stderr.String()))
+	}
+
+	return parseBlamePorcelain(output)
+}
+
+// parseBlamePorcelain extracts the commit, author, and author time from the
+// output of git blame --porcelain for a single line.
+func parseBlamePorcelain(output []byte) (*BlameInfo, error) {
+	commits, err := parseBlamePorcelainCommits(output)
+	if err != nil {
+		return nil, err
+	}
+	return commits[0], nil
+}
+
+// parseBlamePorcelainCommits returns every distinct commit in the output of
+// git blame --porcelain, in the order they first appear. Porcelain output
+// only repeats a commit's headers on its first line, so later lines of the
+// same commit are recognized by their SHA.
+func parseBlamePorcelainCommits(output []byte) ([]*BlameInfo, error) {
+	var commits []*BlameInfo
+	seen := make(map[string]*BlameInfo)
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	var current *BlameInfo
+	for scanner.Scan() {
+		line := scanner.Text()
+		if current == nil {
+			header := strings.Fields(line)
+			if len(header) < 3 || len(header[0]) != len(uncommittedSHA) {
+				return nil, fmt.Errorf("unexpected git blame output %q", line)
+			}
+			current = seen[header[0]]
+			if current == nil {
+				current = &BlameInfo{Commit: header[0]}
+				seen[header[0]] = current
+				commits = append(commits, current)
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "\t") {
+			current = nil
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, " ")
+		var err error
+		switch key {
+		case "author":
+			current.Author = value
+		case "author-time":
+			current.Time, err = parseBlameTime(key, value)
+		case "committer":
+			current.Committer = value
+		case "committer-mail":
+			current.CommitterEmail = strings.TrimSuffix(strings.TrimPrefix(value, "<"), ">")
+		case "committer-time":
+			current.CommitTime, err = parseBlameTime(key, value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("empty git blame output")
+	}
+	return commits, nil
+}
+
+func parseBlameTime(key, value string) (time.Time, error) {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q in git blame output", key, value)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// gitBlameRange runs git blame for lines start through end of filePath and
+// returns the distinct commits that touched them.
+func gitBlameRange(filePath string, start, end int) ([]*BlameInfo, error) {
+	dir, base := filepath.Split(filePath)
+	if dir == "" {
+		dir = "."
+	}
+
+	lineRange := fmt.Sprintf("%d,%d", start, end)
+	cmd := exec.Command("git", "-C", dir, "blame", "--porcelain", "-L", lineRange, "--", base)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git blame %s:%s failed: %s", filePath, lineRange, strings.TrimSpace(stderr.String()))
+	}
+
+	return parseBlamePorcelainCommits(output)
+}
+
+// blockOwner returns the most recent commit touching any line of block,
+// whose committer is the best hint for who to route the cleanup to. It
+// returns nil when no line of the block is committed yet.
+func blockOwner(filePath string, block *removedBlock) (*BlameInfo, error) {
+	commits, err := gitBlameRange(filePath, block.Line, block.EndLine)
+	if err != nil {
+		return nil, err
+	}
+
+	var owner *BlameInfo
+	for _, commit := range commits {
+		if commit.Uncommitted() {
+			continue
+		}
+		if owner == nil || commit.CommitTime.After(owner.CommitTime) {
+			owner = commit
+		}
+	}
+	return owner, nil
+}
+
+// blockBlame returns the blame information for the first line of block,
+// running git blame at most once per block.
+func blockBlame(filePath string, block *removedBlock) (*BlameInfo, error) {
+	if block.blame == nil && block.blameErr == nil {
+		block.blame, block.blameErr = gitBlameLine(filePath, block.Line)
+	}
+	return block.blame, block.blameErr
+}
+
+// gitChangedFiles returns the absolute, symlink-resolved paths of the files
+// changed in rangeSpec (anything git diff accepts, such as
+// origin/main...HEAD) for the repository containing dir.
+func gitChangedFiles(dir, rangeSpec string) (map[string]bool, error) {
+	return gitDiffFiles(dir, rangeSpec)
+}
+
+// gitStagedFiles returns the absolute, symlink-resolved paths of the files
+// with staged changes that still exist, in the repository containing dir.
+func gitStagedFiles(dir string) (map[string]bool, error) {
+	return gitDiffFiles(dir, "--cached", "--diff-filter=d")
+}
+
+// gitUnstagedFiles returns the absolute, symlink-resolved paths of the files
+// whose working copy differs from what is staged, in the repository
+// containing dir.
+func gitUnstagedFiles(dir string) (map[string]bool, error) {
+	return gitDiffFiles(dir)
+}
+
+// gitDiffFiles returns the files git diff --name-only lists with args, as
+// absolute paths, for the repository containing dir.
+func gitDiffFiles(dir string, args ...string) (map[string]bool, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel")
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git rev-parse failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	topLevel := strings.TrimSpace(string(output))
+
+	stderr.Reset()
+	cmd = exec.Command("git", append(append([]string{"-C", dir, "diff", "--name-only", "-z"}, args...), "--")...)
+	cmd.Stderr = &stderr
+	output, err = cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %s", strings.Join(append([]string{"git diff"}, args...), " "), strings.TrimSpace(stderr.String()))
+	}
+
+	changed := make(map[string]bool)
+	for _, name := range strings.Split(string(output), "\x00") {
+		if name != "" {
+			changed[filepath.Join(topLevel, filepath.FromSlash(name))] = true
+		}
+	}
+	return changed, nil
+}
+
+// filterChangedFiles keeps the files that appear in changed.
+func filterChangedFiles(files []string, changed map[string]bool) []string {
+	var result []string
+	for _, file := range files {
+		if changed[resolvedPath(file)] {
+			result = append(result, file)
+		}
+	}
+	return result
+}
+
+// resolvedPath returns the absolute path of file with symlinks in its
+// directory resolved, falling back to the plain absolute path.
+func resolvedPath(file string) string {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return file
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Dir(abs))
+	if err != nil {
+		return abs
+	}
+	return filepath.Join(dir, filepath.Base(abs))
+}
+
+// gitIgnoredFiles returns the subset of files that .gitignore rules exclude
+// in the repository containing dir, as decided by git check-ignore. Tracked
+// files are never reported, matching git's own behavior. Outside a git
+// repository, or without git installed, nothing is ignored.
+func gitIgnoredFiles(dir string, files []string) (map[string]bool, error) {
+	ignored := make(map[string]bool)
+	if len(files) == 0 {
+		return ignored, nil
+	}
+
+	if err := exec.Command("git", "-C", dir, "rev-parse", "--is-inside-work-tree").Run(); err != nil {
+		return ignored, nil
+	}
+
+	byAbs := make(map[string]string, len(files))
+	var input bytes.Buffer
+	for _, file := range files {
+		abs := resolvedPath(file)
+		byAbs[abs] = file
+		input.WriteString(abs)
+		input.WriteByte(0)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", "-C", dir, "check-ignore", "-z", "--stdin")
+	cmd.Stdin = &input
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		// Exit status 1 means that no file is ignored.
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return ignored, nil
+		}
+		return nil, fmt.Errorf("git check-ignore failed: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	for _, path := range strings.Split(string(output), "\x00") {
+		if file, ok := byAbs[path]; ok {
+			ignored[file] = true
+		}
+	}
+	return ignored, nil
+}
+
+// gitAdd stages files, each from the repository containing it.
+func gitAdd(files []string) error {
+	byDir := make(map[string][]string)
+	var dirs []string
+	for _, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		dir := filepath.Dir(abs)
+		if _, ok := byDir[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		byDir[dir] = append(byDir[dir], filepath.Base(abs))
+	}
+
+	for _, dir := range dirs {
+		if _, err := gitOutput(dir, append([]string{"add", "--"}, byDir[dir]...)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gitCommit stages files and commits exactly those files with message, in
+// the repository containing them, leaving anything else that was staged
+// out of the commit. It returns the new commit's hash.
+func gitCommit(files []string, message string) (string, error) {
+	if err := gitAdd(files); err != nil {
+		return "", err
+	}
+
+	var paths []string
+	topLevel := ""
+	for _, file := range files {
+		path := resolvedPath(file)
+		repo, err := gitTopLevel(filepath.Dir(path))
+		if err != nil {
+			return "", err
+		}
+		if topLevel == "" {
+			topLevel = repo
+		} else if repo != topLevel {
+			return "", fmt.Errorf("the modified files are in more than one repository: %s and %s", topLevel, repo)
+		}
+		paths = append(paths, path)
+	}
+
+	if _, err := gitOutput(topLevel, append([]string{"commit", "-q", "-m", message, "--"}, paths...)...); err != nil {
+		return "", err
+	}
+	return gitOutput(topLevel, "rev-parse", "HEAD")
+}
+
+// gitTopLevel returns the root of the work tree containing dir.
+func gitTopLevel(dir string) (string, error) {
+	return gitOutput(dir, "rev-parse", "--show-toplevel")
+}
+
+// gitCurrentBranch returns the branch checked out in dir.
+func gitCurrentBranch(dir string) (string, error) {
+	return gitOutput(dir, "symbolic-ref", "--short", "HEAD")
+}
+
+// gitRemoteURL returns the URL of remote in the repository containing dir.
+func gitRemoteURL(dir, remote string) (string, error) {
+	return gitOutput(dir, "remote", "get-url", remote)
+}
+
+// gitOutput runs git in dir and returns its output without the trailing
+// newline. A failure is described by git's stderr or, for the commands that
+// report on stdout, by its output.
+func gitOutput(dir string, args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		detail := strings.TrimSpace(stderr.String())
+		if detail == "" {
+			detail = strings.TrimSpace(string(output))
+		}
+		if detail == "" {
+			return "", fmt.Errorf("git %s failed: %w", args[0], err)
+		}
+		return "", fmt.Errorf("git %s failed: %s: %w", args[0], detail, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,510 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseBlamePorcelain(t *testing.T) {
+	output := []byte(`4e1f0c5a8d6b1f4b0d0c9a6b2f3e4d5c6b7a8f90 7 7 1
+author Jane Doe
+author-mail <jane@example.com>
+author-time 1717200000
+author-tz +0000
+committer Jane Doe
+committer-time 1717200000
+summary Add removed block
+filename main.tf
+	removed {
+`)
+
+	info, err := parseBlamePorcelain(output)
+	if err != nil {
+		t.Fatalf("parseBlamePorcelain failed: %v", err)
+	}
+
+	if info.Commit != "4e1f0c5a8d6b1f4b0d0c9a6b2f3e4d5c6b7a8f90" {
+		t.Errorf("Unexpected commit %q", info.Commit)
+	}
+	if info.Author != "Jane Doe" {
+		t.Errorf("Unexpected author %q", info.Author)
+	}
+	if !info.Time.Equal(time.Unix(1717200000, 0)) {
+		t.Errorf("Unexpected time %v", info.Time)
+	}
+	if info.Uncommitted() {
+		t.Errorf("Expected committed line")
+	}
+
+	if _, err := parseBlamePorcelain([]byte("garbage\n")); err == nil {
+		t.Errorf("Expected error for malformed output, but got nil")
+	}
+}
+
+// initGitRepo creates a git repository in dir, skipping the test when git is
+// not installed.
+func initGitRepo(t *testing.T, dir string) {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	runGit(t, dir, "init", "-q")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	runGit(t, dir, "config", "user.name", "Test User")
+	runGit(t, dir, "config", "commit.gpgsign", "false")
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, output)
+	}
+}
+
+func TestGitBlameLine(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-blame-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	initGitRepo(t, tempDir)
+
+	testFile := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(testFile, []byte("removed {\n  from = aws_instance.old\n}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	runGit(t, tempDir, "add", "main.tf")
+	runGit(t, tempDir, "commit", "-q", "-m", "add removed block", "--date", "2020-01-01T00:00:00Z")
+
+	info, err := gitBlameLine(testFile, 1)
+	if err != nil {
+		t.Fatalf("gitBlameLine failed: %v", err)
+	}
+	if info.Author != "Test User" || info.Time.Year() != 2020 {
+		t.Errorf("Unexpected blame info %+v", info)
+	}
+
+	if err := os.WriteFile(testFile, []byte("removed {\n  from = aws_instance.old\n}\nremoved {\n  from = aws_instance.new\n}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	info, err = gitBlameLine(testFile, 4)
+	if err != nil {
+		t.Fatalf("gitBlameLine failed: %v", err)
+	}
+	if !info.Uncommitted() {
+		t.Errorf("Expected uncommitted line, got %+v", info)
+	}
+}
+
+func TestBlameFlagRecordsIntroducingCommit(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-blame-flag-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	initGitRepo(t, tempDir)
+
+	testFile := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(testFile, []byte("removed {\n  from = aws_instance.old\n}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	runGit(t, tempDir, "add", "main.tf")
+	runGit(t, tempDir, "commit", "-q", "-m", "add removed block")
+
+	stats := Stats{DryRun: true, Blame: true}
+	if err := processFile(testFile, &stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+
+	if len(stats.Findings) != 1 || stats.Findings[0].Blame == nil {
+		t.Fatalf("Expected a finding with blame information, but got %+v", stats.Findings)
+	}
+	if stats.Findings[0].Blame.Author != "Test User" {
+		t.Errorf("Unexpected author %q", stats.Findings[0].Blame.Author)
+	}
+}
+
+func TestOwnersFlagRecordsLatestCommit(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-owners-flag-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	initGitRepo(t, tempDir)
+
+	testFile := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(testFile, []byte("removed {\n  from = aws_instance.old\n}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	t.Setenv("GIT_COMMITTER_DATE", "2020-01-01T00:00:00Z")
+	runGit(t, tempDir, "add", "main.tf")
+	runGit(t, tempDir, "commit", "-q", "-m", "add removed block")
+
+	// A later change to the block body by someone else makes them the owner.
+	if err := os.WriteFile(testFile, []byte("removed {\n  from = aws_instance.older\n}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	runGit(t, tempDir, "add", "main.tf")
+	t.Setenv("GIT_COMMITTER_DATE", "2021-01-01T00:00:00Z")
+	runGit(t, tempDir, "-c", "user.name=Other User", "-c", "user.email=other@example.com", "commit", "-q", "-m", "rename")
+
+	stats := Stats{DryRun: true, Owners: true}
+	if err := processFile(testFile, &stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+
+	if len(stats.Findings) != 1 || stats.Findings[0].Owner == nil {
+		t.Fatalf("Expected a finding with owner information, but got %+v", stats.Findings)
+	}
+	owner := stats.Findings[0].Owner
+	if owner.Committer != "Other User" || owner.CommitterEmail != "other@example.com" {
+		t.Errorf("Expected Other User <other@example.com> as owner, but got %s <%s>", owner.Committer, owner.CommitterEmail)
+	}
+}
+
+func TestGitChangedFiles(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-git-diff-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	initGitRepo(t, tempDir)
+
+	if err := os.MkdirAll(filepath.Join(tempDir, "envs", "prod"), 0750); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	for _, name := range []string{"main.tf", filepath.Join("envs", "prod", "main.tf")} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("locals {}\n"), 0600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	runGit(t, tempDir, "add", ".")
+	runGit(t, tempDir, "commit", "-q", "-m", "initial")
+	runGit(t, tempDir, "branch", "base")
+
+	if err := os.WriteFile(filepath.Join(tempDir, "envs", "prod", "main.tf"), []byte("removed {\n  from = aws_instance.old\n}\n"), 0600); err != nil {
+		t.Fatalf("Failed to update file: %v", err)
+	}
+	runGit(t, tempDir, "commit", "-q", "-am", "change prod")
+
+	changed, err := gitChangedFiles(filepath.Join(tempDir, "envs"), "base...HEAD")
+	if err != nil {
+		t.Fatalf("gitChangedFiles failed: %v", err)
+	}
+
+	files, err := findTerraformFiles(tempDir)
+	if err != nil {
+		t.Fatalf("findTerraformFiles failed: %v", err)
+	}
+
+	filtered := filterChangedFiles(files, changed)
+	if len(filtered) != 1 || filepath.Base(filepath.Dir(filtered[0])) != "prod" {
+		t.Errorf("Expected only envs/prod/main.tf, but got %v", filtered)
+	}
+
+	if _, err := gitChangedFiles(tempDir, "no-such-ref...HEAD"); err == nil {
+		t.Errorf("Expected error for an unknown ref, but got nil")
+	}
+}
+
+func TestGitIgnoredFiles(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-gitignore-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	// Outside a repository nothing is ignored.
+	outside := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(outside, []byte(""), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	ignored, err := gitIgnoredFiles(tempDir, []string{outside})
+	if err != nil || len(ignored) != 0 {
+		t.Errorf("Expected nothing ignored outside a git repository, got %v, %v", ignored, err)
+	}
+
+	initGitRepo(t, tempDir)
+	files := map[string]string{
+		".gitignore":         "build/\nscratch.tf\ntracked.tf\n",
+		"main.tf":            "",
+		"build/generated.tf": "",
+		"scratch.tf":         "",
+		"tracked.tf":         "",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if mkdirErr := os.MkdirAll(filepath.Dir(path), 0750); mkdirErr != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, mkdirErr)
+		}
+		if writeErr := os.WriteFile(path, []byte(content), 0600); writeErr != nil {
+			t.Fatalf("Failed to write file %s: %v", name, writeErr)
+		}
+	}
+	runGit(t, tempDir, "add", "-f", "tracked.tf")
+
+	discovery, err := discoverFiles(tempDir, DiscoveryOptions{})
+	if err != nil {
+		t.Fatalf("discoverFiles failed: %v", err)
+	}
+	stats := Stats{}
+	filtered := filterGitIgnored(discovery, tempDir, &stats)
+
+	expected := []string{filepath.Join(tempDir, "main.tf"), filepath.Join(tempDir, "tracked.tf")}
+	if !slices.Equal(filtered.Files, expected) {
+		t.Errorf("Expected %v, but got %v", expected, filtered.Files)
+	}
+	if len(stats.Warnings) != 0 {
+		t.Errorf("Unexpected warnings %v", stats.Warnings)
+	}
+}
+
+func TestFilterGitStagedAndGitAdd(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-staged-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	initGitRepo(t, tempDir)
+
+	content := "removed {\n  from = aws_instance.old\n}\n\nresource \"aws_instance\" \"web\" {}\n"
+	for _, name := range []string{"staged.tf", "partial.tf", "untracked.tf"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	runGit(t, tempDir, "add", "staged.tf", "partial.tf")
+	if err := os.WriteFile(filepath.Join(tempDir, "partial.tf"), []byte(content+"# not staged\n"), 0600); err != nil {
+		t.Fatalf("Failed to update partial.tf: %v", err)
+	}
+
+	discovery, err := discoverFiles(tempDir, DiscoveryOptions{})
+	if err != nil {
+		t.Fatalf("discoverFiles failed: %v", err)
+	}
+	stats := &Stats{}
+	if discovery, err = filterGitStaged(discovery, tempDir, stats); err != nil {
+		t.Fatalf("filterGitStaged failed: %v", err)
+	}
+	if len(discovery.Files) != 1 || filepath.Base(discovery.Files[0]) != "staged.tf" {
+		t.Fatalf("Expected only staged.tf, but got %v", discovery.Files)
+	}
+	if len(stats.Warnings) != 1 || !strings.Contains(stats.Warnings[0], "partial.tf: skipped because it has unstaged changes") {
+		t.Errorf("Expected a warning about partial.tf, but got %v", stats.Warnings)
+	}
+
+	if err := processFile(discovery.Files[0], stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	if err := gitAdd(stats.Written); err != nil {
+		t.Fatalf("gitAdd failed: %v", err)
+	}
+	output, err := exec.Command("git", "-C", tempDir, "show", ":staged.tf").Output()
+	if err != nil {
+		t.Fatalf("git show failed: %v", err)
+	}
+	if strings.Contains(string(output), "removed") {
+		t.Errorf("Expected the rewritten file to be staged, but got %q", string(output))
+	}
+}
+
+func TestStagedExitStatus(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-staged-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	initGitRepo(t, tempDir)
+	testFile := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(testFile, []byte("removed {\n  from = aws_instance.old\n}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	runGit(t, tempDir, "add", "main.tf")
+
+	// Modifying a staged file aborts the commit; a clean one lets it through.
+	for _, expected := range []int{exitChanges, exitOK} {
+		if status := run([]string{"-no-config", "-log-level", "error", "-staged", tempDir}); status != expected {
+			t.Errorf("Expected exit status %d, but got %d", expected, status)
+		}
+	}
+}
+
+func TestGitCommit(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-git-commit-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	initGitRepo(t, tempDir)
+
+	testFile := filepath.Join(tempDir, "main.tf")
+	otherFile := filepath.Join(tempDir, "other.tf")
+	for _, file := range []string{testFile, otherFile} {
+		if err := os.WriteFile(file, []byte("removed {\n  from = aws_instance.old\n}\n"), 0600); err != nil {
+			t.Fatalf("Failed to write %s: %v", file, err)
+		}
+	}
+	runGit(t, tempDir, "add", ".")
+	runGit(t, tempDir, "commit", "-q", "-m", "initial")
+
+	// A change staged by someone else stays out of the commit
+	if err := os.WriteFile(otherFile, []byte("locals {}\n"), 0600); err != nil {
+		t.Fatalf("Failed to update other.tf: %v", err)
+	}
+	runGit(t, tempDir, "add", "other.tf")
+
+	stats := &Stats{}
+	if err := processFile(testFile, stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	commit, err := gitCommit(stats.Written, "chore: drop applied removed blocks")
+	if err != nil {
+		t.Fatalf("gitCommit failed: %v", err)
+	}
+	if len(commit) != 40 {
+		t.Errorf("Expected a commit hash, but got %q", commit)
+	}
+
+	output, err := exec.Command("git", "-C", tempDir, "show", "--name-only", "--format=%s", "HEAD").Output()
+	if err != nil {
+		t.Fatalf("git show failed: %v", err)
+	}
+	expected := "chore: drop applied removed blocks\n\nmain.tf\n"
+	if string(output) != expected {
+		t.Errorf("Expected %q, but got %q", expected, string(output))
+	}
+	output, err = exec.Command("git", "-C", tempDir, "diff", "--cached", "--name-only").Output()
+	if err != nil {
+		t.Fatalf("git diff failed: %v", err)
+	}
+	if string(output) != "other.tf\n" {
+		t.Errorf("Expected other.tf to stay staged, but got %q", string(output))
+	}
+}
+
+func TestGitOutputErrors(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-git-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+	initGitRepo(t, tempDir)
+	if err := os.WriteFile(filepath.Join(tempDir, "main.tf"), []byte("removed {}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	runGit(t, tempDir, "add", "main.tf")
+	runGit(t, tempDir, "commit", "-q", "-m", "initial")
+	if err := os.WriteFile(filepath.Join(tempDir, "main.tf"), []byte("\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{name: "stderr", args: []string{"remote", "get-url", "missing"}, expected: "No such remote 'missing'"},
+		{name: "stdout", args: []string{"diff", "--exit-code", "--name-only"}, expected: "git diff failed: main.tf: exit status 1"},
+		{name: "silent", args: []string{"diff", "--quiet"}, expected: "git diff failed: exit status 1"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := gitOutput(tempDir, tt.args...)
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) {
+				t.Fatalf("Expected an *exec.ExitError, but got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected the error to contain %q, but got %q", tt.expected, err.Error())
+			}
+		})
+	}
+}
+
+func TestGitFiltersFailWithRuntimeStatus(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-git-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	for _, tt := range []struct {
+		name   string
+		stats  *Stats
+		filter func(*Discovery, string, *Stats) (*Discovery, error)
+	}{
+		{name: "git-diff without git", stats: &Stats{Capabilities: &Capabilities{}}, filter: func(d *Discovery, dir string, stats *Stats) (*Discovery, error) {
+			return filterGitDiff(d, dir, "HEAD", stats)
+		}},
+		{name: "git-diff outside a repository", stats: &Stats{}, filter: func(d *Discovery, dir string, stats *Stats) (*Discovery, error) {
+			return filterGitDiff(d, dir, "HEAD", stats)
+		}},
+		{name: "staged without git", stats: &Stats{Capabilities: &Capabilities{}}, filter: filterGitStaged},
+		{name: "staged outside a repository", stats: &Stats{}, filter: filterGitStaged},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.filter(&Discovery{}, tempDir, tt.stats)
+			if err == nil {
+				t.Fatalf("Expected an error")
+			}
+			if status := errorStatus(err, exitUsage); status != exitRuntime {
+				t.Errorf("Expected exit status %d, but got %d (%v)", exitRuntime, status, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// hasGlobMeta reports whether pattern contains glob syntax.
+func hasGlobMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// matchDoublestar reports whether the slash-separated name matches pattern.
+// Segments are matched with path.Match, and a "**" segment matches zero or
+// more whole segments, so envs/**/networking matches envs/networking and
+// envs/prod/us-east-1/networking.
+func matchDoublestar(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			pattern = pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// expandGlob returns the files and directories matching pattern, in lexical
+// order. Only the part of the tree below the pattern's literal prefix is
+// walked.
+func expandGlob(pattern string) ([]string, error) {
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+	if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	segments := strings.Split(pattern, "/")
+	literal := 0
+	for literal < len(segments) && !hasGlobMeta(segments[literal]) {
+		literal++
+	}
+	base := strings.Join(segments[:literal], "/")
+	if base == "" {
+		base = "."
+		if strings.HasPrefix(pattern, "/") {
+			base = "/"
+		}
+	}
+	rest := strings.Join(segments[literal:], "/")
+
+	var matches []string
+	err := filepath.WalkDir(filepath.FromSlash(base), func(p string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, relErr := filepath.Rel(filepath.FromSlash(base), p)
+		if relErr != nil || rel == "." {
+			return relErr
+		}
+		if matchDoublestar(rest, filepath.ToSlash(rel)) {
+			matches = append(matches, p)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("error expanding %q: %w", pattern, err)
+	}
+	return matches, nil
+}
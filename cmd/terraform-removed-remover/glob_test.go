@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestMatchDoublestar(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"envs/**/networking", "envs/networking", true},
+		{"envs/**/networking", "envs/prod/us-east-1/networking", true},
+		{"envs/**/networking", "envs/prod/networking/main.tf", false},
+		{"envs/*/main.tf", "envs/prod/main.tf", true},
+		{"envs/*/main.tf", "envs/prod/eu/main.tf", false},
+		{"**/*.tf", "main.tf", true},
+		{"**", "a/b/c", true},
+		{"modules/vpc-?", "modules/vpc-a", true},
+	}
+
+	for _, tt := range tests {
+		if got := matchDoublestar(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchDoublestar(%q, %q) = %v, expected %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExpandGlob(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-glob-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	for _, dir := range []string{"envs/prod/networking", "envs/staging/eu/networking", "envs/prod/compute"} {
+		if mkdirErr := os.MkdirAll(filepath.Join(tempDir, dir), 0750); mkdirErr != nil {
+			t.Fatalf("Failed to create directory %s: %v", dir, mkdirErr)
+		}
+	}
+
+	matches, err := expandGlob(filepath.Join(tempDir, "envs/**/networking"))
+	if err != nil {
+		t.Fatalf("expandGlob failed: %v", err)
+	}
+
+	expected := []string{
+		filepath.Join(tempDir, "envs/prod/networking"),
+		filepath.Join(tempDir, "envs/staging/eu/networking"),
+	}
+	if !slices.Equal(matches, expected) {
+		t.Errorf("Expected %v, but got %v", expected, matches)
+	}
+
+	if _, err := expandGlob(filepath.Join(tempDir, "envs/[")); err == nil {
+		t.Errorf("Expected an error for a malformed pattern, but got nil")
+	}
+}
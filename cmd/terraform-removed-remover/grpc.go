@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+
+	removedremoverv1 "github.com/mkusaka/terraform-removed-remover/proto/terraformremovedremover/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// grpcChunkSize is how much content each ProcessStream response chunk holds.
+const grpcChunkSize = 1 << 20
+
+// grpcMessageOverhead is the room left in a message, beyond the largest
+// file allowed, for the filename and the rest of the message.
+const grpcMessageOverhead = 64 << 10
+
+// grpcServer is the serve subcommand's gRPC API, defined in
+// proto/terraformremovedremover/v1/remover.proto. It processes files like
+// the HTTP API it is built on.
+type grpcServer struct {
+	removedremoverv1.UnimplementedRemoverServiceServer
+	process *processServer
+}
+
+func newGRPCServer(process *processServer) *grpcServer {
+	return &grpcServer{process: process}
+}
+
+// Process cleans the file in the request, like POST /process.
+func (s *grpcServer) Process(_ context.Context, request *removedremoverv1.ProcessRequest) (*removedremoverv1.ProcessResponse, error) {
+	name, err := requestFilename(request.GetFilename())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if limit := s.process.maxBody(); int64(len(request.GetContent())) > limit {
+		return nil, status.Errorf(codes.ResourceExhausted, "the file exceeds %d bytes", limit)
+	}
+
+	result, report, err := s.processFile(name, request.GetContent())
+	if err != nil {
+		return nil, err
+	}
+	return &removedremoverv1.ProcessResponse{Content: result, Report: report}, nil
+}
+
+// ProcessStream cleans a file sent in chunks and sends the result back in
+// chunks, the last carrying the report.
+func (s *grpcServer) ProcessStream(stream grpc.BidiStreamingServer[removedremoverv1.FileChunk, removedremoverv1.ProcessChunk]) error {
+	var files int
+	var result []byte
+	var report *removedremoverv1.Report
+	err := s.receiveFiles(stream, func(name string, content []byte) error {
+		if files++; files > 1 {
+			return status.Error(codes.InvalidArgument, "ProcessStream takes a single file")
+		}
+		var err error
+		result, report, err = s.processFile(name, content)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for len(result) > grpcChunkSize {
+		if err = stream.Send(&removedremoverv1.ProcessChunk{Content: result[:grpcChunkSize]}); err != nil {
+			return err
+		}
+		result = result[grpcChunkSize:]
+	}
+	return stream.Send(&removedremoverv1.ProcessChunk{Content: result, Report: report})
+}
+
+// ListBlocks sends the removed blocks of each file as the file is
+// processed. The files are not changed.
+func (s *grpcServer) ListBlocks(stream grpc.BidiStreamingServer[removedremoverv1.FileChunk, removedremoverv1.RemovedBlock]) error {
+	return s.receiveFiles(stream, func(name string, content []byte) error {
+		_, report, err := s.processFile(name, content)
+		if err != nil {
+			return err
+		}
+		for _, block := range report.GetBlocks() {
+			if err = stream.Send(block); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Check reports whether the files sent are free of removed blocks.
+func (s *grpcServer) Check(stream grpc.ClientStreamingServer[removedremoverv1.FileChunk, removedremoverv1.CheckResponse]) error {
+	response := &removedremoverv1.CheckResponse{}
+	err := s.receiveFiles(stream, func(name string, content []byte) error {
+		_, report, err := s.processFile(name, content)
+		response.Blocks = append(response.Blocks, report.GetBlocks()...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	response.Clean = len(response.Blocks) == 0
+	return stream.SendAndClose(response)
+}
+
+// receiveFiles reads the files streamed as chunks and calls fn with each
+// one once it is complete. A chunk with a filename starts a new file; the
+// first chunk starts one even without a filename, which then defaults to
+// main.tf.
+func (s *grpcServer) receiveFiles(stream interface {
+	Recv() (*removedremoverv1.FileChunk, error)
+}, fn func(name string, content []byte) error) error {
+	limit := s.process.maxBody()
+	var name string
+	var content []byte
+	started := false
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if chunk.GetFilename() != "" || !started {
+			if started {
+				if err = fn(name, content); err != nil {
+					return err
+				}
+			}
+			if name, err = requestFilename(chunk.GetFilename()); err != nil {
+				return status.Error(codes.InvalidArgument, err.Error())
+			}
+			content = nil
+			started = true
+		}
+		if int64(len(content)+len(chunk.GetContent())) > limit {
+			return status.Errorf(codes.ResourceExhausted, "%s exceeds %d bytes", name, limit)
+		}
+		content = append(content, chunk.GetContent()...)
+	}
+
+	if !started {
+		return nil
+	}
+	return fn(name, content)
+}
+
+// processFile processes a file sent to the server and returns the result
+// and its report, or a status error: files that can't be parsed are
+// invalid arguments.
+func (s *grpcServer) processFile(name string, content []byte) ([]byte, *removedremoverv1.Report, error) {
+	result, stats, err := processContent(name, content, s.process.base)
+	if err != nil {
+		var parseErr *parseError
+		if errors.As(err, &parseErr) {
+			return nil, nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, nil, status.Error(codes.Internal, err.Error())
+	}
+	s.process.logger.Debug("Processed gRPC request", "filename", name, "removed", stats.RemovedBlocksRemoved)
+	return result, newProtoReport(newReport(s.process.redactor.redactStats(stats))), nil
+}
+
+// newProtoReport converts a report to its message in remover.proto.
+func newProtoReport(report *Report) *removedremoverv1.Report {
+	message := &removedremoverv1.Report{
+		SchemaVersion:        protoInt(report.SchemaVersion),
+		ToolVersion:          report.ToolVersion,
+		FilesProcessed:       protoInt(report.FilesProcessed),
+		FilesModified:        protoInt(report.FilesModified),
+		RemovedBlocksRemoved: protoInt(report.RemovedBlocksRemoved),
+		RemovedBlocksSkipped: protoInt(report.RemovedBlocksSkipped),
+		Warnings:             report.Warnings,
+	}
+	for _, block := range report.Blocks {
+		message.Blocks = append(message.Blocks, &removedremoverv1.RemovedBlock{
+			File:    block.File,
+			Line:    protoInt(block.Line),
+			Address: block.Address,
+			Kind:    block.Kind,
+		})
+	}
+	return message
+}
+
+// protoInt converts a count or line number to an int32 field, capping it
+// rather than wrapping around.
+func protoInt(n int) int32 {
+	if n > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return int32(n) // #nosec G115 -- capped above
+}
+
+// runGRPCServer serves service on addr until ctx is done, then waits for
+// the calls in progress.
+func runGRPCServer(ctx context.Context, addr string, service *grpcServer, logger *slog.Logger) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	maxMessage := int(service.process.maxBody()) + grpcMessageOverhead
+	server := grpc.NewServer(grpc.MaxRecvMsgSize(maxMessage))
+	removedremoverv1.RegisterRemoverServiceServer(server, service)
+	logger.Info("Serving the gRPC API", "address", listener.Addr().String())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve(listener)
+	}()
+	select {
+	case err := <-done:
+		return fmt.Errorf("gRPC server: %w", err)
+	case <-ctx.Done():
+	}
+	server.GracefulStop()
+	return nil
+}
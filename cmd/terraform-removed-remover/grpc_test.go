@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+
+	removedremoverv1 "github.com/mkusaka/terraform-removed-remover/proto/terraformremovedremover/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newGRPCTestClient serves the gRPC API with the options in base over an
+// in-memory connection and returns a client for it.
+func newGRPCTestClient(t *testing.T, base Stats) removedremoverv1.RemoverServiceClient {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	removedremoverv1.RegisterRemoverServiceServer(server, newGRPCServer(newProcessServer(base, nil, logger)))
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return removedremoverv1.NewRemoverServiceClient(conn)
+}
+
+const grpcTestFile = "removed {\n  from = aws_instance.old\n}\n\nresource \"aws_instance\" \"web\" {}\n"
+
+func TestGRPCProcess(t *testing.T) {
+	client := newGRPCTestClient(t, Stats{Pure: true})
+
+	response, err := client.Process(context.Background(), &removedremoverv1.ProcessRequest{Content: []byte(grpcTestFile)})
+	if err != nil {
+		t.Fatalf("Process failed: %v", err)
+	}
+	if expected := "\nresource \"aws_instance\" \"web\" {}\n"; string(response.GetContent()) != expected {
+		t.Errorf("Expected content %q, but got %q", expected, response.GetContent())
+	}
+	report := response.GetReport()
+	if report.GetRemovedBlocksRemoved() != 1 || len(report.GetBlocks()) != 1 || report.GetBlocks()[0].GetFile() != "main.tf" || report.GetBlocks()[0].GetKind() != AddressKindResource {
+		t.Errorf("Expected one resource block in main.tf, but got %v", report)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		request  *removedremoverv1.ProcessRequest
+		expected codes.Code
+	}{
+		{name: "directory", request: &removedremoverv1.ProcessRequest{Filename: "../main.tf"}, expected: codes.InvalidArgument},
+		{name: "invalid", request: &removedremoverv1.ProcessRequest{Content: []byte("removed {")}, expected: codes.InvalidArgument},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.Process(context.Background(), tt.request)
+			if status.Code(err) != tt.expected {
+				t.Errorf("Expected %s, but got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestGRPCProcessStream(t *testing.T) {
+	client := newGRPCTestClient(t, Stats{})
+	stream, err := client.ProcessStream(context.Background())
+	if err != nil {
+		t.Fatalf("ProcessStream failed: %v", err)
+	}
+
+	// The file arrives in chunks; only the first names it.
+	half := len(grpcTestFile) / 2
+	for _, chunk := range []*removedremoverv1.FileChunk{
+		{Filename: "net.tf", Content: []byte(grpcTestFile[:half])},
+		{Content: []byte(grpcTestFile[half:])},
+	} {
+		if err := stream.Send(chunk); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatalf("CloseSend failed: %v", err)
+	}
+
+	var content strings.Builder
+	var report *removedremoverv1.Report
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		content.Write(chunk.GetContent())
+		report = chunk.GetReport()
+	}
+	if strings.Contains(content.String(), "removed") {
+		t.Errorf("Expected the removed block to be deleted, but got %q", content.String())
+	}
+	if len(report.GetBlocks()) != 1 || report.GetBlocks()[0].GetFile() != "net.tf" {
+		t.Errorf("Expected the last chunk to report the block in net.tf, but got %v", report)
+	}
+}
+
+func TestGRPCListBlocksAndCheck(t *testing.T) {
+	client := newGRPCTestClient(t, Stats{})
+	files := []*removedremoverv1.FileChunk{
+		{Filename: "a.tf", Content: []byte(grpcTestFile)},
+		{Filename: "clean.tf", Content: []byte("resource \"aws_instance\" \"web\" {}\n")},
+		{Filename: "b.tf.json", Content: []byte(`{"removed": [{"from": "module.old"}]}`)},
+	}
+
+	list, err := client.ListBlocks(context.Background())
+	if err != nil {
+		t.Fatalf("ListBlocks failed: %v", err)
+	}
+	for _, chunk := range files {
+		if err := list.Send(chunk); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+	if err := list.CloseSend(); err != nil {
+		t.Fatalf("CloseSend failed: %v", err)
+	}
+	var blocks []string
+	for {
+		block, err := list.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		blocks = append(blocks, block.GetFile()+":"+block.GetAddress()+":"+block.GetKind())
+	}
+	if expected := "a.tf:aws_instance.old:resource b.tf.json:module.old:module"; strings.Join(blocks, " ") != expected {
+		t.Errorf("Expected blocks %q, but got %q", expected, strings.Join(blocks, " "))
+	}
+
+	for _, tt := range []struct {
+		name  string
+		files []*removedremoverv1.FileChunk
+		clean bool
+	}{
+		{name: "removed blocks", files: files, clean: false},
+		{name: "clean", files: files[1:2], clean: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			check, err := client.Check(context.Background())
+			if err != nil {
+				t.Fatalf("Check failed: %v", err)
+			}
+			for _, chunk := range tt.files {
+				if err := check.Send(chunk); err != nil {
+					t.Fatalf("Send failed: %v", err)
+				}
+			}
+			response, err := check.CloseAndRecv()
+			if err != nil {
+				t.Fatalf("CloseAndRecv failed: %v", err)
+			}
+			if response.GetClean() != tt.clean {
+				t.Errorf("Expected clean to be %v, but got %v", tt.clean, response)
+			}
+		})
+	}
+}
+
+func TestGRPCFileTooLarge(t *testing.T) {
+	client := newGRPCTestClient(t, Stats{MaxFileSize: 16})
+
+	_, err := client.Process(context.Background(), &removedremoverv1.ProcessRequest{Content: []byte(grpcTestFile)})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected ResourceExhausted, but got %v", err)
+	}
+
+	check, err := client.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	for _, chunk := range []*removedremoverv1.FileChunk{{Content: []byte(grpcTestFile[:10])}, {Content: []byte(grpcTestFile[10:])}} {
+		if err := check.Send(chunk); err != nil && !errors.Is(err, io.EOF) {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+	if _, err := check.CloseAndRecv(); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected ResourceExhausted for a streamed file, but got %v", err)
+	}
+}
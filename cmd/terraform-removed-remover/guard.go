@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// resolveRoot returns the absolute, symlink-free form of root, so that
+// containment checks compare real locations on disk.
+func resolveRoot(root string) (string, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// outsideRoots splits files into those whose real location, after resolving
+// symlinks and .. segments, lies inside one of the resolved roots and those
+// that escape them, for example through a symlinked module directory.
+func outsideRoots(files, resolvedRoots []string) (inside, outside []string, err error) {
+	for _, file := range files {
+		resolved, err := resolveRoot(file)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if isWithinAny(resolvedRoots, resolved) {
+			inside = append(inside, file)
+		} else {
+			outside = append(outside, file)
+		}
+	}
+	return inside, outside, nil
+}
+
+// writeConfigFile writes content to filePath after checking, once more, that
+// the file still resolves inside stats.ResolvedRoots. Discovery already
+// skips files outside the roots; repeating the check at write time means a
+// symlink swapped in while the run is in progress, say by a hostile branch
+// on a shared CI runner, can't redirect the write. With stats.WriteFile,
+// content goes there instead and nothing is checked.
+func writeConfigFile(filePath string, content []byte, stats *Stats) error {
+	if stats.WriteFile != nil {
+		if err := stats.WriteFile(filePath, content); err != nil {
+			return fmt.Errorf("error writing file %s: %w", filePath, err)
+		}
+		stats.Written = append(stats.Written, filePath)
+		return nil
+	}
+
+	_, statErr := os.Lstat(filePath)
+	created := errors.Is(statErr, fs.ErrNotExist)
+	if len(stats.ResolvedRoots) > 0 && !stats.AllowOutsideRoot {
+		// A file about to be created, like consolidate's removed.tf, is
+		// checked by the directory it goes in.
+		resolved, err := resolveRoot(filePath)
+		if created {
+			if resolved, err = resolveRoot(filepath.Dir(filePath)); err == nil {
+				resolved = filepath.Join(resolved, filepath.Base(filePath))
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("error writing file %s: %w", filePath, err)
+		}
+		if !isWithinAny(stats.ResolvedRoots, resolved) {
+			return fmt.Errorf("refusing to write %s: it resolves to %s, outside the root (use -allow-outside-root to allow it)", filePath, resolved)
+		}
+	}
+
+	if stats.Backup != "" && !created {
+		if err := backupFile(stats.Backup, filePath, stats); err != nil {
+			return err
+		}
+	}
+	if err := writeFileAtomic(filePath, content); err != nil {
+		return fmt.Errorf("error writing file %s: %w", filePath, err)
+	}
+	stats.Written = append(stats.Written, filePath)
+	return nil
+}
+
+// isWithin reports whether path is root or below it. Both must be clean,
+// absolute paths.
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// isWithinAny reports whether path is within one of roots.
+func isWithinAny(roots []string, path string) bool {
+	for _, root := range roots {
+		if isWithin(root, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// confirm asks a yes/no question on w and reads the answer from r. Anything
+// other than y or yes, including end of input, is a no.
+func confirm(r io.Reader, w io.Writer, prompt string) bool {
+	fmt.Fprintf(w, "%s [y/N] ", prompt)
+	answer, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestOutsideRoots(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-guard-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	root := filepath.Join(tempDir, "repo")
+	sibling := filepath.Join(tempDir, "sibling")
+	for _, dir := range []string{root, sibling} {
+		if mkdirErr := os.MkdirAll(dir, 0750); mkdirErr != nil {
+			t.Fatalf("Failed to create directory %s: %v", dir, mkdirErr)
+		}
+	}
+	for _, file := range []string{filepath.Join(root, "main.tf"), filepath.Join(sibling, "main.tf")} {
+		if writeErr := os.WriteFile(file, []byte(""), 0600); writeErr != nil {
+			t.Fatalf("Failed to write file %s: %v", file, writeErr)
+		}
+	}
+	if err := os.Symlink(filepath.Join(sibling, "main.tf"), filepath.Join(root, "linked.tf")); err != nil {
+		t.Skipf("Symlinks are not supported: %v", err)
+	}
+
+	resolved, err := resolveRoot(root)
+	if err != nil {
+		t.Fatalf("resolveRoot failed: %v", err)
+	}
+
+	files := []string{
+		filepath.Join(root, "main.tf"),
+		filepath.Join(root, "linked.tf"),
+		filepath.Join(root, "..", "sibling", "main.tf"),
+	}
+	inside, outside, err := outsideRoots(files, []string{resolved})
+	if err != nil {
+		t.Fatalf("outsideRoots failed: %v", err)
+	}
+
+	if !slices.Equal(inside, files[:1]) {
+		t.Errorf("Expected only %s inside the root, but got %v", files[0], inside)
+	}
+	if !slices.Equal(outside, files[1:]) {
+		t.Errorf("Expected the symlink and .. path outside the root, but got %v", outside)
+	}
+}
+
+func TestProcessFileRefusesWritesOutsideRoot(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-guard-write-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	root := filepath.Join(tempDir, "repo")
+	if err := os.MkdirAll(root, 0750); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	content := "removed {\n  from = aws_instance.old\n}\n"
+	target := filepath.Join(tempDir, "victim.tf")
+	if err := os.WriteFile(target, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	// The symlink appears after discovery, as on a runner where another
+	// job rewrites the checkout mid-run.
+	linked := filepath.Join(root, "main.tf")
+	if err := os.Symlink(target, linked); err != nil {
+		t.Skipf("Symlinks are not supported: %v", err)
+	}
+
+	resolved, err := resolveRoot(root)
+	if err != nil {
+		t.Fatalf("resolveRoot failed: %v", err)
+	}
+
+	stats := Stats{ResolvedRoots: []string{resolved}}
+	if err := processFile(linked, &stats); err == nil || !strings.Contains(err.Error(), "outside the root") {
+		t.Errorf("Expected the write to be refused, but got %v", err)
+	}
+	unchanged, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(unchanged) != content {
+		t.Errorf("Expected the file outside the root to be untouched, but got:\n%s", unchanged)
+	}
+
+	stats = Stats{ResolvedRoots: []string{resolved}, AllowOutsideRoot: true}
+	if err := processFile(linked, &stats); err != nil {
+		t.Errorf("Expected -allow-outside-root to permit the write, but got %v", err)
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"y\n", true},
+		{"YES\n", true},
+		{"n\n", false},
+		{"\n", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := confirm(strings.NewReader(tt.input), io.Discard, "Continue?"); got != tt.want {
+			t.Errorf("confirm(%q) = %v, expected %v", tt.input, got, tt.want)
+		}
+	}
+}
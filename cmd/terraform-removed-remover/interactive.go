@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// interactiveContext is the number of lines shown around each block.
+const interactiveContext = 3
+
+// blockPrompter asks, for -interactive, whether to delete each block the
+// filters selected, like git add -p: y deletes it, n keeps it, a deletes it
+// and every later block without asking, and q keeps it and every later
+// block and ends the run.
+type blockPrompter struct {
+	in    *bufio.Reader
+	out   io.Writer
+	color bool
+	all   bool
+	quit  bool
+}
+
+func newBlockPrompter(in io.Reader, out io.Writer, color bool) *blockPrompter {
+	return &blockPrompter{in: bufio.NewReader(in), out: out, color: color}
+}
+
+// confirm shows block, from filePath, with its surrounding lines and reports
+// whether to delete it. content is the source the block offsets refer to.
+// The question is repeated until it gets an answer it understands; the end
+// of input answers q.
+func (p *blockPrompter) confirm(filePath string, block removedBlock, content []byte) bool {
+	if p.all {
+		return true
+	}
+	if p.quit {
+		return false
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "\n%s\n", colorize(p.color, ansiBold, fmt.Sprintf("%s:%d: removed block for %s", filePath, block.Line, block.Address)))
+	writeBlockContext(&out, block, content, p.color)
+	_, _ = out.WriteTo(p.out)
+
+	for {
+		fmt.Fprint(p.out, "Delete this block [y,n,a,q,?]? ")
+		answer, err := p.in.ReadString('\n')
+		if err != nil && answer == "" {
+			fmt.Fprintln(p.out)
+			p.quit = true
+			return false
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		case "a":
+			p.all = true
+			return true
+		case "q":
+			p.quit = true
+			return false
+		default:
+			fmt.Fprintln(p.out, "y - delete this block\nn - keep this block\na - delete this and all later blocks\nq - keep this and all later blocks and stop")
+		}
+	}
+}
+
+// writeBlockContext writes the lines of block to out prefixed with "-", as
+// in a diff, between up to interactiveContext unchanged lines on each side.
+func writeBlockContext(out *bytes.Buffer, block removedBlock, content []byte, color bool) {
+	start := lineStartOf(content, block.start)
+	before := start
+	for i := 0; i < interactiveContext && before > 0; i++ {
+		before = lineStartOf(content, before-1)
+	}
+	end := lineEndOf(content, block.end)
+	after := end
+	for i := 0; i < interactiveContext && after < len(content); i++ {
+		after = lineEndOf(content, after+1)
+	}
+
+	for _, line := range splitLines(content[before:start]) {
+		writeDiffLine(out, ' ', line, color, "")
+	}
+	for _, line := range splitLines(content[start:min(end+1, len(content))]) {
+		writeDiffLine(out, '-', line, color, ansiRed)
+	}
+	if end+1 < len(content) {
+		for _, line := range splitLines(content[end+1 : min(after+1, len(content))]) {
+			writeDiffLine(out, ' ', line, color, "")
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProcessFileInteractive(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-interactive-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := `removed {
+  from = aws_instance.a
+}
+
+removed {
+  from = aws_instance.b
+}
+
+removed {
+  from = aws_instance.c
+}
+`
+	for _, tt := range []struct {
+		answers  string
+		kept     []string
+		quit     bool
+		prompted int
+	}{
+		{answers: "y\nn\ny\n", kept: []string{"aws_instance.b"}, prompted: 3},
+		{answers: "maybe\nn\na\n", kept: []string{"aws_instance.a"}, prompted: 2},
+		{answers: "y\nq\n", kept: []string{"aws_instance.b", "aws_instance.c"}, quit: true, prompted: 2},
+		{answers: "", kept: []string{"aws_instance.a", "aws_instance.b", "aws_instance.c"}, quit: true, prompted: 1},
+	} {
+		testFile := filepath.Join(tempDir, "main.tf")
+		if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+		var out bytes.Buffer
+		prompter := newBlockPrompter(strings.NewReader(tt.answers), &out, false)
+		if err := processFile(testFile, &Stats{Prompter: prompter}); err != nil {
+			t.Fatalf("processFile failed: %v", err)
+		}
+
+		data, err := os.ReadFile(testFile)
+		if err != nil {
+			t.Fatalf("Failed to read test file: %v", err)
+		}
+		for _, address := range []string{"aws_instance.a", "aws_instance.b", "aws_instance.c"} {
+			expected := false
+			for _, kept := range tt.kept {
+				expected = expected || kept == address
+			}
+			if strings.Contains(string(data), address) != expected {
+				t.Errorf("%q: expected %s kept = %v, but got %q", tt.answers, address, expected, string(data))
+			}
+		}
+		if prompter.quit != tt.quit {
+			t.Errorf("%q: expected quit = %v", tt.answers, tt.quit)
+		}
+		if prompted := strings.Count(out.String(), ": removed block for "); prompted != tt.prompted {
+			t.Errorf("%q: expected %d blocks shown, but got %d: %q", tt.answers, tt.prompted, prompted, out.String())
+		}
+	}
+}
+
+func TestWriteBlockContext(t *testing.T) {
+	content := []byte("a\nb\nc\nd\nremoved {\n  from = x.y\n}\ne\nf")
+	start := bytes.Index(content, []byte("removed"))
+	block := removedBlock{start: start, end: bytes.Index(content, []byte("}")) + 1}
+
+	var out bytes.Buffer
+	writeBlockContext(&out, block, content, false)
+	expected := " b\n c\n d\n-removed {\n-  from = x.y\n-}\n e\n f\n\\ No newline at end of file\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, but got %q", expected, out.String())
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// InventoryVersion is the major version of the -inventory format. Like
+// ReportSchemaVersion, it only changes for breaking changes.
+const InventoryVersion = 1
+
+// Inventory is the -inventory document: what a run scanned, as coverage
+// evidence for compliance tooling. Every list is sorted.
+type Inventory struct {
+	SchemaVersion int               `json:"schema_version"`
+	ToolVersion   string            `json:"tool_version"`
+	Files         []string          `json:"files"`
+	Providers     []string          `json:"providers"`
+	Modules       []InventoryModule `json:"modules"`
+	// BlockTypes counts the top-level blocks of each type, such as resource
+	// or removed.
+	BlockTypes map[string]int `json:"block_types"`
+
+	providers map[string]bool
+}
+
+// InventoryModule is a module call seen during the scan.
+type InventoryModule struct {
+	File   string `json:"file"`
+	Name   string `json:"name"`
+	Source string `json:"source,omitempty"`
+}
+
+func newInventory() *Inventory {
+	return &Inventory{
+		SchemaVersion: InventoryVersion,
+		ToolVersion:   Version,
+		Files:         []string{},
+		Providers:     []string{},
+		Modules:       []InventoryModule{},
+		BlockTypes:    map[string]int{},
+		providers:     map[string]bool{},
+	}
+}
+
+// addProvider records a provider local name, or the provider implied by a
+// resource type (aws for aws_instance).
+func (inv *Inventory) addProvider(name string) {
+	provider, _, _ := strings.Cut(name, "_")
+	if provider != "" {
+		inv.providers[provider] = true
+	}
+}
+
+// addHCL records filePath and the top-level blocks of body. Providers come
+// from provider blocks, required_providers, and resource and data types.
+func (inv *Inventory) addHCL(filePath string, body *hclsyntax.Body) {
+	inv.Files = append(inv.Files, filePath)
+	for _, block := range body.Blocks {
+		inv.BlockTypes[block.Type]++
+		switch block.Type {
+		case "resource", "data":
+			if len(block.Labels) > 0 {
+				inv.addProvider(block.Labels[0])
+			}
+		case "provider":
+			if len(block.Labels) > 0 {
+				inv.providers[block.Labels[0]] = true
+			}
+		case "module":
+			if len(block.Labels) > 0 {
+				module := InventoryModule{File: filePath, Name: block.Labels[0]}
+				if attribute, ok := block.Body.Attributes["source"]; ok {
+					if value, diags := attribute.Expr.Value(nil); !diags.HasErrors() && value.Type() == cty.String && value.IsKnown() && !value.IsNull() {
+						module.Source = value.AsString()
+					}
+				}
+				inv.Modules = append(inv.Modules, module)
+			}
+		case "terraform":
+			for _, nested := range block.Body.Blocks {
+				if nested.Type == "required_providers" {
+					for name := range nested.Body.Attributes {
+						inv.providers[name] = true
+					}
+				}
+			}
+		}
+	}
+}
+
+// jsonBlockLabels is the number of labels of each block type in the JSON
+// syntax, which are nested object keys. Unlisted types have none.
+var jsonBlockLabels = map[string]int{
+	"resource": 2,
+	"data":     2,
+	"module":   1,
+	"provider": 1,
+	"variable": 1,
+	"output":   1,
+	"check":    1,
+}
+
+// addJSON records filePath and the top-level blocks of a JSON configuration.
+func (inv *Inventory) addJSON(filePath string, content []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(content, &members); err != nil {
+		return fmt.Errorf("error reading %s for the inventory: %w", filePath, err)
+	}
+
+	inv.Files = append(inv.Files, filePath)
+	for blockType, value := range members {
+		if blockType == "//" {
+			continue
+		}
+		jsonBlocks(value, jsonBlockLabels[blockType], nil, func(labels []string, body json.RawMessage) {
+			inv.BlockTypes[blockType]++
+			switch blockType {
+			case "resource", "data":
+				inv.addProvider(labels[0])
+			case "provider":
+				inv.providers[labels[0]] = true
+			case "module":
+				module := InventoryModule{File: filePath, Name: labels[0]}
+				var attributes struct {
+					Source string `json:"source"`
+				}
+				if json.Unmarshal(body, &attributes) == nil {
+					module.Source = attributes.Source
+				}
+				inv.Modules = append(inv.Modules, module)
+			case "terraform":
+				var attributes struct {
+					RequiredProviders map[string]json.RawMessage `json:"required_providers"`
+				}
+				if json.Unmarshal(body, &attributes) == nil {
+					for name := range attributes.RequiredProviders {
+						inv.providers[name] = true
+					}
+				}
+			}
+		})
+	}
+	return nil
+}
+
+// jsonBlocks calls fn for every block body in value, a JSON block of the
+// given number of labels. Any level may be an array of objects to merge.
+func jsonBlocks(value json.RawMessage, labels int, seen []string, fn func([]string, json.RawMessage)) {
+	var elements []json.RawMessage
+	if json.Unmarshal(value, &elements) == nil {
+		for _, element := range elements {
+			jsonBlocks(element, labels, seen, fn)
+		}
+		return
+	}
+	if labels == 0 {
+		fn(seen, value)
+		return
+	}
+
+	var object map[string]json.RawMessage
+	if json.Unmarshal(value, &object) != nil {
+		return
+	}
+	for label, nested := range object {
+		jsonBlocks(nested, labels-1, append(append([]string{}, seen...), label), fn)
+	}
+}
+
+// finish sorts the inventory for output.
+func (inv *Inventory) finish() {
+	inv.Providers = inv.Providers[:0]
+	for provider := range inv.providers {
+		inv.Providers = append(inv.Providers, provider)
+	}
+	sort.Strings(inv.Providers)
+	sort.Strings(inv.Files)
+	sort.Slice(inv.Modules, func(i, j int) bool {
+		if inv.Modules[i].File != inv.Modules[j].File {
+			return inv.Modules[i].File < inv.Modules[j].File
+		}
+		return inv.Modules[i].Name < inv.Modules[j].Name
+	})
+}
+
+// writeInventory writes inv as indented JSON to path, replacing it atomically.
+func writeInventory(path string, inv *Inventory) error {
+	inv.finish()
+	data, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing inventory: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestInventory(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-inventory-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	files := map[string]string{
+		"main.tf": `terraform {
+  required_providers {
+    random = { source = "hashicorp/random" }
+  }
+}
+
+provider "aws" {}
+
+resource "aws_instance" "web" {}
+
+data "google_project" "current" {}
+
+module "vpc" {
+  source = "./modules/vpc"
+}
+
+removed {
+  from = aws_instance.old
+}
+`,
+		"clean.tf": "locals {}\n",
+		"extra.tf.json": `{
+  "resource": {"azurerm_resource_group": {"main": {}, "backup": {}}},
+  "module": {"dns": [{"source": "git::https://example.com/dns.git"}]}
+}
+`,
+	}
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		paths = append(paths, path)
+	}
+
+	stats := Stats{DryRun: true, Inventory: newInventory()}
+	for _, path := range paths {
+		if err := processFile(path, &stats); err != nil {
+			t.Fatalf("processFile failed: %v", err)
+		}
+	}
+
+	inventoryPath := filepath.Join(tempDir, "inventory.json")
+	if err := writeInventory(inventoryPath, stats.Inventory); err != nil {
+		t.Fatalf("writeInventory failed: %v", err)
+	}
+	data, err := os.ReadFile(inventoryPath)
+	if err != nil {
+		t.Fatalf("Failed to read inventory: %v", err)
+	}
+	var inventory Inventory
+	if err := json.Unmarshal(data, &inventory); err != nil {
+		t.Fatalf("Inventory is not valid JSON: %v\n%s", err, data)
+	}
+
+	expectedFiles := []string{filepath.Join(tempDir, "clean.tf"), filepath.Join(tempDir, "extra.tf.json"), filepath.Join(tempDir, "main.tf")}
+	if !reflect.DeepEqual(inventory.Files, expectedFiles) {
+		t.Errorf("Expected files %v, but got %v", expectedFiles, inventory.Files)
+	}
+	expectedProviders := []string{"aws", "azurerm", "google", "random"}
+	if !reflect.DeepEqual(inventory.Providers, expectedProviders) {
+		t.Errorf("Expected providers %v, but got %v", expectedProviders, inventory.Providers)
+	}
+	expectedModules := []InventoryModule{
+		{File: filepath.Join(tempDir, "extra.tf.json"), Name: "dns", Source: "git::https://example.com/dns.git"},
+		{File: filepath.Join(tempDir, "main.tf"), Name: "vpc", Source: "./modules/vpc"},
+	}
+	if !reflect.DeepEqual(inventory.Modules, expectedModules) {
+		t.Errorf("Expected modules %v, but got %v", expectedModules, inventory.Modules)
+	}
+	expectedTypes := map[string]int{"terraform": 1, "provider": 1, "resource": 3, "data": 1, "module": 2, "removed": 1, "locals": 1}
+	if !reflect.DeepEqual(inventory.BlockTypes, expectedTypes) {
+		t.Errorf("Expected block types %v, but got %v", expectedTypes, inventory.BlockTypes)
+	}
+}
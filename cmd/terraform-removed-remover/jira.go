@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// jiraLabel marks the issues managed by -jira-project so they can be found
+// and updated on later runs.
+const jiraLabel = "terraform-removed-blocks"
+
+// jiraClient opens and updates Jira issues through the REST API. It is
+// configured from JIRA_URL and JIRA_TOKEN, plus JIRA_USER for basic
+// authentication with an API token (Jira Cloud), and JIRA_ISSUE_TYPE to
+// override the default Task issue type.
+type jiraClient struct {
+	baseURL   string
+	user      string
+	token     string
+	issueType string
+	http      *forgeClient
+}
+
+// jiraResult records what happened to a module's issue.
+type jiraResult struct {
+	Module  string
+	Key     string
+	Created bool
+	// Closed is set when the module no longer has removed blocks and its
+	// issue was transitioned to done.
+	Closed bool
+}
+
+func newJiraClientFromEnv() (*jiraClient, error) {
+	baseURL := strings.TrimSuffix(os.Getenv("JIRA_URL"), "/")
+	token := os.Getenv("JIRA_TOKEN")
+	if baseURL == "" || token == "" {
+		return nil, fmt.Errorf("-jira-project requires the JIRA_URL and JIRA_TOKEN environment variables")
+	}
+
+	issueType := os.Getenv("JIRA_ISSUE_TYPE")
+	if issueType == "" {
+		issueType = "Task"
+	}
+
+	return &jiraClient{
+		baseURL:   baseURL,
+		user:      os.Getenv("JIRA_USER"),
+		token:     token,
+		issueType: issueType,
+		http:      newForgeClient(),
+	}, nil
+}
+
+// syncModuleIssues opens or updates one issue per module directory that
+// still has removed blocks, listing them in the description. Open issues
+// for the scanned modules that no longer have any are transitioned to done;
+// those for modules outside the scan are left alone.
+func (c *jiraClient) syncModuleIssues(project string, findings []Finding, scanned []string) ([]jiraResult, error) {
+	byModule := make(map[string][]Finding)
+	for _, finding := range findings {
+		module := jiraModule(finding.File)
+		byModule[module] = append(byModule[module], finding)
+	}
+
+	issues, err := c.findIssues(project)
+	if err != nil {
+		return nil, err
+	}
+
+	modules := make([]string, 0, len(byModule))
+	for module := range byModule {
+		modules = append(modules, module)
+	}
+	for _, module := range scanned {
+		if _, ok := issues[module]; ok && !slices.Contains(modules, module) {
+			modules = append(modules, module)
+		}
+	}
+	slices.Sort(modules)
+
+	var results []jiraResult
+	for _, module := range modules {
+		key := issues[module]
+		result := jiraResult{Module: module, Key: key}
+		switch {
+		case byModule[module] == nil:
+			err = c.closeIssue(key)
+			result.Closed = true
+		case key == "":
+			result.Key, err = c.createIssue(project, jiraSummary(module), jiraDescription(module, byModule[module]))
+			result.Created = true
+		default:
+			err = c.updateIssue(key, jiraDescription(module, byModule[module]))
+		}
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// jiraModule returns the module directory of file, as named in issues.
+func jiraModule(file string) string {
+	return filepath.ToSlash(filepath.Dir(file))
+}
+
+const jiraSummaryPrefix = "Clean up lingering removed blocks in "
+
+func jiraSummary(module string) string {
+	return jiraSummaryPrefix + module
+}
+
+func jiraDescription(module string, findings []Finding) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "terraform-removed-remover found %d removed blocks in %s that can be cleaned up:\n\n", len(findings), module)
+	for _, finding := range findings {
+		fmt.Fprintf(&b, "* %s\n", formatFinding(finding))
+	}
+	fmt.Fprintf(&b, "\nThis issue is updated automatically on every scan.")
+	return b.String()
+}
+
+// findIssues returns the keys of the open issues carrying jiraLabel in
+// project, by module. Issues whose summary wasn't written by the tool are
+// skipped.
+func (c *jiraClient) findIssues(project string) (map[string]string, error) {
+	jql := fmt.Sprintf("project = %s AND labels = %s AND statusCategory != Done ORDER BY key",
+		jqlQuote(project), jqlQuote(jiraLabel))
+
+	issues := make(map[string]string)
+	for startAt := 0; ; {
+		var result struct {
+			Total  int `json:"total"`
+			Issues []struct {
+				Key    string `json:"key"`
+				Fields struct {
+					Summary string `json:"summary"`
+				} `json:"fields"`
+			} `json:"issues"`
+		}
+		query := url.Values{"jql": {jql}, "fields": {"summary"}, "startAt": {strconv.Itoa(startAt)}}
+		if err := c.do(http.MethodGet, "/rest/api/2/search?"+query.Encode(), nil, &result); err != nil {
+			return nil, err
+		}
+
+		for _, issue := range result.Issues {
+			module, ok := strings.CutPrefix(issue.Fields.Summary, jiraSummaryPrefix)
+			if _, seen := issues[module]; ok && !seen {
+				issues[module] = issue.Key
+			}
+		}
+		startAt += len(result.Issues)
+		if len(result.Issues) == 0 || startAt >= result.Total {
+			return issues, nil
+		}
+	}
+}
+
+func (c *jiraClient) createIssue(project, summary, description string) (string, error) {
+	request := map[string]any{
+		"fields": map[string]any{
+			"project":     map[string]string{"key": project},
+			"summary":     summary,
+			"description": description,
+			"issuetype":   map[string]string{"name": c.issueType},
+			"labels":      []string{jiraLabel},
+		},
+	}
+
+	var result struct {
+		Key string `json:"key"`
+	}
+	if err := c.do(http.MethodPost, "/rest/api/2/issue", request, &result); err != nil {
+		return "", err
+	}
+	return result.Key, nil
+}
+
+func (c *jiraClient) updateIssue(key, description string) error {
+	request := map[string]any{
+		"fields": map[string]any{"description": description},
+	}
+	return c.do(http.MethodPut, "/rest/api/2/issue/"+url.PathEscape(key), request, nil)
+}
+
+// closeIssue moves the issue to done through the first transition that
+// leads to a status in the Done category, whatever the workflow calls it.
+func (c *jiraClient) closeIssue(key string) error {
+	path := "/rest/api/2/issue/" + url.PathEscape(key) + "/transitions"
+	var result struct {
+		Transitions []struct {
+			ID string `json:"id"`
+			To struct {
+				StatusCategory struct {
+					Key string `json:"key"`
+				} `json:"statusCategory"`
+			} `json:"to"`
+		} `json:"transitions"`
+	}
+	if err := c.do(http.MethodGet, path, nil, &result); err != nil {
+		return err
+	}
+
+	for _, transition := range result.Transitions {
+		if transition.To.StatusCategory.Key == "done" {
+			request := map[string]any{"transition": map[string]string{"id": transition.ID}}
+			return c.do(http.MethodPost, path, request, nil)
+		}
+	}
+	return fmt.Errorf("jira issue %s has no transition to a done status", key)
+}
+
+// do sends a JSON request and decodes the JSON response into result, if
+// non-nil.
+func (c *jiraClient) do(method, path string, body, result any) error {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.user != "" {
+		req.SetBasicAuth(c.user, c.token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("jira %s %s: %w", method, path, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("jira %s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(message)))
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("jira %s %s: invalid response: %w", method, path, err)
+	}
+	return nil
+}
+
+// jqlQuote returns s as a quoted JQL string literal.
+func jqlQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
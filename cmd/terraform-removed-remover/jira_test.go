@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJiraSyncModuleIssues(t *testing.T) {
+	var created, updated, closed []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Expected bearer authentication, got %q", r.Header.Get("Authorization"))
+		}
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/rest/api/2/search":
+			jql := r.URL.Query().Get("jql")
+			if !strings.Contains(jql, `project = "INFRA" AND labels = "terraform-removed-blocks"`) {
+				t.Errorf("Unexpected JQL %q", jql)
+			}
+			// envs/prod already has an issue, envs/dev is clean now, and
+			// envs/old wasn't scanned. The results come in two pages.
+			if r.URL.Query().Get("startAt") == "0" {
+				_, _ = w.Write([]byte(`{"total": 4, "issues": [
+					{"key": "INFRA-1", "fields": {"summary": "Clean up lingering removed blocks in envs/prod"}},
+					{"key": "INFRA-3", "fields": {"summary": "Clean up lingering removed blocks in envs/dev"}}
+				]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"total": 4, "issues": [
+				{"key": "INFRA-4", "fields": {"summary": "Clean up lingering removed blocks in envs/old"}},
+				{"key": "INFRA-5", "fields": {"summary": "Some other issue"}}
+			]}`))
+
+		case r.Method == http.MethodPost && r.URL.Path == "/rest/api/2/issue":
+			var request struct {
+				Fields struct {
+					Summary     string   `json:"summary"`
+					Description string   `json:"description"`
+					Labels      []string `json:"labels"`
+				} `json:"fields"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				t.Errorf("Invalid create request: %v", err)
+			}
+			if !strings.Contains(request.Fields.Description, "modules/vpc/main.tf:3: removed block for aws_vpc.old") {
+				t.Errorf("Expected the description to list the block, got %q", request.Fields.Description)
+			}
+			created = append(created, request.Fields.Summary)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"key": "INFRA-2"}`))
+
+		case r.Method == http.MethodPut && r.URL.Path == "/rest/api/2/issue/INFRA-1":
+			updated = append(updated, "INFRA-1")
+			w.WriteHeader(http.StatusNoContent)
+
+		case r.Method == http.MethodGet && r.URL.Path == "/rest/api/2/issue/INFRA-3/transitions":
+			_, _ = w.Write([]byte(`{"transitions": [
+				{"id": "11", "to": {"statusCategory": {"key": "indeterminate"}}},
+				{"id": "31", "to": {"statusCategory": {"key": "done"}}}
+			]}`))
+
+		case r.Method == http.MethodPost && r.URL.Path == "/rest/api/2/issue/INFRA-3/transitions":
+			var request struct {
+				Transition struct {
+					ID string `json:"id"`
+				} `json:"transition"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				t.Errorf("Invalid transition request: %v", err)
+			}
+			closed = append(closed, "INFRA-3:"+request.Transition.ID)
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("JIRA_URL", server.URL+"/")
+	t.Setenv("JIRA_TOKEN", "secret")
+	client, err := newJiraClientFromEnv()
+	if err != nil {
+		t.Fatalf("newJiraClientFromEnv failed: %v", err)
+	}
+
+	results, err := client.syncModuleIssues("INFRA", []Finding{
+		{File: "modules/vpc/main.tf", Address: "aws_vpc.old", Line: 3},
+		{File: "envs/prod/main.tf", Address: "aws_instance.old", Line: 1},
+	}, []string{"envs/dev", "envs/prod", "envs/staging", "modules/vpc"})
+	if err != nil {
+		t.Fatalf("syncModuleIssues failed: %v", err)
+	}
+
+	expected := []jiraResult{
+		{Module: "envs/dev", Key: "INFRA-3", Closed: true},
+		{Module: "envs/prod", Key: "INFRA-1"},
+		{Module: "modules/vpc", Key: "INFRA-2", Created: true},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("Expected %+v, but got %+v", expected, results)
+	}
+	if len(created) != 1 || created[0] != "Clean up lingering removed blocks in modules/vpc" {
+		t.Errorf("Unexpected created issues %v", created)
+	}
+	if len(updated) != 1 {
+		t.Errorf("Unexpected updated issues %v", updated)
+	}
+	if len(closed) != 1 || closed[0] != "INFRA-3:31" {
+		t.Errorf("Expected INFRA-3 to be closed through transition 31, but got %v", closed)
+	}
+}
+
+func TestJiraCloseIssueWithoutDoneTransition(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"transitions": [{"id": "11", "to": {"statusCategory": {"key": "indeterminate"}}}]}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("JIRA_URL", server.URL)
+	t.Setenv("JIRA_TOKEN", "secret")
+	client, err := newJiraClientFromEnv()
+	if err != nil {
+		t.Fatalf("newJiraClientFromEnv failed: %v", err)
+	}
+	if err := client.closeIssue("INFRA-3"); err == nil || !strings.Contains(err.Error(), "no transition to a done status") {
+		t.Errorf("Expected an error about the missing transition, but got %v", err)
+	}
+}
+
+func TestNewJiraClientFromEnvRequiresConfiguration(t *testing.T) {
+	t.Setenv("JIRA_URL", "")
+	t.Setenv("JIRA_TOKEN", "")
+	if _, err := newJiraClientFromEnv(); err == nil {
+		t.Errorf("Expected an error without JIRA_URL and JIRA_TOKEN, but got nil")
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewLoggerLevels(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := newLogger(&out, "warn", "text", true, false)
+	if err != nil {
+		t.Fatalf("newLogger failed: %v", err)
+	}
+	logger.Info("Found Terraform files", "count", 3)
+	logger.Warn("Could not scan ignored file", "file", "a.tf")
+	if strings.Contains(out.String(), "Found") || !strings.Contains(out.String(), `level=WARN msg="Could not scan ignored file" file=a.tf`) {
+		t.Errorf("Expected only the warning, but got %q", out.String())
+	}
+
+	out.Reset()
+	logger, err = newLogger(&out, "", "text", true, false)
+	if err != nil {
+		t.Fatalf("newLogger failed: %v", err)
+	}
+	logger.Debug("Processing", "file", "a.tf")
+	if !strings.Contains(out.String(), "level=DEBUG") {
+		t.Errorf("Expected -verbose to log debug messages, but got %q", out.String())
+	}
+
+	if _, err := newLogger(&out, "loud", "text", false, false); err == nil {
+		t.Errorf("Expected error for an unknown level, but got nil")
+	}
+	if _, err := newLogger(&out, "", "logfmt", false, false); err == nil {
+		t.Errorf("Expected error for an unknown format, but got nil")
+	}
+}
+
+func TestNewLoggerJSON(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := newLogger(&out, "", "json", false, true)
+	if err != nil {
+		t.Fatalf("newLogger failed: %v", err)
+	}
+	logger.Info("Found Terraform files", "count", 3)
+
+	var record map[string]any
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a JSON record, but got %q: %v", out.String(), err)
+	}
+	if record["msg"] != "Found Terraform files" || record["count"] != float64(3) {
+		t.Errorf("Unexpected record: %v", record)
+	}
+	if _, ok := record["time"]; ok {
+		t.Errorf("Expected no timestamp with -pure, but got %v", record["time"])
+	}
+}
+
+func TestOpenLogFileAppends(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-log-file-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	logPath := filepath.Join(tempDir, "cleanup.log")
+	for _, message := range []string{"first run", "second run"} {
+		logFile, err := openLogFile(logPath)
+		if err != nil {
+			t.Fatalf("openLogFile failed: %v", err)
+		}
+		logger, err := newLogger(logFile, "", "text", false, true)
+		if err != nil {
+			t.Fatalf("newLogger failed: %v", err)
+		}
+		logger.Info(message)
+		_ = logFile.Close()
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	expected := "level=INFO msg=\"first run\"\nlevel=INFO msg=\"second run\"\n"
+	if string(data) != expected {
+		t.Errorf("Expected %q, but got %q", expected, string(data))
+	}
+
+	if _, err := openLogFile(filepath.Join(tempDir, "missing", "cleanup.log")); err == nil {
+		t.Errorf("Expected error for a missing directory, but got nil")
+	}
+}
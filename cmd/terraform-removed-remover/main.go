@@ -2,10 +2,15 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -14,65 +19,311 @@ import (
 	"github.com/hashicorp/hcl/v2/hclwrite"
 )
 
-// Version represents the current version of the terraform-removed-remover tool
-const Version = "0.0.1"
+// Version represents the current version of the terraform-removed-remover tool.
+// Release builds set it from the tag with -ldflags "-X main.Version=...".
+var Version = "0.0.1"
 
 // Stats holds statistics about the processing operation
 type Stats struct {
-	FilesProcessed       int
-	FilesModified        int
+	FilesProcessed int
+	FilesModified  int
+	// FilesSkipped counts the files -skip-invalid left alone because they
+	// could not be parsed.
+	FilesSkipped int
+	// FilesIgnored counts the files left alone because of an ignore-file
+	// directive.
+	FilesIgnored int
+	// FilesNotUTF8 counts the files left alone because they are not valid
+	// UTF-8 text, such as binaries or files in a legacy encoding.
+	FilesNotUTF8         int
 	RemovedBlocksRemoved int
+	RemovedBlocksSkipped int
 	StartTime            time.Time
 	EndTime              time.Time
 	DryRun               bool
 	NormalizeWhitespace  bool
+	// NormalizeAll collapses consecutive blank lines in every file, not only
+	// in files where removed blocks were deleted.
+	NormalizeAll bool
+	// MaxBlankLines is the number of consecutive blank lines normalization
+	// keeps; zero means defaultMaxBlankLines.
+	MaxBlankLines int
+	// FmtAll formats every file, not only files where removed blocks were
+	// deleted, so formatting drift alone rewrites a file.
+	FmtAll bool
+	// NoFormat never formats: removed blocks are cut out and everything else
+	// is left byte for byte as it was.
+	NoFormat bool
+	// PreserveEncoding writes UTF-16 files back in their original encoding
+	// instead of converting them to UTF-8.
+	PreserveEncoding bool
+	// ResolvedRoots are the real locations of the paths given on the command
+	// line. When set, files resolving outside them are never written unless
+	// AllowOutsideRoot is set.
+	ResolvedRoots    []string
+	AllowOutsideRoot bool
+	// MaxFileSize, when positive, skips files larger than this many bytes
+	// with a warning, before they are read.
+	MaxFileSize int64
+	// DiffOutput receives a unified diff of every file the run changes, or
+	// would change in a dry run. Nil disables diffs.
+	DiffOutput io.Writer
+	// Edits, when set, records every change the run makes, or would make, as
+	// LSP text edits for -edits-json.
+	Edits *WorkspaceEdit
+	// DirConfigs, when set, overrides options for the files below nested
+	// configuration files.
+	DirConfigs *dirConfigs
+	// DiffAlgorithm is one of the diff* algorithm names; empty means Myers.
+	DiffAlgorithm string
+	// DiffContext is the number of unchanged lines around each change.
+	DiffContext int
+	// Color colors the diff and the summary with ANSI escape sequences.
+	Color bool
+	// FinalNewline is the end-of-file newline policy, one of the
+	// finalNewline* values. Empty keeps the formatter's output as is.
+	FinalNewline string
+	// LineEndings is the line ending policy, one of the lineEndings*
+	// values. Empty keeps every line's ending.
+	LineEndings string
+	// Only restricts removal to blocks whose from address matches one of
+	// these patterns. An empty list removes every block.
+	Only []string
+	// ExcludeAddress preserves blocks whose from address matches any of
+	// these expressions, even when they also match Only.
+	ExcludeAddress []*regexp.Regexp
+	// Providers restricts removal to resources of these providers, as
+	// implied by the resource type (aws for aws_instance).
+	Providers []string
+	// TypePrefixes restricts removal to resources whose type starts with
+	// one of these prefixes.
+	TypePrefixes []string
+	// Modules restricts removal to blocks targeting a module call, given as
+	// module addresses like module.networking, or anything inside it.
+	Modules []string
+	// AllowedAddresses, when non-nil, restricts removal to exactly these from
+	// addresses.
+	AllowedAddresses map[string]bool
+	// Selection, when set, restricts removal to the blocks chosen in it.
+	Selection *blockSelection
+	// OlderThan, when non-zero, restricts removal to blocks whose first line
+	// was committed at least this long ago according to git blame.
+	OlderThan time.Duration
+	// Blame records the commit that introduced each block in the report.
+	Blame bool
+	// Owners records the most recent commit touching each block in the
+	// report, as an assignee hint for downstream ticketing.
+	Owners bool
+	// Capabilities records the optional dependencies detected for the run.
+	// Nil means detection was skipped and every feature is assumed to work.
+	Capabilities *Capabilities
+	// Pure runs hermetically: git features are off, nothing is detected
+	// from the environment, and reported durations are zero.
+	Pure bool
+	// Backup, when set, keeps the original of every file before it is
+	// rewritten: a suffix such as .bak for a copy next to it, or a
+	// directory, see backupPath.
+	Backup string
+	// Backups lists the backups made so far, for the backup manifest.
+	Backups []backupEntry
+	// Written lists the configuration files written so far, for -staged to
+	// stage them again.
+	Written []string
+	// WriteFile, when set, receives the files a run would write instead of
+	// the filesystem, so content can be processed in memory.
+	WriteFile func(filePath string, content []byte) error
+	// AuditLog, when set, receives a JSON line for every block deleted, see
+	// AuditRecord.
+	AuditLog io.Writer
+	// StripLeadingComments deletes the comment lines directly above each
+	// deleted block along with it.
+	StripLeadingComments bool
+	// StripTrailingComments deletes the comments following each deleted
+	// block along with it, see trailingCommentEnd.
+	StripTrailingComments bool
+	// Prompter, when set, asks before each block is deleted.
+	Prompter *blockPrompter
+	// Tombstone leaves a comment in place of every deleted block, see
+	// tombstoneComment.
+	Tombstone bool
+	// Archive, when set, receives the source of every block deleted, see
+	// archiveDeletedBlocks.
+	Archive io.Writer
+	// StageDir, when set, receives a copy of every deleted block as its own
+	// .tf file before the source file is rewritten.
+	StageDir string
+	// DiscoveryOptions control which files are picked up when walking a
+	// directory.
+	DiscoveryOptions DiscoveryOptions
+	// SkipInvalid skips files that can't be parsed with a warning instead
+	// of failing them.
+	SkipInvalid bool
+	// FailOnChange makes any modified file fail the run. Dry runs then
+	// render every file, so formatting-only changes are counted too.
+	FailOnChange bool
+	// FormatOnly runs the fmt subcommand: files are formatted and
+	// normalized but removed blocks are left alone.
+	FormatOnly bool
+	// Consolidate runs the consolidate subcommand: removed blocks are moved
+	// into the removed.tf of their directory instead of being deleted.
+	Consolidate bool
+	// Inventory, when set, records every file parsed and what it holds.
+	Inventory *Inventory
+	// Reformatted lists the files fmt changed, or would change in a dry run.
+	Reformatted []string
+	// Findings lists every block that was (or would be) removed.
+	Findings []Finding
+	// Warnings collects non-fatal problems to report at the end of the run.
+	Warnings []string
+	// Errors collects the files that could not be processed, in order.
+	Errors []FileError
+	// NestedFindings lists removed blocks found inside other blocks. They are
+	// invalid Terraform and are never removed.
+	NestedFindings []NestedFinding
+	// ExpiringWithin, when positive, records in Expiring the blocks whose
+	// remove-after date passes within this long.
+	ExpiringWithin time.Duration
+	// Expiring lists the blocks kept for a remove-after date that passes
+	// within ExpiringWithin.
+	Expiring []ExpiringFinding
+	// IgnoredFindings lists removed blocks found in files Terraform ignores.
+	// These files are never modified.
+	IgnoredFindings []Finding
 }
 
-func findTerraformFiles(rootDir string) ([]string, error) {
-	var files []string
+// removedBlock describes a top-level removed block and its byte range in the
+// source file.
+type removedBlock struct {
+	Address string
+	Line    int
+	EndLine int
+	// Destroy is the block's lifecycle destroy setting, true by default.
+	Destroy bool
+	start   int
+	end     int
+	// comment is the comment group directly above the block, or the "//"
+	// member of a JSON block, for directives.
+	comment string
+	// blame and blameErr memoize blockBlame.
+	blame    *BlameInfo
+	blameErr error
+}
 
-	err := filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return fmt.Errorf("error accessing path %s: %w", path, err)
-		}
+// invalidFile returns err for a file that could not be parsed or, with
+// -skip-invalid, counts the file as skipped and returns nil.
+func invalidFile(filePath string, err error, stats *Stats) error {
+	if !stats.SkipInvalid {
+		return &parseError{err}
+	}
+	stats.FilesSkipped++
+	stats.Warnings = append(stats.Warnings, fmt.Sprintf("%s: skipped, it could not be parsed: %s", filePath, err))
+	return nil
+}
 
-		if !info.IsDir() && strings.HasSuffix(path, ".tf") {
-			files = append(files, path)
-		}
+// stringSliceFlag collects the values of a repeatable string flag.
+type stringSliceFlag []string
 
-		return nil
-	})
+func (s *stringSliceFlag) String() string {
+	return strings.Join(*s, ",")
+}
 
-	return files, err
+func (s *stringSliceFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
 }
 
 func processFile(filePath string, stats *Stats) error {
-	content, err := os.ReadFile(filePath)
+	if tooLarge, size := exceedsMaxFileSize(filePath, stats.MaxFileSize); tooLarge {
+		stats.Warnings = append(stats.Warnings, fmt.Sprintf("%s: skipped, its %d bytes exceed -max-file-size (%d bytes)", filePath, size, stats.MaxFileSize))
+		return nil
+	}
+
+	raw, err := os.ReadFile(filePath)
 	if err != nil {
 		return fmt.Errorf("error reading file %s: %w", filePath, err)
 	}
+	if stats.DirConfigs != nil {
+		base := stats.fileOptions()
+		options, err := stats.DirConfigs.options(filePath, base)
+		if err != nil {
+			return err
+		}
+		if options.Ignore {
+			stats.FilesIgnored++
+			return nil
+		}
+		stats.setFileOptions(options)
+		defer stats.setFileOptions(base)
+	}
+	return processSource(filePath, raw, stats)
+}
+
+// processSource processes raw, the content of the file at filePath. It
+// touches the filesystem only to write the result, through writeConfigFile,
+// and for the options that read or write other files.
+func processSource(filePath string, raw []byte, stats *Stats) error {
+	content, encoding, err := decodeContent(raw)
+	if err != nil {
+		return &parseError{fmt.Errorf("error decoding %s: %w", filePath, err)}
+	}
+	if !encoding.isUTF16() && !isText(content) {
+		stats.FilesNotUTF8++
+		stats.Warnings = append(stats.Warnings, fmt.Sprintf("%s: skipped, not valid UTF-8 text", filePath))
+		return nil
+	}
+
+	if ignoresFile(filePath, content) {
+		stats.FilesIgnored++
+		return nil
+	}
+
+	// A cheap byte scan spares clean files the parser
+	if !rendersCleanFiles(stats) && stats.Inventory == nil && !encoding.isUTF16() && !mayContainRemovedBlock(content) {
+		stats.FilesProcessed++
+		return nil
+	}
+
+	if isJSONConfig(filePath) {
+		return processJSONFile(filePath, content, encoding, stats)
+	}
+	if stats.DiscoveryOptions.Terragrunt && isTerragruntConfig(filePath) {
+		return processTerragruntFile(filePath, content, encoding, stats)
+	}
 
 	// Parse with hclsyntax to get block ranges that exclude leading comments
 	syntaxFile, diags := hclsyntax.ParseConfig(content, filePath, hcl.Pos{Line: 1, Column: 1})
 	if diags.HasErrors() {
-		return fmt.Errorf("error parsing %s: %s", filePath, diags.Error())
+		return invalidFile(filePath, parseFailure(filePath, content, diags), stats)
 	}
 
 	syntaxBody, ok := syntaxFile.Body.(*hclsyntax.Body)
 	if !ok {
-		return fmt.Errorf("unexpected body type in %s", filePath)
+		return &parseError{fmt.Errorf("unexpected body type in %s", filePath)}
+	}
+	if stats.Inventory != nil {
+		stats.Inventory.addHCL(filePath, syntaxBody)
 	}
 
-	// Collect byte ranges of removed blocks (SrcRange excludes leading comments)
-	type byteRange struct {
-		start, end int
+	if stats.FormatOnly {
+		return formatFile(filePath, content, encoding, stats)
 	}
-	var removedRanges []byteRange
-	for _, block := range syntaxBody.Blocks {
-		if block.Type == "removed" {
-			r := block.Range()
-			removedRanges = append(removedRanges, byteRange{start: r.Start.Byte, end: r.End.Byte})
-		}
+	if stats.Consolidate && filepath.Base(filePath) == consolidatedFile {
+		stats.FilesProcessed++
+		return nil
+	}
+
+	removedRanges := selectRemovedBlocks(filePath, findRemovedBlocks(syntaxBody, content), content, stats)
+	// Consolidated blocks take their comments, directives included, along.
+	removedRanges = withLeadingComments(content, removedRanges, stats.StripLeadingComments || stats.Consolidate)
+	if stats.StripTrailingComments {
+		removedRanges = withTrailingComments(content, removedRanges)
+	} else {
+		warnTrailingComments(filePath, content, removedRanges, stats)
+	}
+
+	for _, nested := range findNestedRemovedBlocks(syntaxBody, content) {
+		nested.File = filePath
+		stats.NestedFindings = append(stats.NestedFindings, nested)
 	}
 
 	removedBlocksCount := len(removedRanges)
@@ -80,55 +331,82 @@ func processFile(filePath string, stats *Stats) error {
 
 	stats.FilesProcessed++
 
-	if !stats.DryRun {
+	// Dry runs only render the result when it is shown as a diff or needed
+	// for -fail-on-change
+	var formattedContent []byte
+	if !stats.DryRun || stats.DiffOutput != nil || stats.Edits != nil || stats.FailOnChange {
 		resultContent := content
-		if fileModified {
-			// Remove blocks from content in reverse order to preserve byte offsets
-			result := make([]byte, len(content))
-			copy(result, content)
-
-			for i := len(removedRanges) - 1; i >= 0; i-- {
-				r := removedRanges[i]
-				start := r.start
-				end := r.end
-
-				// Consume leading whitespace on the same line as `removed`
-				for start > 0 && (result[start-1] == ' ' || result[start-1] == '\t') {
-					start--
-				}
-
-				// Consume trailing newline after closing brace
-				for end < len(result) && (result[end] == '\r' || result[end] == '\n') {
-					end++
-					if result[end-1] == '\n' {
-						break
-					}
-				}
-
-				result = append(result[:start], result[end:]...)
+		if fileModified && stats.Tombstone {
+			resultContent = tombstoneBlocks(content, removedRanges, stats)
+		} else if fileModified {
+			var junctions []int
+			resultContent, junctions = removeBlocks(content, removedRanges)
+			if stats.NormalizeWhitespace && !stats.NormalizeAll {
+				resultContent = collapseRemovalGaps(resultContent, junctions, stats.maxBlankLines())
 			}
-			resultContent = result
 		}
 
-		formattedContent := hclwrite.Format(resultContent)
+		formattedContent = resultContent
+		if (fileModified || stats.FmtAll) && !stats.NoFormat {
+			formattedContent = hclwrite.Format(resultContent)
+		}
+
+		if stats.NormalizeAll {
+			formattedContent = normalizeBlankLines(formattedContent, stats.maxBlankLines())
+		}
+		formattedContent = applyFinalNewline(formattedContent, content, stats.FinalNewline)
+		formattedContent = applyLineEndings(formattedContent, stats.LineEndings)
+	}
 
-		if fileModified && stats.NormalizeWhitespace {
-			formattedContent = normalizeConsecutiveNewlines(formattedContent)
+	if stats.DiffOutput != nil && !bytes.Equal(formattedContent, content) {
+		if err := writeFileDiff(filePath, content, formattedContent, stats); err != nil {
+			return err
+		}
+	}
+	if stats.Edits != nil && !bytes.Equal(formattedContent, content) {
+		if err := writeFileEdits(filePath, content, formattedContent, stats); err != nil {
+			return err
 		}
+	}
 
-		if fileModified || !bytes.Equal(formattedContent, content) {
+	// Converting a UTF-16 file to UTF-8 is itself a change
+	converted := encoding.isUTF16() && !stats.PreserveEncoding
+	if !stats.DryRun {
+		if fileModified || converted || !bytes.Equal(formattedContent, content) {
 			stats.FilesModified++
 
 			if fileModified {
 				stats.RemovedBlocksRemoved += removedBlocksCount
 			}
 
-			err = os.WriteFile(filePath, formattedContent, 0600)
-			if err != nil {
-				return fmt.Errorf("error writing file %s: %w", filePath, err)
+			if stats.Consolidate && fileModified {
+				if err := consolidateBlocks(filePath, removedRanges, content, stats); err != nil {
+					return err
+				}
+			} else if stats.StageDir != "" {
+				for _, block := range removedRanges {
+					if err := stageDeletedBlock(stats.StageDir, filePath, block, content); err != nil {
+						return err
+					}
+				}
+			}
+
+			if converted {
+				stats.Warnings = append(stats.Warnings, fmt.Sprintf("%s: converted from %s to UTF-8", filePath, encoding.Name))
+			} else {
+				formattedContent = encodeContent(formattedContent, encoding)
+			}
+
+			if err := writeConfigFile(filePath, formattedContent, stats); err != nil {
+				return err
+			}
+			if !stats.Consolidate {
+				if err := recordDeletedBlocks(filePath, removedRanges, content, stats); err != nil {
+					return err
+				}
 			}
 		}
-	} else if fileModified {
+	} else if fileModified || stats.FailOnChange && (converted || !bytes.Equal(formattedContent, content)) {
 		stats.FilesModified++
 		stats.RemovedBlocksRemoved += removedBlocksCount
 	}
@@ -136,116 +414,519 @@ func processFile(filePath string, stats *Stats) error {
 	return nil
 }
 
-func normalizeConsecutiveNewlines(content []byte) []byte {
-	contentStr := string(content)
+// recordDeletedBlocks adds blocks, just deleted from filePath, to the audit
+// log and the archive. content is the source the block offsets refer to.
+func recordDeletedBlocks(filePath string, blocks []removedBlock, content []byte, stats *Stats) error {
+	if err := auditDeletedBlocks(filePath, blocks, content, stats); err != nil {
+		return err
+	}
+	return archiveDeletedBlocks(filePath, blocks, content, stats)
+}
+
+// removeBlockRanges returns a copy of content without the given blocks, which
+// must be in source order, along with their indentation and line break.
+func removeBlockRanges(content []byte, blocks []removedBlock) []byte {
+	result, _ := removeBlocks(content, blocks)
+	return result
+}
 
-	re := strings.NewReplacer("\n\n\n", "\n\n", "\r\n\r\n\r\n", "\r\n\r\n")
+// removeBlocks is removeBlockRanges that also returns the offsets in the
+// result where each block was, in ascending order.
+func removeBlocks(content []byte, blocks []removedBlock) ([]byte, []int) {
+	// Remove blocks from content in reverse order to preserve byte offsets
+	result := make([]byte, len(content))
+	copy(result, content)
+
+	junctions := make([]int, len(blocks))
+	for i := len(blocks) - 1; i >= 0; i-- {
+		r := blocks[i]
+		start := r.start
+		end := r.end
+
+		// Consume leading whitespace on the same line as `removed`
+		for start > 0 && (result[start-1] == ' ' || result[start-1] == '\t') {
+			start--
+		}
 
-	for {
-		newContent := re.Replace(contentStr)
-		if newContent == contentStr {
-			break
+		// Consume trailing newline after closing brace
+		for end < len(result) && (result[end] == '\r' || result[end] == '\n') {
+			end++
+			if result[end-1] == '\n' {
+				break
+			}
+		}
+
+		result = append(result[:start], result[end:]...)
+		junctions[i] = start
+		for j := i + 1; j < len(blocks); j++ {
+			junctions[j] -= end - start
 		}
-		contentStr = newContent
 	}
+	return result, junctions
+}
 
-	contentStr = strings.ReplaceAll(contentStr, "\r\n", "\n")
+// selectRemovedBlocks applies the filters in stats to blocks, recording a
+// finding for each block to remove and counting the rest as skipped. With
+// -interactive, the blocks the filters select are confirmed one by one;
+// content is the source the block offsets refer to.
+func selectRemovedBlocks(filePath string, blocks []removedBlock, content []byte, stats *Stats) []removedBlock {
+	var removedRanges []removedBlock
+	for _, block := range blocks {
+		if kind := addressKind(block.Address); kind == AddressKindUnknown {
+			stats.Warnings = append(stats.Warnings, fmt.Sprintf("%s:%d: removed block targets %q, an address of unknown kind; address filters never match it", filePath, block.Line, block.Address))
+		}
+		selected := (stats.Selection == nil || stats.Selection.selects(filePath, block)) && shouldRemoveBlock(block, stats)
+		// Consolidating keeps blocks in effect, so -older-than and
+		// remove-after dates, which say when a block may go, don't apply.
+		if selected && !stats.Consolidate {
+			selected = isOldEnough(filePath, &block, stats) && isExpired(filePath, block, stats)
+		}
+		if selected && (stats.Prompter == nil || stats.Prompter.confirm(filePath, block, content)) {
+			removedRanges = append(removedRanges, block)
+			finding := Finding{File: filePath, Address: block.Address, Line: block.Line}
+			if stats.Blame {
+				info, err := blockBlame(filePath, &block)
+				if err != nil {
+					stats.Warnings = append(stats.Warnings, fmt.Sprintf("%s:%d: could not determine the commit that introduced %s: %s", filePath, block.Line, block.Address, err))
+				} else if !info.Uncommitted() {
+					finding.Blame = info
+				}
+			}
+			if stats.Owners {
+				owner, err := blockOwner(filePath, &block)
+				if err != nil {
+					stats.Warnings = append(stats.Warnings, fmt.Sprintf("%s:%d: could not determine the owner of %s: %s", filePath, block.Line, block.Address, err))
+				} else {
+					finding.Owner = owner
+				}
+			}
+			stats.Findings = append(stats.Findings, finding)
+		} else {
+			stats.RemovedBlocksSkipped++
+		}
+	}
+	return removedRanges
+}
 
-	contentStr = strings.TrimRight(contentStr, "\n") + "\n"
+// formatFile is the fmt subcommand's counterpart to the removal half of
+// processFile: it applies the same formatting, normalization, and encoding
+// handling without touching any blocks.
+func formatFile(filePath string, content []byte, encoding fileEncoding, stats *Stats) error {
+	stats.FilesProcessed++
 
-	if bytes.Contains(content, []byte("\r\n")) {
-		contentStr = strings.ReplaceAll(contentStr, "\n", "\r\n")
+	formattedContent := hclwrite.Format(content)
+	if stats.NormalizeWhitespace || stats.NormalizeAll {
+		formattedContent = normalizeBlankLines(formattedContent, stats.maxBlankLines())
 	}
+	formattedContent = applyFinalNewline(formattedContent, content, stats.FinalNewline)
+	formattedContent = applyLineEndings(formattedContent, stats.LineEndings)
 
-	return []byte(contentStr)
+	converted := encoding.isUTF16() && !stats.PreserveEncoding
+	if !converted && bytes.Equal(formattedContent, content) {
+		return nil
+	}
+
+	stats.FilesModified++
+	stats.Reformatted = append(stats.Reformatted, filePath)
+	if stats.DryRun {
+		return nil
+	}
+
+	if converted {
+		stats.Warnings = append(stats.Warnings, fmt.Sprintf("%s: converted from %s to UTF-8", filePath, encoding.Name))
+	} else {
+		formattedContent = encodeContent(formattedContent, encoding)
+	}
+
+	return writeConfigFile(filePath, formattedContent, stats)
 }
 
-func printUsage() {
-	fmt.Println("Terraform Removed Block Remover")
-	fmt.Println("-------------------------------")
-	fmt.Println("This tool recursively scans Terraform files, removes all 'removed' blocks,")
-	fmt.Println("and applies standard Terraform formatting to the files.")
-	fmt.Println()
-	fmt.Println("Usage: terraform-removed-remover [options] [directory]")
-	fmt.Println("       If directory is not specified, the current directory will be used.")
-	fmt.Println()
-	fmt.Println("Options:")
-	flag.PrintDefaults()
-	fmt.Println()
+// findRemovedBlocks returns the top-level removed blocks of body in source
+// order. Block ranges exclude leading comments.
+func findRemovedBlocks(body *hclsyntax.Body, content []byte) []removedBlock {
+	var blocks []removedBlock
+	for _, block := range body.Blocks {
+		if block.Type != "removed" {
+			continue
+		}
+		r := block.Range()
+		blocks = append(blocks, removedBlock{
+			Address: blockFromAddress(block, content),
+			Line:    r.Start.Line,
+			EndLine: r.End.Line,
+			Destroy: blockDestroys(block),
+			start:   r.Start.Byte,
+			end:     r.End.Byte,
+			comment: string(content[leadingCommentStart(content, r.Start.Byte):r.Start.Byte]),
+		})
+	}
+	return blocks
 }
 
-func main() {
-	helpFlag := flag.Bool("help", false, "Display help information")
-	versionFlag := flag.Bool("version", false, "Display version information")
-	dryRunFlag := flag.Bool("dry-run", false, "Run without modifying files")
-	verboseFlag := flag.Bool("verbose", false, "Enable verbose output")
-	normalizeFlag := flag.Bool("normalize-whitespace", false, "Normalize whitespace after removing removed blocks")
+// findNestedRemovedBlocks returns removed blocks that appear anywhere below
+// the top level of body, for example inside a resource block after a bad
+// merge. Terraform rejects these, so they are reported rather than removed.
+func findNestedRemovedBlocks(body *hclsyntax.Body, content []byte) []NestedFinding {
+	var nested []NestedFinding
+
+	var walk func(parent *hclsyntax.Block)
+	walk = func(parent *hclsyntax.Block) {
+		for _, block := range parent.Body.Blocks {
+			if block.Type == "removed" {
+				r := block.Range()
+				nested = append(nested, NestedFinding{
+					Finding: Finding{Address: blockFromAddress(block, content), Line: r.Start.Line},
+					Column:  r.Start.Column,
+					Parent:  parent.Type,
+				})
+			}
+			walk(block)
+		}
+	}
+	for _, block := range body.Blocks {
+		walk(block)
+	}
 
-	flag.Usage = printUsage
+	return nested
+}
 
-	flag.Parse()
+// blockFromAddress returns the source text of the block's from attribute, or
+// an empty string when the attribute is missing.
+func blockFromAddress(block *hclsyntax.Block, content []byte) string {
+	attr, ok := block.Body.Attributes["from"]
+	if !ok {
+		return ""
+	}
+	r := attr.Expr.Range()
+	return strings.Join(strings.Fields(string(content[r.Start.Byte:r.End.Byte])), "")
+}
 
-	if *helpFlag {
-		printUsage()
-		os.Exit(0)
+// defaultMaxBlankLines is the number of consecutive blank lines
+// normalization keeps unless -max-blank-lines says otherwise.
+const defaultMaxBlankLines = 1
+
+// normalizeBlankLines shortens every run of blank lines in content to at
+// most maxBlank lines and drops the blank lines at the end, leaving the last
+// line terminated. Each line keeps its own line ending, so files mixing LF
+// and CRLF come out mixed the same way.
+func normalizeBlankLines(content []byte, maxBlank int) []byte {
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
 	}
 
-	if *versionFlag {
-		fmt.Printf("Terraform Removed Block Remover v%s\n", Version)
-		os.Exit(0)
+	var result []byte
+	blank := 0
+	newline := []byte("\n")
+	for _, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			blank++
+			if blank > maxBlank {
+				continue
+			}
+		} else {
+			blank = 0
+		}
+		if bytes.HasSuffix(line, []byte("\r\n")) {
+			newline = []byte("\r\n")
+		} else if bytes.HasSuffix(line, []byte("\n")) {
+			newline = []byte("\n")
+		}
+		result = append(result, line...)
 	}
 
-	args := flag.Args()
-	rootDir := "."
+	end := len(result)
+	for end > 0 {
+		start := lineStartOf(result, end-1)
+		if len(bytes.TrimSpace(result[start:end])) > 0 {
+			break
+		}
+		end = start
+	}
+	result = result[:end]
+	if !bytes.HasSuffix(result, []byte("\n")) {
+		result = append(result, newline...)
+	}
+	return result
+}
 
-	if len(args) > 0 {
-		rootDir = args[0]
+// maxBlankLines is the number of consecutive blank lines normalization
+// keeps.
+func (s *Stats) maxBlankLines() int {
+	if s.MaxBlankLines > 0 {
+		return s.MaxBlankLines
 	}
+	return defaultMaxBlankLines
+}
 
-	info, err := os.Stat(rootDir)
-	if err != nil {
-		fmt.Printf("Error: %s\n", err)
-		os.Exit(1)
+// collapseRemovalGaps shrinks each run of blank lines that joins where
+// blocks were removed, given by junctions in ascending order, to the longest
+// of the runs it was made of, but no more than maxBlank. Blank lines
+// elsewhere, such as the double blank lines that set off banner comments,
+// are left alone. Trailing blank lines are dropped when a block was removed
+// from the end of the file.
+func collapseRemovalGaps(content []byte, junctions []int, maxBlank int) []byte {
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	// cut[i] means a block was removed right before line i
+	cut := make([]bool, len(lines)+1)
+	offset, next := 0, 0
+	for i, line := range lines {
+		for next < len(junctions) && junctions[next] <= offset {
+			if junctions[next] == offset {
+				cut[i] = true
+			}
+			next++
+		}
+		offset += len(line)
+	}
+	for ; next < len(junctions); next++ {
+		if junctions[next] == offset {
+			cut[len(lines)] = true
+		}
+	}
+
+	isBlank := func(line []byte) bool {
+		return len(bytes.TrimSpace(line)) == 0
+	}
+
+	var result []byte
+	for i := 0; i < len(lines); {
+		if !isBlank(lines[i]) {
+			result = append(result, lines[i]...)
+			i++
+			continue
+		}
+
+		end := i
+		for end < len(lines) && isBlank(lines[end]) {
+			end++
+		}
+		keep := end - i
+		if slices.Contains(cut[i:end+1], true) {
+			// The run at the start or end of the file goes entirely
+			keep = 0
+			if i > 0 && end < len(lines) {
+				segment := 0
+				for j := i; j <= end; j++ {
+					if cut[j] {
+						keep = max(keep, segment)
+						segment = 0
+					}
+					if j < end {
+						segment++
+					}
+				}
+				keep = min(max(keep, segment), maxBlank)
+			}
+		}
+		for _, line := range lines[i : i+keep] {
+			result = append(result, line...)
+		}
+		i = end
+	}
+	return result
+}
+
+// End-of-file newline policies for -final-newline.
+const (
+	// finalNewlineAlways ends every file with exactly one newline.
+	finalNewlineAlways = "always"
+	// finalNewlinePreserve keeps the trailing newlines the file had before
+	// it was processed, byte for byte.
+	finalNewlinePreserve = "preserve"
+	// finalNewlineNever strips all trailing newlines.
+	finalNewlineNever = "never"
+)
+
+// finalNewlineAliases name the policies after what they leave at the end
+// of a file.
+var finalNewlineAliases = map[string]string{
+	"one":  finalNewlineAlways,
+	"none": finalNewlineNever,
+}
+
+// parseFinalNewline returns the -final-newline policy named value, or
+// reports that there is none. Empty is the formatter's default.
+func parseFinalNewline(value string) (string, bool) {
+	if policy, ok := finalNewlineAliases[value]; ok {
+		return policy, true
+	}
+	switch value {
+	case "", finalNewlineAlways, finalNewlinePreserve, finalNewlineNever:
+		return value, true
+	}
+	return "", false
+}
+
+// applyFinalNewline rewrites the trailing newlines of content according to
+// policy. original is the file as read, which preserve restores the ending
+// of. The newline style of the file, LF or CRLF, is kept.
+func applyFinalNewline(content, original []byte, policy string) []byte {
+	switch policy {
+	case finalNewlineAlways:
+		newline := "\n"
+		if bytes.Contains(content, []byte("\r\n")) {
+			newline = "\r\n"
+		}
+		return append(bytes.TrimRight(content, "\r\n"), newline...)
+	case finalNewlinePreserve:
+		body := bytes.TrimRight(original, "\r\n")
+		return append(bytes.TrimRight(content, "\r\n"), original[len(body):]...)
+	case finalNewlineNever:
+		return bytes.TrimRight(content, "\r\n")
+	default:
+		return content
 	}
+}
+
+// Line ending policies for -line-endings.
+const (
+	// lineEndingsPreserve keeps the line ending of every line as it was,
+	// so files mixing LF and CRLF stay mixed.
+	lineEndingsPreserve = "preserve"
+	// lineEndingsLF ends every line with LF.
+	lineEndingsLF = "lf"
+	// lineEndingsCRLF ends every line with CRLF.
+	lineEndingsCRLF = "crlf"
+)
 
-	if !info.IsDir() {
-		fmt.Printf("Error: %s is not a directory\n", rootDir)
-		os.Exit(1)
+// applyLineEndings converts the line endings of content according to
+// policy. Empty is lineEndingsPreserve.
+func applyLineEndings(content []byte, policy string) []byte {
+	switch policy {
+	case lineEndingsLF:
+		return bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+	case lineEndingsCRLF:
+		return bytes.ReplaceAll(bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n")), []byte("\n"), []byte("\r\n"))
+	default:
+		return content
 	}
+}
 
-	stats := Stats{
-		StartTime:           time.Now(),
-		DryRun:              *dryRunFlag,
-		NormalizeWhitespace: *normalizeFlag,
+// listedFiles builds a Discovery from the -files list, where "-" means stdin.
+// Paths that no longer exist, such as files deleted in a diff, are skipped
+// with a warning.
+func listedFiles(source string, opts DiscoveryOptions, logger *slog.Logger) (*Discovery, error) {
+	r := io.Reader(os.Stdin)
+	if source != "-" {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		r = f
 	}
 
-	fmt.Printf("Scanning directory: %s\n", rootDir)
-	files, err := findTerraformFiles(rootDir)
+	files, err := readFileList(r, opts)
 	if err != nil {
-		fmt.Printf("Error finding Terraform files: %s\n", err)
-		os.Exit(1)
+		return nil, err
 	}
-	fmt.Printf("Found %d Terraform files\n", len(files))
 
+	discovery := &Discovery{}
 	for _, file := range files {
-		if *verboseFlag {
-			fmt.Printf("Processing: %s\n", file)
-		}
-		err := processFile(file, &stats)
+		info, err := os.Stat(file)
 		if err != nil {
-			fmt.Printf("Error processing %s: %s\n", file, err)
+			logger.Warn("Skipping listed file", "file", file, "error", err)
+			continue
+		}
+		if info.IsDir() {
+			logger.Warn("Skipping listed file: is a directory", "file", file)
+			continue
 		}
+		discovery.Files = append(discovery.Files, file)
 	}
+	return discovery, nil
+}
 
-	stats.EndTime = time.Now()
-	duration := stats.EndTime.Sub(stats.StartTime)
+// filterGitDiff narrows discovery to the files changed in rangeSpec, as seen
+// from the repository containing dir.
+func filterGitDiff(discovery *Discovery, dir, rangeSpec string, stats *Stats) (*Discovery, error) {
+	// Processing every file instead would rewrite far more than asked.
+	if !stats.hasGit() {
+		return nil, runtimeError(errors.New("-git-diff needs git, which is not available"))
+	}
+	changed, err := gitChangedFiles(dir, rangeSpec)
+	if err != nil {
+		return nil, runtimeError(err)
+	}
+	return &Discovery{
+		Files:   filterChangedFiles(discovery.Files, changed),
+		Ignored: filterChangedFiles(discovery.Ignored, changed),
+	}, nil
+}
 
-	fmt.Printf("\nStatistics:\n")
-	if stats.DryRun {
-		fmt.Println("DRY RUN MODE: No files were modified")
+// filterGitStaged narrows discovery to the files staged in the repository
+// containing dir, for -staged. Files that also have unstaged changes are
+// left out with a warning, since staging them again would add changes that
+// weren't meant to be committed.
+func filterGitStaged(discovery *Discovery, dir string, stats *Stats) (*Discovery, error) {
+	if !stats.hasGit() {
+		return nil, runtimeError(errors.New("-staged needs git, which is not available"))
+	}
+	staged, err := gitStagedFiles(dir)
+	if err != nil {
+		return nil, runtimeError(err)
+	}
+	unstaged, err := gitUnstagedFiles(dir)
+	if err != nil {
+		return nil, runtimeError(err)
+	}
+	for _, file := range discovery.Files {
+		if path := resolvedPath(file); staged[path] && unstaged[path] {
+			delete(staged, path)
+			stats.Warnings = append(stats.Warnings, fmt.Sprintf("%s: skipped because it has unstaged changes; stage or stash them first", file))
+		}
+	}
+	return &Discovery{
+		Files:   filterChangedFiles(discovery.Files, staged),
+		Ignored: filterChangedFiles(discovery.Ignored, staged),
+	}, nil
+}
+
+// filterGitIgnored drops the files excluded by .gitignore in the repository
+// containing dir. If git can't decide, every file is kept with a warning.
+func filterGitIgnored(discovery *Discovery, dir string, stats *Stats) *Discovery {
+	ignored, err := gitIgnoredFiles(dir, append(append([]string{}, discovery.Files...), discovery.Ignored...))
+	if err != nil {
+		stats.Warnings = append(stats.Warnings, fmt.Sprintf("%s: could not apply .gitignore rules: %s", dir, err))
+		return discovery
+	}
+
+	return discovery.exclude(func(file string) bool { return ignored[file] })
+}
+
+func printUsage(flags *flag.FlagSet) {
+	fmt.Println("Terraform Removed Block Remover")
+	fmt.Println("-------------------------------")
+	fmt.Println("This tool recursively scans Terraform files, removes all 'removed' blocks,")
+	fmt.Println("and applies standard Terraform formatting to the files it changes.")
+	fmt.Println()
+	fmt.Println("Usage: terraform-removed-remover [options] [directory|file.tf ...]")
+	fmt.Println("       terraform-removed-remover [options] -files <list|->")
+	fmt.Println("       terraform-removed-remover fmt [options] [path ...]")
+	fmt.Println("       terraform-removed-remover consolidate [options] [path ...]")
+	fmt.Println("       terraform-removed-remover tui [options] [path ...]")
+	fmt.Println("       terraform-removed-remover serve [-listen addr] [options]")
+	fmt.Println("       terraform-removed-remover doctor [options] [path ...]")
+	fmt.Println("       terraform-removed-remover compat")
+	fmt.Println("       terraform-removed-remover undo [-dry-run]")
+	fmt.Println("       terraform-removed-remover self-update [-dry-run]")
+	fmt.Println("       If no path is specified, the current directory will be used.")
+	fmt.Println()
+	fmt.Println("Options:")
+	flags.PrintDefaults()
+	fmt.Println()
+}
+
+func main() {
+	if runBrowser() {
+		return
 	}
-	fmt.Printf("Files processed: %d\n", stats.FilesProcessed)
-	fmt.Printf("Files modified: %d\n", stats.FilesModified)
-	fmt.Printf("Removed blocks removed: %d\n", stats.RemovedBlocksRemoved)
-	fmt.Printf("Processing time: %v\n", duration)
+	os.Exit(run(os.Args[1:]))
 }
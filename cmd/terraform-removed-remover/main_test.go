@@ -4,6 +4,7 @@ import (
 	"flag"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -138,7 +139,8 @@ removed {
 		t.Fatalf("Failed to write invalid file: %v", err)
 	}
 
-	err = processFile(invalidFile, &stats)
+	// Without removed blocks, only -fmt-all parses the file
+	err = processFile(invalidFile, &Stats{FmtAll: true})
 	if err == nil {
 		t.Errorf("Expected error for invalid HCL, but got nil")
 	}
@@ -159,6 +161,18 @@ ami = "ami-123456"
 	if err != nil {
 		t.Fatalf("processFile failed for formatting test: %v", err)
 	}
+	untouchedContent, err := os.ReadFile(unformattedFile)
+	if err != nil {
+		t.Fatalf("Failed to read unformatted file: %v", err)
+	}
+	if string(untouchedContent) != unformattedContent {
+		t.Errorf("Expected a file without removed blocks to keep its formatting, but got %q", untouchedContent)
+	}
+
+	err = processFile(unformattedFile, &Stats{FmtAll: true})
+	if err != nil {
+		t.Fatalf("processFile failed for formatting test: %v", err)
+	}
 
 	formattedContent, err := os.ReadFile(unformattedFile)
 	if err != nil {
@@ -576,3 +590,638 @@ removed {
 		t.Errorf("File contains %d trailing empty lines, expected at most 1", trailingEmptyLines)
 	}
 }
+
+func TestFinalNewlinePolicy(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-final-newline-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	tests := []struct {
+		policy   string
+		content  string
+		expected string
+	}{
+		{finalNewlineAlways, "locals {}\n\nremoved {\n  from = aws_instance.old\n}", "locals {}\n"},
+		{finalNewlineAlways, "locals {}", "locals {}\n"},
+		{finalNewlinePreserve, "locals {}\nremoved {\n  from = aws_instance.old\n}", "locals {}"},
+		{finalNewlinePreserve, "locals {}\n\n\n", "locals {}\n\n\n"},
+		{finalNewlineNever, "locals {}\nremoved {\n  from = aws_instance.old\n}\n", "locals {}"},
+		{finalNewlineAlways, "locals {}\r\nremoved {\r\n  from = aws_instance.old\r\n}", "locals {}\r\n"},
+	}
+
+	for i, tt := range tests {
+		testFile := filepath.Join(tempDir, "main.tf")
+		if err := os.WriteFile(testFile, []byte(tt.content), 0600); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+
+		stats := Stats{FinalNewline: tt.policy}
+		if err := processFile(testFile, &stats); err != nil {
+			t.Fatalf("processFile failed: %v", err)
+		}
+
+		content, err := os.ReadFile(testFile)
+		if err != nil {
+			t.Fatalf("Failed to read test file: %v", err)
+		}
+		if string(content) != tt.expected {
+			t.Errorf("Case %d (%s): expected %q, but got %q", i, tt.policy, tt.expected, content)
+		}
+	}
+}
+
+func TestLineEndingsPolicy(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-line-endings-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := "locals {\r\n  a = 1\n}\r\n\nremoved {\n  from = aws_instance.old\r\n}\n"
+	tests := []struct {
+		policy   string
+		expected string
+	}{
+		{"", "locals {\r\n  a = 1\n}\r\n"},
+		{lineEndingsPreserve, "locals {\r\n  a = 1\n}\r\n"},
+		{lineEndingsLF, "locals {\n  a = 1\n}\n"},
+		{lineEndingsCRLF, "locals {\r\n  a = 1\r\n}\r\n"},
+	}
+	for _, tt := range tests {
+		testFile := filepath.Join(tempDir, "main.tf")
+		if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+
+		stats := Stats{LineEndings: tt.policy, NormalizeWhitespace: true}
+		if err := processFile(testFile, &stats); err != nil {
+			t.Fatalf("processFile failed: %v", err)
+		}
+		actual, err := os.ReadFile(testFile)
+		if err != nil {
+			t.Fatalf("Failed to read test file: %v", err)
+		}
+		if string(actual) != tt.expected {
+			t.Errorf("Policy %q: expected %q, but got %q", tt.policy, tt.expected, actual)
+		}
+	}
+
+	// Converting is a change even in files without removed blocks
+	clean := filepath.Join(tempDir, "clean.tf")
+	if err := os.WriteFile(clean, []byte("locals {}\r\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	stats := Stats{LineEndings: lineEndingsLF}
+	if err := processFile(clean, &stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	if actual, _ := os.ReadFile(clean); string(actual) != "locals {}\n" || stats.FilesModified != 1 {
+		t.Errorf("Expected the clean file converted to LF, but got %q and %d modified", actual, stats.FilesModified)
+	}
+}
+
+func TestParseFinalNewline(t *testing.T) {
+	for _, tt := range []struct {
+		value    string
+		expected string
+		ok       bool
+	}{
+		{value: "", expected: "", ok: true},
+		{value: "always", expected: finalNewlineAlways, ok: true},
+		{value: "one", expected: finalNewlineAlways, ok: true},
+		{value: "preserve", expected: finalNewlinePreserve, ok: true},
+		{value: "never", expected: finalNewlineNever, ok: true},
+		{value: "none", expected: finalNewlineNever, ok: true},
+		{value: "two", ok: false},
+	} {
+		actual, ok := parseFinalNewline(tt.value)
+		if actual != tt.expected || ok != tt.ok {
+			t.Errorf("parseFinalNewline(%q) = %q, %v, expected %q, %v", tt.value, actual, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestOnlyFilter(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-only-filter-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	testFile := filepath.Join(tempDir, "main.tf")
+	content := `
+removed {
+  from = aws_instance.old_web
+  lifecycle {
+    destroy = false
+  }
+}
+
+removed {
+  from = aws_instance.legacy
+  lifecycle {
+    destroy = false
+  }
+}
+
+removed {
+  from = aws_s3_bucket.old_logs
+  lifecycle {
+    destroy = true
+  }
+}
+`
+	err = os.WriteFile(testFile, []byte(content), 0600)
+	if err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	stats := Stats{
+		StartTime: time.Now(),
+		Only:      []string{"aws_instance.old*", "aws_s3_bucket.*"},
+	}
+	err = processFile(testFile, &stats)
+	if err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+
+	if stats.RemovedBlocksRemoved != 2 {
+		t.Errorf("Expected RemovedBlocksRemoved to be 2, but got %d", stats.RemovedBlocksRemoved)
+	}
+	if stats.RemovedBlocksSkipped != 1 {
+		t.Errorf("Expected RemovedBlocksSkipped to be 1, but got %d", stats.RemovedBlocksSkipped)
+	}
+
+	modifiedContent, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read modified file: %v", err)
+	}
+
+	result := string(modifiedContent)
+	if !strings.Contains(result, "aws_instance.legacy") {
+		t.Errorf("Block not matching -only was removed")
+	}
+	if strings.Contains(result, "aws_instance.old_web") || strings.Contains(result, "aws_s3_bucket.old_logs") {
+		t.Errorf("Blocks matching -only were not removed")
+	}
+}
+
+func TestExcludeAddressFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		address  string
+		only     []string
+		exclude  []string
+		expected bool
+	}{
+		{name: "no filters", address: "aws_instance.old", expected: true},
+		{name: "excluded module", address: "module.legacy.aws_instance.old", exclude: []string{`^module\.legacy\.`}, expected: false},
+		{name: "other module kept for removal", address: "module.app.aws_instance.old", exclude: []string{`^module\.legacy\.`}, expected: true},
+		{name: "exclude wins over only", address: "aws_instance.old", only: []string{"aws_instance.*"}, exclude: []string{`old$`}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := Stats{Only: tt.only}
+			for _, expr := range tt.exclude {
+				stats.ExcludeAddress = append(stats.ExcludeAddress, regexp.MustCompile(expr))
+			}
+
+			if got := shouldRemoveBlock(removedBlock{Address: tt.address}, &stats); got != tt.expected {
+				t.Errorf("shouldRemoveBlock(%q) = %v, expected %v", tt.address, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizeAllFlag(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-normalize-all-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := `resource "aws_instance" "web" {
+  ami = "ami-123456"
+}
+
+
+
+resource "aws_s3_bucket" "data" {
+  bucket = "my-bucket"
+}
+`
+
+	for _, tt := range []struct {
+		name         string
+		stats        Stats
+		expectChange bool
+	}{
+		{name: "normalize-whitespace leaves files without removals alone", stats: Stats{NormalizeWhitespace: true}, expectChange: false},
+		{name: "normalize-all applies everywhere", stats: Stats{NormalizeAll: true}, expectChange: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := filepath.Join(tempDir, "main.tf")
+			if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+				t.Fatalf("Failed to write test file: %v", err)
+			}
+
+			if err := processFile(testFile, &tt.stats); err != nil {
+				t.Fatalf("processFile failed: %v", err)
+			}
+
+			modifiedContent, err := os.ReadFile(testFile)
+			if err != nil {
+				t.Fatalf("Failed to read modified file: %v", err)
+			}
+
+			changed := string(modifiedContent) != content
+			if changed != tt.expectChange {
+				t.Errorf("Expected file changed = %v, but got %v:\n%s", tt.expectChange, changed, modifiedContent)
+			}
+			if tt.expectChange && strings.Contains(string(modifiedContent), "}\n\n\n") {
+				t.Errorf("File still contains consecutive blank lines:\n%s", modifiedContent)
+			}
+		})
+	}
+}
+
+func TestNormalizeWhitespaceKeepsBannerSections(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-normalize-banner-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := `# ---- compute ----
+
+resource "aws_instance" "web" {}
+
+removed {
+  from = aws_instance.old
+}
+
+
+# ---- networking ----
+
+resource "aws_vpc" "main" {}
+
+
+# ---- storage ----
+
+removed {
+  from = aws_s3_bucket.old
+}
+
+resource "aws_s3_bucket" "data" {}
+
+removed {
+  from = aws_s3_bucket.older
+}
+`
+	expected := `# ---- compute ----
+
+resource "aws_instance" "web" {}
+
+
+# ---- networking ----
+
+resource "aws_vpc" "main" {}
+
+
+# ---- storage ----
+
+resource "aws_s3_bucket" "data" {}
+`
+
+	testFile := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	// The banners are set off by two blank lines, which -max-blank-lines 1
+	// would cut down to one where a block was removed next to them
+	stats := Stats{NormalizeWhitespace: true, MaxBlankLines: 2}
+	if err := processFile(testFile, &stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+
+	modifiedContent, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read modified file: %v", err)
+	}
+	if string(modifiedContent) != expected {
+		t.Errorf("Expected content:\n%s\nBut got:\n%s", expected, modifiedContent)
+	}
+}
+
+func TestNormalizeWhitespaceMaxBlankLines(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-normalize-max-blank-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := "resource \"aws_instance\" \"web\" {}\n\n\nremoved {\n  from = aws_instance.old\n}\n\nresource \"aws_vpc\" \"main\" {}\n"
+	expected := "resource \"aws_instance\" \"web\" {}\n\nresource \"aws_vpc\" \"main\" {}\n"
+	testFile := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	status := run([]string{"-no-config", "-log-level", "error", "-normalize-whitespace", "-max-blank-lines", "1", testFile})
+	if status != exitChanges {
+		t.Fatalf("Expected exit status %d, but got %d", exitChanges, status)
+	}
+	modifiedContent, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read modified file: %v", err)
+	}
+	if string(modifiedContent) != expected {
+		t.Errorf("Expected %q, but got %q", expected, modifiedContent)
+	}
+}
+
+func TestNestedRemovedBlocksAreReportedNotRemoved(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-nested-removed-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	testFile := filepath.Join(tempDir, "main.tf")
+	content := `resource "aws_instance" "web" {
+  ami = "ami-123456"
+
+  removed {
+    from = aws_instance.merged_by_mistake
+  }
+}
+
+removed {
+  from = aws_instance.old
+}
+`
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	stats := Stats{}
+	if err := processFile(testFile, &stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+
+	if stats.RemovedBlocksRemoved != 1 {
+		t.Errorf("Expected only the top-level block to be removed, but got %d", stats.RemovedBlocksRemoved)
+	}
+	if len(stats.NestedFindings) != 1 {
+		t.Fatalf("Expected 1 nested finding, but got %v", stats.NestedFindings)
+	}
+
+	nested := stats.NestedFindings[0]
+	if nested.File != testFile || nested.Line != 4 || nested.Column != 3 || nested.Parent != "resource" || nested.Address != "aws_instance.merged_by_mistake" {
+		t.Errorf("Unexpected nested finding %+v", nested)
+	}
+
+	modifiedContent, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read modified file: %v", err)
+	}
+	if !strings.Contains(string(modifiedContent), "aws_instance.merged_by_mistake") {
+		t.Errorf("Nested removed block was modified:\n%s", modifiedContent)
+	}
+}
+
+func TestFormatOnlyKeepsRemovedBlocks(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-fmt-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := `removed {
+from = aws_instance.old
+lifecycle {
+destroy = false
+}
+}
+`
+	expected := `removed {
+  from = aws_instance.old
+  lifecycle {
+    destroy = false
+  }
+}
+`
+	testFile := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	dryRunStats := Stats{FormatOnly: true, DryRun: true}
+	if err := processFile(testFile, &dryRunStats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	if len(dryRunStats.Reformatted) != 1 {
+		t.Errorf("Expected the dry run to report 1 file needing formatting, but got %v", dryRunStats.Reformatted)
+	}
+
+	stats := Stats{FormatOnly: true}
+	if err := processFile(testFile, &stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+
+	modifiedContent, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read modified file: %v", err)
+	}
+	if string(modifiedContent) != expected {
+		t.Errorf("Expected formatted content:\n%s\nbut got:\n%s", expected, modifiedContent)
+	}
+	if stats.RemovedBlocksRemoved != 0 || stats.FilesModified != 1 {
+		t.Errorf("Expected 1 formatted file and no removed blocks, but got %+v", stats)
+	}
+}
+
+func TestFailOnChangeCountsFormattingInDryRun(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-fail-on-change-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := "resource \"aws_instance\" \"web\" {\n  ami =   \"ami-123\"\n}\n"
+	testFile := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	stats := Stats{DryRun: true}
+	if err := processFile(testFile, &stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	if stats.FilesModified != 0 {
+		t.Errorf("Expected a plain dry run not to count formatting, but got %d modified", stats.FilesModified)
+	}
+
+	stats = Stats{DryRun: true, FailOnChange: true}
+	if err := processFile(testFile, &stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	if stats.FilesModified != 0 {
+		t.Errorf("Expected formatting not to count without -fmt-all, but got %d modified", stats.FilesModified)
+	}
+
+	stats = Stats{DryRun: true, FailOnChange: true, FmtAll: true}
+	if err := processFile(testFile, &stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	if stats.FilesModified != 1 || stats.RemovedBlocksRemoved != 0 {
+		t.Errorf("Expected 1 file that would be reformatted, but got %d modified and %d removed", stats.FilesModified, stats.RemovedBlocksRemoved)
+	}
+
+	unchanged, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	if string(unchanged) != content {
+		t.Errorf("Expected the dry run to leave the file alone, but got:\n%s", unchanged)
+	}
+}
+
+func TestSkipInvalidCountsUnparsableFiles(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-skip-invalid-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := "removed {\n  from = ${template_address}\n"
+	testFile := filepath.Join(tempDir, "fixture.tf")
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	if err := processFile(testFile, &Stats{}); err == nil {
+		t.Fatalf("Expected error for an unparsable file, but got nil")
+	}
+
+	stats := Stats{SkipInvalid: true}
+	if err := processFile(testFile, &stats); err != nil {
+		t.Fatalf("Expected -skip-invalid to skip the file, but got %v", err)
+	}
+	if stats.FilesSkipped != 1 || stats.FilesProcessed != 0 {
+		t.Errorf("Expected 1 skipped and no processed files, but got %d skipped and %d processed", stats.FilesSkipped, stats.FilesProcessed)
+	}
+	if len(stats.Warnings) != 1 || !strings.Contains(stats.Warnings[0], "could not be parsed") {
+		t.Errorf("Expected a warning about the unparsable file, but got %v", stats.Warnings)
+	}
+
+	unchanged, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	if string(unchanged) != content {
+		t.Errorf("Expected the skipped file to be left alone, but got:\n%s", unchanged)
+	}
+}
+
+func TestProcessFileNoFormat(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-no-format-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := "resource \"aws_instance\" \"web\" {\n  ami           =   \"ami-123\"\n    instance_type = \"t3.micro\"\n}\n\nremoved {\n  from = aws_instance.old\n}\n\nlocals {\nname = \"web\"\n}\n"
+	testFile := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	stats := Stats{NoFormat: true}
+	if err := processFile(testFile, &stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	actual, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	expected := "resource \"aws_instance\" \"web\" {\n  ami           =   \"ami-123\"\n    instance_type = \"t3.micro\"\n}\n\n\nlocals {\nname = \"web\"\n}\n"
+	if string(actual) != expected {
+		t.Errorf("Expected only the block cut out, %q, but got %q", expected, string(actual))
+	}
+	if stats.RemovedBlocksRemoved != 1 {
+		t.Errorf("Expected 1 removed block, but got %d", stats.RemovedBlocksRemoved)
+	}
+}
+
+func TestNormalizeBlankLines(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		content  string
+		maxBlank int
+		expected string
+	}{
+		{name: "one blank line", content: "a\n\n\n\nb\n", maxBlank: 1, expected: "a\n\nb\n"},
+		{name: "two blank lines", content: "a\n\n\n\nb\n\nc\n", maxBlank: 2, expected: "a\n\n\nb\n\nc\n"},
+		{name: "leading blank lines", content: "\n\n\na\n", maxBlank: 1, expected: "\na\n"},
+		{name: "trailing blank lines", content: "a\n\n\n", maxBlank: 1, expected: "a\n"},
+		{name: "whitespace-only lines", content: "a\n  \n\t\n\nb\n", maxBlank: 1, expected: "a\n  \nb\n"},
+		{name: "missing final newline", content: "a\r\nb", maxBlank: 1, expected: "a\r\nb\r\n"},
+		{name: "crlf", content: "a\r\n\r\n\r\n\r\nb\r\n", maxBlank: 1, expected: "a\r\n\r\nb\r\n"},
+		{name: "mixed line endings", content: "a\r\n\n\r\n\nb\nc\r\n", maxBlank: 1, expected: "a\r\n\nb\nc\r\n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := string(normalizeBlankLines([]byte(tt.content), tt.maxBlank)); actual != tt.expected {
+				t.Errorf("Expected %q, but got %q", tt.expected, actual)
+			}
+		})
+	}
+}
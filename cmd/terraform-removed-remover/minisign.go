@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// minisignAlgorithm marks a minisign key or a legacy signature of the
+// message itself. Prehashed signatures, "ED", need BLAKE2b, which the
+// standard library lacks, so releases are signed with minisign -l.
+const minisignAlgorithm = "Ed"
+
+// minisignPublicKey is a public key in minisign's format: the algorithm,
+// an 8-byte key ID, and the Ed25519 key.
+type minisignPublicKey struct {
+	keyID [8]byte
+	key   ed25519.PublicKey
+}
+
+// parseMinisignPublicKey parses a minisign public key, either the key line
+// alone or the whole .pub file with its comment.
+func parseMinisignPublicKey(text string) (*minisignPublicKey, error) {
+	var line string
+	for _, l := range strings.Split(strings.TrimSpace(text), "\n") {
+		if l = strings.TrimSpace(l); l != "" && !strings.HasPrefix(l, "untrusted comment:") {
+			line = l
+		}
+	}
+	data, err := base64.StdEncoding.DecodeString(line)
+	if err != nil || len(data) != 2+8+ed25519.PublicKeySize || string(data[:2]) != minisignAlgorithm {
+		return nil, errors.New("invalid minisign public key")
+	}
+	key := &minisignPublicKey{key: ed25519.PublicKey(data[10:])}
+	copy(key.keyID[:], data[2:10])
+	return key, nil
+}
+
+// verify checks signature, the contents of a .minisig file, against
+// message: the signature of the message itself, and the global signature
+// binding the trusted comment to it.
+func (k *minisignPublicKey) verify(message, signature []byte) error {
+	lines := strings.Split(strings.TrimRight(string(signature), "\n"), "\n")
+	if len(lines) != 4 {
+		return errors.New("invalid minisign signature: expected 4 lines")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+		return errors.New("invalid minisign signature")
+	}
+	if algorithm := string(sig[:2]); algorithm != minisignAlgorithm {
+		return fmt.Errorf("unsupported minisign signature algorithm %q; sign with minisign -l", algorithm)
+	}
+	if !bytes.Equal(sig[2:10], k.keyID[:]) {
+		return errors.New("minisign signature was made with a different key")
+	}
+	if !ed25519.Verify(k.key, message, sig[10:]) {
+		return errors.New("minisign signature does not match")
+	}
+
+	comment, ok := strings.CutPrefix(lines[2], "trusted comment: ")
+	if !ok {
+		return errors.New("invalid minisign signature: no trusted comment")
+	}
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return errors.New("invalid minisign signature: invalid global signature")
+	}
+	if !ed25519.Verify(k.key, append(append([]byte{}, sig[10:]...), comment...), global) {
+		return errors.New("minisign trusted comment does not match")
+	}
+	return nil
+}
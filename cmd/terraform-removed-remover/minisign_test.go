@@ -0,0 +1,72 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// newMinisignKey returns a key pair with the public key in minisign's .pub
+// format.
+func newMinisignKey(t *testing.T) (string, ed25519.PrivateKey) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	keyID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	data := append(append([]byte(minisignAlgorithm), keyID...), public...)
+	return "untrusted comment: minisign public key 0807060504030201\n" + base64.StdEncoding.EncodeToString(data) + "\n", private
+}
+
+// minisignSign signs message as minisign -l does, with the key ID of
+// newMinisignKey.
+func minisignSign(private ed25519.PrivateKey, message []byte, comment string) string {
+	keyID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	sig := ed25519.Sign(private, message)
+	global := ed25519.Sign(private, append(append([]byte{}, sig...), comment...))
+	data := append(append([]byte(minisignAlgorithm), keyID...), sig...)
+	return "untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(data) + "\n" +
+		"trusted comment: " + comment + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n"
+}
+
+func TestMinisignVerify(t *testing.T) {
+	publicKey, private := newMinisignKey(t)
+	key, err := parseMinisignPublicKey(publicKey)
+	if err != nil {
+		t.Fatalf("parseMinisignPublicKey failed: %v", err)
+	}
+	message := []byte("checksums\n")
+	signature := minisignSign(private, message, "terraform-removed-remover v1.0.0")
+
+	if err := key.verify(message, []byte(signature)); err != nil {
+		t.Errorf("Expected a valid signature, but got %v", err)
+	}
+
+	_, other := newMinisignKey(t)
+	for _, tt := range []struct {
+		name      string
+		message   string
+		signature string
+		expected  string
+	}{
+		{name: "tampered message", message: "checksums!\n", signature: signature, expected: "signature does not match"},
+		{name: "other key", message: string(message), signature: minisignSign(other, message, "c"), expected: "signature does not match"},
+		{name: "tampered comment", message: string(message), signature: strings.Replace(signature, "v1.0.0", "v9.9.9", 1), expected: "trusted comment does not match"},
+		{name: "truncated", message: string(message), signature: signature[:40], expected: "expected 4 lines"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := key.verify([]byte(tt.message), []byte(tt.signature))
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected an error containing %q, but got %v", tt.expected, err)
+			}
+		})
+	}
+
+	if _, err := parseMinisignPublicKey("not a key"); err == nil {
+		t.Errorf("Expected an error for an invalid key, but got nil")
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// planEdit is one entry of a -plan file: a removed block to delete from
+// file, given by the address it removes or by the lines it starts on.
+type planEdit struct {
+	File      string `json:"file"`
+	Address   string `json:"address,omitempty"`
+	StartLine int    `json:"start_line,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+}
+
+// loadEditPlan reads the JSON list of edits at path. Relative file paths
+// are relative to the current directory, as with -files.
+func loadEditPlan(path string) (*blockSelection, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading plan: %w", err)
+	}
+	var edits []planEdit
+	if err := json.Unmarshal(data, &edits); err != nil {
+		return nil, fmt.Errorf("error reading plan %s: %w", path, err)
+	}
+
+	selection := newBlockSelection()
+	for i, edit := range edits {
+		label := fmt.Sprintf("%s: edit %d", path, i+1)
+		if err := edit.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", label, err)
+		}
+		choice := &blockChoice{address: edit.Address, startLine: edit.StartLine, endLine: edit.EndLine, label: label}
+		if choice.endLine == 0 {
+			choice.endLine = choice.startLine
+		}
+		selection.add(edit.File, choice)
+	}
+	return selection, nil
+}
+
+func (e planEdit) validate() error {
+	switch {
+	case e.File == "":
+		return errors.New("file is required")
+	case e.Address != "" && (e.StartLine != 0 || e.EndLine != 0):
+		return errors.New("give either address or start_line, not both")
+	case e.Address != "":
+		if _, err := parseAddress(e.Address); err != nil {
+			return err
+		}
+	case e.StartLine <= 0:
+		return errors.New("address or a positive start_line is required")
+	case e.EndLine != 0 && e.EndLine < e.StartLine:
+		return fmt.Errorf("end_line %d is before start_line %d", e.EndLine, e.StartLine)
+	}
+	return nil
+}
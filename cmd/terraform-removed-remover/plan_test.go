@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoadEditPlan(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-plan-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := `removed {
+  from = aws_instance.a
+}
+
+removed {
+  from = aws_instance.b
+}
+
+removed {
+  from = aws_instance.c
+}
+`
+	testFile := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	planFile := filepath.Join(tempDir, "plan.json")
+	plan := `[
+  {"file": "` + testFile + `", "address": "aws_instance.a"},
+  {"file": "` + testFile + `", "start_line": 8, "end_line": 11},
+  {"file": "` + testFile + `", "address": "aws_instance.gone"},
+  {"file": "` + testFile + `", "start_line": 2}
+]`
+	if err := os.WriteFile(planFile, []byte(plan), 0600); err != nil {
+		t.Fatalf("Failed to write plan: %v", err)
+	}
+
+	selection, err := loadEditPlan(planFile)
+	if err != nil {
+		t.Fatalf("loadEditPlan failed: %v", err)
+	}
+	if expected := []string{testFile}; !slices.Equal(selection.paths(), expected) {
+		t.Errorf("Expected %v, but got %v", expected, selection.paths())
+	}
+
+	stats := &Stats{Selection: selection}
+	if err := processFile(testFile, stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	data, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	if strings.Contains(string(data), "aws_instance.a") || !strings.Contains(string(data), "aws_instance.b") || strings.Contains(string(data), "aws_instance.c") {
+		t.Errorf("Expected only aws_instance.b to be left, but got %q", string(data))
+	}
+
+	expected := []string{
+		planFile + ": edit 3: no removed block for aws_instance.gone in " + testFile,
+		planFile + ": edit 4: no removed block starts on lines 2-2 of " + testFile,
+	}
+	if actual := selection.unmatched(); !slices.Equal(actual, expected) {
+		t.Errorf("Expected %q, but got %q", expected, actual)
+	}
+}
+
+func TestLoadEditPlanInvalid(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-plan-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	for _, tt := range []struct {
+		plan     string
+		expected string
+	}{
+		{plan: `{"file": "main.tf"}`, expected: "cannot unmarshal"},
+		{plan: `[{"address": "aws_instance.a"}]`, expected: "edit 1: file is required"},
+		{plan: `[{"file": "main.tf"}]`, expected: "edit 1: address or a positive start_line is required"},
+		{plan: `[{"file": "main.tf", "address": "aws_instance.a", "start_line": 1}]`, expected: "edit 1: give either address or start_line, not both"},
+		{plan: `[{"file": "main.tf", "start_line": 1}, {"file": "main.tf", "start_line": 5, "end_line": 3}]`, expected: "edit 2: end_line 3 is before start_line 5"},
+		{plan: `[{"file": "main.tf", "address": "not an address"}]`, expected: "edit 1:"},
+	} {
+		planFile := filepath.Join(tempDir, "plan.json")
+		if err := os.WriteFile(planFile, []byte(tt.plan), 0600); err != nil {
+			t.Fatalf("Failed to write plan: %v", err)
+		}
+		_, err := loadEditPlan(planFile)
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("Expected an error containing %q for %s, but got %v", tt.expected, tt.plan, err)
+		}
+	}
+}
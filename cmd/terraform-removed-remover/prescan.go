@@ -0,0 +1,40 @@
+package main
+
+import "bytes"
+
+var removedToken = []byte("removed")
+
+// mayContainRemovedBlock reports whether content has the removed keyword as
+// a whole word anywhere, including in comments and strings. Without it the
+// file can't hold a removed block, nested or not, in HCL or JSON syntax.
+func mayContainRemovedBlock(content []byte) bool {
+	for offset := 0; ; {
+		i := bytes.Index(content[offset:], removedToken)
+		if i < 0 {
+			return false
+		}
+		start := offset + i
+		end := start + len(removedToken)
+		if (start == 0 || !isIdentByte(content[start-1])) && (end == len(content) || !isIdentByte(content[end])) {
+			return true
+		}
+		offset = end
+	}
+}
+
+func isIdentByte(b byte) bool {
+	return b == '_' || b == '-' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// rendersCleanFiles reports whether files without removed blocks still need
+// a full parse: they are checked by the fmt subcommand and, with -fmt-all,
+// -normalize-all, -final-newline, or -line-endings, changed when written,
+// shown in diffs and edits, and checked by -fail-on-change. Otherwise the
+// pre-scan skips them.
+func rendersCleanFiles(stats *Stats) bool {
+	if stats.FormatOnly {
+		return true
+	}
+	changesCleanFiles := stats.FmtAll || stats.NormalizeAll || stats.FinalNewline != "" || stats.LineEndings != "" && stats.LineEndings != lineEndingsPreserve
+	return changesCleanFiles && (!stats.DryRun || stats.DiffOutput != nil || stats.Edits != nil || stats.FailOnChange)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMayContainRemovedBlock(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected bool
+	}{
+		{content: "removed {\n  from = aws_instance.old\n}\n", expected: true},
+		{content: "resource \"a\" \"b\" {\n  lifecycle {\n    removed {}\n  }\n}\n", expected: true},
+		{content: `{"removed": [{"from": "aws_instance.old"}]}`, expected: true},
+		{content: "# removed\n", expected: true},
+		{content: "locals {\n  removed_items = []\n  unremoved    = 1\n}\n", expected: false},
+		{content: "locals {}\n", expected: false},
+		{content: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		if got := mayContainRemovedBlock([]byte(tt.content)); got != tt.expected {
+			t.Errorf("mayContainRemovedBlock(%q) = %v, expected %v", tt.content, got, tt.expected)
+		}
+	}
+}
+
+func TestProcessFileSkipsCleanFilesWithoutParsing(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-prescan-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	// Invalid syntax shows whether the parser ran
+	testFile := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(testFile, []byte("locals {\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	stats := Stats{DryRun: true}
+	if err := processFile(testFile, &stats); err != nil {
+		t.Errorf("Expected the pre-scan to skip the file, but got %v", err)
+	}
+	if stats.FilesProcessed != 1 {
+		t.Errorf("Expected the skipped file to be counted, but got %d", stats.FilesProcessed)
+	}
+
+	stats = Stats{}
+	if err := processFile(testFile, &stats); err != nil {
+		t.Errorf("Expected the pre-scan to skip a file that would not be formatted, but got %v", err)
+	}
+
+	stats = Stats{FmtAll: true}
+	if err := processFile(testFile, &stats); err == nil {
+		t.Errorf("Expected the file to be parsed when it would be formatted, but got nil")
+	}
+}
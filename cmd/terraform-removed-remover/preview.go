@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"html/template"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// previewServer serves the prospective changes of a dry run while the scan
+// is still running, so review can start before a long scan finishes.
+type previewServer struct {
+	mu        sync.Mutex
+	total     int
+	processed int
+	done      bool
+	files     []previewFile
+}
+
+type previewFile struct {
+	File   string        `json:"file"`
+	Blocks []ReportBlock `json:"blocks"`
+}
+
+type previewState struct {
+	Total     int           `json:"total"`
+	Processed int           `json:"processed"`
+	Done      bool          `json:"done"`
+	Files     []previewFile `json:"files"`
+}
+
+func newPreviewServer(total int) *previewServer {
+	return &previewServer{total: total}
+}
+
+// addFile records that file has been scanned. Files without prospective
+// changes only advance the progress counter.
+func (p *previewServer) addFile(file string, findings []Finding) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.processed++
+	if len(findings) > 0 {
+		p.files = append(p.files, previewFile{File: file, Blocks: reportBlocks(findings)})
+	}
+}
+
+func (p *previewServer) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done = true
+}
+
+func (p *previewServer) state() previewState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return previewState{
+		Total:     p.total,
+		Processed: p.processed,
+		Done:      p.done,
+		Files:     append([]previewFile(nil), p.files...),
+	}
+}
+
+func (p *previewServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := previewTemplate.Execute(w, p.state()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	case "/changes.json":
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(p.state()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serve starts serving on addr in the background and returns the URL of
+// the preview page.
+func (p *previewServer) serve(addr string) (string, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+
+	server := &http.Server{Handler: p, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	return "http://" + listener.Addr().String() + "/", nil
+}
+
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+{{if not .Done}}<meta http-equiv="refresh" content="2">{{end}}
+<title>Removed block preview</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+code { background: #f4f4f4; padding: 0 0.2em; }
+li { margin: 0.2em 0; }
+</style>
+</head>
+<body>
+<h1>Removed block preview</h1>
+<p>{{if .Done}}Scan complete{{else}}Scanning{{end}}: {{.Processed}} of {{.Total}} files processed, {{len .Files}} with removed blocks.</p>
+{{range .Files}}
+<h2><code>{{.File}}</code></h2>
+<ul>
+{{range .Blocks}}<li>line {{.Line}}: <code>{{.Address}}</code></li>
+{{end}}</ul>
+{{else}}
+<p>No removed blocks found yet.</p>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPreviewServerReportsProgress(t *testing.T) {
+	preview := newPreviewServer(3)
+	server := httptest.NewServer(preview)
+	defer server.Close()
+
+	preview.addFile("main.tf", []Finding{{File: "main.tf", Line: 3, Address: "aws_instance.<old>"}})
+	preview.addFile("empty.tf", nil)
+
+	resp, err := http.Get(server.URL + "/changes.json")
+	if err != nil {
+		t.Fatalf("Failed to fetch changes: %v", err)
+	}
+	var state previewState
+	decodeErr := json.NewDecoder(resp.Body).Decode(&state)
+	_ = resp.Body.Close()
+	if decodeErr != nil {
+		t.Fatalf("Failed to decode changes: %v", decodeErr)
+	}
+
+	if state.Total != 3 || state.Processed != 2 || state.Done {
+		t.Errorf("Expected 2 of 3 files processed and not done, but got %+v", state)
+	}
+	if len(state.Files) != 1 || state.Files[0].File != "main.tf" || state.Files[0].Blocks[0].Line != 3 {
+		t.Errorf("Expected only main.tf to be listed, but got %+v", state.Files)
+	}
+
+	preview.finish()
+
+	resp, err = http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatalf("Failed to fetch preview page: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	page := string(body)
+	if !strings.Contains(page, "Scan complete") {
+		t.Errorf("Expected the page to report a complete scan, but got:\n%s", page)
+	}
+	if !strings.Contains(page, "aws_instance.&lt;old&gt;") {
+		t.Errorf("Expected the address to be listed and escaped, but got:\n%s", page)
+	}
+	if strings.Contains(page, `http-equiv="refresh"`) {
+		t.Errorf("Expected the finished page to stop refreshing")
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// startProfiling starts writing a CPU profile to cpuPath, if set. The
+// returned function stops it and writes a heap profile to memPath, if set.
+// Either file can be read with go tool pprof.
+func startProfiling(cpuPath, memPath string) (func() error, error) {
+	var cpuFile *os.File
+	if cpuPath != "" {
+		var err error
+		cpuFile, err = os.Create(cpuPath)
+		if err != nil {
+			return nil, fmt.Errorf("error creating CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(cpuFile); err != nil {
+			_ = cpuFile.Close()
+			return nil, fmt.Errorf("error starting CPU profile: %w", err)
+		}
+	}
+
+	return func() error {
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			if err := cpuFile.Close(); err != nil {
+				return fmt.Errorf("error writing CPU profile: %w", err)
+			}
+		}
+		if memPath == "" {
+			return nil
+		}
+
+		memFile, err := os.Create(memPath)
+		if err != nil {
+			return fmt.Errorf("error creating memory profile: %w", err)
+		}
+		// Report live objects as of the end of the run
+		runtime.GC()
+		err = pprof.WriteHeapProfile(memFile)
+		if closeErr := memFile.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("error writing memory profile: %w", err)
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStartProfiling(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-profile-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	cpuPath := filepath.Join(tempDir, "cpu.pprof")
+	memPath := filepath.Join(tempDir, "mem.pprof")
+	stop, err := startProfiling(cpuPath, memPath)
+	if err != nil {
+		t.Fatalf("startProfiling failed: %v", err)
+	}
+	if err := stop(); err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+
+	for _, path := range []string{cpuPath, memPath} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Errorf("Expected %s to be written: %v", path, err)
+		} else if info.Size() == 0 {
+			t.Errorf("Expected %s to hold a profile, but it is empty", path)
+		}
+	}
+
+	if _, err := startProfiling(filepath.Join(tempDir, "missing", "cpu.pprof"), ""); err == nil {
+		t.Errorf("Expected error creating a profile in a missing directory, but got nil")
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// terminalProgressInterval is how often the progress line is redrawn on
+	// a terminal.
+	terminalProgressInterval = 100 * time.Millisecond
+	// logProgressInterval is how often a progress line is printed with
+	// -progress on when stderr is not a terminal, e.g. in CI logs.
+	logProgressInterval = 10 * time.Second
+)
+
+// progressReporter shows how many files of a run have been processed, so a
+// long run over a big monorepo can be told apart from a hung one. On a
+// terminal a single line is redrawn in place; elsewhere a line is printed
+// every logProgressInterval.
+type progressReporter struct {
+	out         io.Writer
+	total       int
+	interactive bool
+	interval    time.Duration
+	last        time.Time
+	width       int
+}
+
+// isTerminal reports whether f is a terminal rather than a pipe or file. It
+// is a variable so tests can stand in for a terminal.
+var isTerminal = func(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// newProgressReporter returns the reporter for -progress mode, or nil if
+// progress is not shown: "auto" shows it only when out is a terminal, "on"
+// always, and "off" never. The caller sets total once files are discovered.
+func newProgressReporter(mode string, out *os.File) (*progressReporter, error) {
+	interactive := isTerminal(out)
+	switch mode {
+	case "auto":
+		if !interactive {
+			return nil, nil
+		}
+	case "on":
+	case "off":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("invalid -progress %q: must be auto, on, or off", mode)
+	}
+
+	interval := logProgressInterval
+	if interactive {
+		interval = terminalProgressInterval
+	}
+	return &progressReporter{out: out, interactive: interactive, interval: interval}, nil
+}
+
+// update reports that done files have been processed. Lines are throttled to
+// the reporter's interval, except for the last file.
+func (p *progressReporter) update(done int, now time.Time) {
+	if done < p.total && now.Sub(p.last) < p.interval {
+		return
+	}
+	p.last = now
+
+	line := fmt.Sprintf("Processed %d/%d files", done, p.total)
+	if !p.interactive {
+		fmt.Fprintln(p.out, line)
+		return
+	}
+	// Pad over the rest of a longer previous line
+	padding := max(p.width-len(line), 0)
+	p.width = len(line)
+	fmt.Fprintf(p.out, "\r%s%s", line, strings.Repeat(" ", padding))
+}
+
+// finish clears the progress line so the summary starts on a clean line.
+func (p *progressReporter) finish() {
+	if !p.interactive || p.width == 0 {
+		return
+	}
+	fmt.Fprintf(p.out, "\r%s\r", strings.Repeat(" ", p.width))
+	p.width = 0
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressReporterThrottles(t *testing.T) {
+	var out bytes.Buffer
+	progress := &progressReporter{out: &out, total: 3, interval: logProgressInterval}
+
+	start := time.Now()
+	progress.update(1, start)
+	progress.update(2, start.Add(time.Second))
+	progress.update(3, start.Add(2*time.Second))
+	progress.finish()
+
+	expected := "Processed 1/3 files\nProcessed 3/3 files\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, but got %q", expected, out.String())
+	}
+}
+
+func TestProgressReporterRedrawsOnTerminal(t *testing.T) {
+	var out bytes.Buffer
+	progress := &progressReporter{out: &out, total: 10, interactive: true, interval: terminalProgressInterval}
+
+	start := time.Now()
+	progress.update(9, start)
+	progress.update(10, start.Add(time.Second))
+	progress.finish()
+
+	expected := "\rProcessed 9/10 files\rProcessed 10/10 files\r" + strings.Repeat(" ", len("Processed 10/10 files")) + "\r"
+	if out.String() != expected {
+		t.Errorf("Expected %q, but got %q", expected, out.String())
+	}
+}
+
+func TestNewProgressReporterModes(t *testing.T) {
+	pipeReader, pipeWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	defer func() {
+		_ = pipeReader.Close()
+		_ = pipeWriter.Close()
+	}()
+
+	for mode, enabled := range map[string]bool{"auto": false, "on": true, "off": false} {
+		progress, err := newProgressReporter(mode, pipeWriter)
+		if err != nil {
+			t.Errorf("newProgressReporter(%q) failed: %v", mode, err)
+			continue
+		}
+		if (progress != nil) != enabled {
+			t.Errorf("Expected progress for %q on a pipe to be %v, but got %v", mode, enabled, progress != nil)
+		}
+		if progress != nil && progress.interactive {
+			t.Errorf("Expected a pipe not to be treated as a terminal")
+		}
+	}
+
+	if _, err := newProgressReporter("always", pipeWriter); err == nil {
+		t.Errorf("Expected error for an unknown mode, but got nil")
+	}
+}
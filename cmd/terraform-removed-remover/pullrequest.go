@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// defaultCommitMessage is the message of the commit -git-branch pushes when
+// -git-commit doesn't give one.
+const defaultCommitMessage = "chore: drop applied removed blocks"
+
+// defaultGitHubAPI is the GitHub API used unless GITHUB_API_URL points at a
+// GitHub Enterprise server.
+const defaultGitHubAPI = "https://api.github.com"
+
+// pullRequestMaxRows caps the blocks listed in a pull request body, which
+// GitHub limits to 65536 characters.
+const pullRequestMaxRows = 500
+
+// pullRequest is what -create-pr opens.
+type pullRequest struct {
+	Title string `json:"title"`
+	Head  string `json:"head"`
+	Base  string `json:"base"`
+	Body  string `json:"body"`
+}
+
+// publishOptions are the -git-commit, -git-branch and -create-pr settings.
+type publishOptions struct {
+	message  string
+	branch   string
+	createPR bool
+	// body is the pull request description.
+	body string
+}
+
+// publishChanges commits the files a run wrote and, with a branch, commits
+// them on that new branch instead, pushes it to origin, and opens a pull
+// request against the branch that was checked out. It returns the pull
+// request's URL, if one was opened.
+func publishChanges(files []string, opts publishOptions, logger *slog.Logger) (string, error) {
+	message := opts.message
+	if message == "" {
+		message = defaultCommitMessage
+	}
+	repo, err := gitTopLevel(filepath.Dir(resolvedPath(files[0])))
+	if err != nil {
+		return "", err
+	}
+
+	var base string
+	if opts.branch != "" {
+		if base, err = gitCurrentBranch(repo); err != nil {
+			return "", err
+		}
+		if _, err := gitOutput(repo, "switch", "-q", "-c", opts.branch); err != nil {
+			return "", err
+		}
+	}
+
+	commit, err := gitCommit(files, message)
+	if err != nil {
+		return "", err
+	}
+	logger.Info("Committed the modified files", "commit", commit, "files", len(files))
+	if opts.branch == "" {
+		return "", nil
+	}
+
+	if _, err := gitOutput(repo, "push", "-q", "-u", "origin", opts.branch); err != nil {
+		return "", err
+	}
+	logger.Info("Pushed branch", "branch", opts.branch)
+	if !opts.createPR {
+		return "", nil
+	}
+
+	title, _, _ := strings.Cut(message, "\n")
+	return openPullRequest(repo, pullRequest{Title: title, Head: opts.branch, Base: base, Body: opts.body})
+}
+
+// openPullRequest opens pr for the repository checked out in repo, through
+// the GitHub API when GITHUB_TOKEN or GH_TOKEN is set and through the gh CLI
+// otherwise.
+func openPullRequest(repo string, pr pullRequest) (string, error) {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		token = os.Getenv("GH_TOKEN")
+	}
+	if token == "" {
+		if _, err := exec.LookPath("gh"); err != nil {
+			return "", errors.New("-create-pr needs GITHUB_TOKEN, GH_TOKEN, or the gh CLI")
+		}
+		return createPullRequestWithGh(repo, pr)
+	}
+
+	remote, err := gitRemoteURL(repo, "origin")
+	if err != nil {
+		return "", err
+	}
+	owner, name, err := parseGitHubRemote(remote)
+	if err != nil {
+		return "", err
+	}
+	apiURL := strings.TrimSuffix(os.Getenv("GITHUB_API_URL"), "/")
+	if apiURL == "" {
+		apiURL = defaultGitHubAPI
+	}
+	return createGitHubPullRequest(newForgeClient(), apiURL, token, owner, name, pr)
+}
+
+// createGitHubPullRequest opens pr in owner/name and returns its URL.
+func createGitHubPullRequest(client *forgeClient, apiURL, token, owner, name string, pr pullRequest) (string, error) {
+	data, err := json.Marshal(pr)
+	if err != nil {
+		return "", err
+	}
+	path := fmt.Sprintf("/repos/%s/%s/pulls", url.PathEscape(owner), url.PathEscape(name))
+	req, err := http.NewRequest(http.MethodPost, apiURL+path, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("github POST %s: %w", path, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", fmt.Errorf("github POST %s: %s: %s", path, resp.Status, strings.TrimSpace(string(message)))
+	}
+	var created struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return "", fmt.Errorf("github POST %s: invalid response: %w", path, err)
+	}
+	return created.HTMLURL, nil
+}
+
+// createPullRequestWithGh opens pr with gh pr create, which prints its URL.
+func createPullRequestWithGh(repo string, pr pullRequest) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("gh", "pr", "create", "--head", pr.Head, "--base", pr.Base, "--title", pr.Title, "--body-file", "-")
+	cmd.Dir = repo
+	cmd.Stdin = strings.NewReader(pr.Body)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("gh pr create failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// parseGitHubRemote returns the owner and name of the repository at remote,
+// an HTTPS or SSH URL such as https://github.com/org/infra.git or
+// git@github.com:org/infra.git.
+func parseGitHubRemote(remote string) (string, string, error) {
+	path := remote
+	if parsed, err := url.Parse(remote); err == nil && parsed.Scheme != "" && parsed.Host != "" {
+		path = parsed.Path
+	} else if _, after, ok := strings.Cut(remote, ":"); ok && !strings.Contains(remote, "://") {
+		path = after
+	}
+	parts := strings.Split(strings.Trim(strings.TrimSuffix(path, ".git"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("cannot tell the GitHub repository of remote %s", remote)
+	}
+	return parts[0], parts[1], nil
+}
+
+// pullRequestBody describes the run in markdown: the counts and a table of
+// the blocks deleted.
+func pullRequestBody(stats *Stats) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Deletes %d `removed` blocks from %d files whose removals have been applied.\n\n", stats.RemovedBlocksRemoved, stats.FilesModified)
+	if len(stats.Findings) > 0 {
+		b.WriteString("| File | Line | Address |\n|---|---|---|\n")
+		for i, finding := range stats.Findings {
+			if i == pullRequestMaxRows {
+				fmt.Fprintf(&b, "\n…and %d more.\n", len(stats.Findings)-i)
+				break
+			}
+			fmt.Fprintf(&b, "| `%s` | %d | `%s` |\n", finding.File, finding.Line, finding.Address)
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "Generated by terraform-removed-remover %s.\n", Version)
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// impureFlags are the flags -pure rejects: they reach the network, run git,
+// read the environment, record the current time, or write outside the roots.
+var impureFlags = []string{
+	"blame",
+	"owners",
+	"older-than",
+	"expiring",
+	"git-diff",
+	"staged",
+	"git-commit",
+	"git-branch",
+	"create-pr",
+	"repo",
+	"jira-project",
+	"serve-preview",
+	"state-dir",
+	"allow-outside-root",
+}
+
+// impureSubcommands are the subcommands -pure rejects: serve listens on the
+// network, and self-update downloads a release to replace the binary.
+var impureSubcommands = []string{"serve", "self-update"}
+
+// pureConflicts returns the impure flags that were set in flags, in the
+// order they are listed in impureFlags.
+func pureConflicts(flags *flag.FlagSet) []string {
+	var conflicts []string
+	flags.Visit(func(f *flag.Flag) {
+		if slices.Contains(impureFlags, f.Name) {
+			conflicts = append(conflicts, f.Name)
+		}
+	})
+	slices.SortFunc(conflicts, func(a, b string) int {
+		return slices.Index(impureFlags, a) - slices.Index(impureFlags, b)
+	})
+	return conflicts
+}
+
+// checkPure returns an error when -pure is set together with a subcommand or
+// flag it rejects. It runs
+// before any subcommand is dispatched.
+func (f *cliFlags) checkPure(subcommand string, flags *flag.FlagSet) error {
+	if !f.pure {
+		return nil
+	}
+	if slices.Contains(impureSubcommands, subcommand) {
+		return fmt.Errorf("-pure cannot be used with the %s subcommand", subcommand)
+	}
+	if conflicts := pureConflicts(flags); len(conflicts) > 0 {
+		return fmt.Errorf("-pure cannot be combined with -%s", strings.Join(conflicts, ", -"))
+	}
+	return nil
+}
+
+// now is the time a run records, as in the backup manifest. It is the zero
+// time with -pure.
+func (s *Stats) now() time.Time {
+	if s.Pure {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// duration is how long the run took so far. It is always zero with -pure,
+// so identical inputs produce identical reports.
+func (s *Stats) duration() time.Duration {
+	if s.Pure {
+		return 0
+	}
+	end := s.EndTime
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return end.Sub(s.StartTime)
+}
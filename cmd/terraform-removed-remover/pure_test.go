@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPureConflicts(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.Bool("dry-run", false, "")
+	flags.Bool("blame", false, "")
+	flags.String("jira-project", "", "")
+	flags.String("state-dir", "", "")
+
+	if err := flags.Parse([]string{"-state-dir", ".trr-state", "-dry-run", "-blame"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	expected := []string{"blame", "state-dir"}
+	if got := pureConflicts(flags); !slices.Equal(got, expected) {
+		t.Errorf("Expected %v, but got %v", expected, got)
+	}
+}
+
+func TestCheckPure(t *testing.T) {
+	for _, tt := range []struct {
+		subcommand string
+		args       []string
+		expected   string
+	}{
+		{args: []string{"-pure", "-dry-run"}},
+		{args: []string{"-dry-run", "-blame"}},
+		{args: []string{"-pure", "-blame"}, expected: "-pure cannot be combined with -blame"},
+		{args: []string{"-pure", "-allow-outside-root"}, expected: "-pure cannot be combined with -allow-outside-root"},
+		{subcommand: "serve", args: []string{"-pure"}, expected: "-pure cannot be used with the serve subcommand"},
+		{subcommand: "self-update", args: []string{"-pure"}, expected: "-pure cannot be used with the self-update subcommand"},
+		{subcommand: "fmt", args: []string{"-pure"}},
+	} {
+		t.Run(strings.Join(append([]string{tt.subcommand}, tt.args...), " "), func(t *testing.T) {
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.SetOutput(io.Discard)
+			f := newCLIFlags(flags)
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			err := f.checkPure(tt.subcommand, flags)
+			switch {
+			case tt.expected == "" && err != nil:
+				t.Errorf("Expected no error, but got %v", err)
+			case tt.expected != "" && (err == nil || err.Error() != tt.expected):
+				t.Errorf("Expected error %q, but got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestPureSkipsParentConfig(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-pure-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	nested := filepath.Join(tempDir, "modules", "vpc")
+	if err := os.MkdirAll(nested, 0750); err != nil {
+		t.Fatalf("Failed to create dirs: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, configFileName), []byte("dry-run = true\n"), 0600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	if found, err := findConfigFile(nested, false); err != nil || found != "" {
+		t.Errorf("Expected no config file without parents, but got %q, %v", found, err)
+	}
+	if found, err := findConfigFile(tempDir, false); err != nil || found != filepath.Join(tempDir, configFileName) {
+		t.Errorf("Expected the config file in the directory itself, but got %q, %v", found, err)
+	}
+}
+
+func TestPureRunIsDeterministic(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-pure-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	testFile := filepath.Join(tempDir, "main.tf")
+	content := "resource \"aws_instance\" \"web\" {}\n\nremoved {\n  from = aws_instance.old\n}\n"
+
+	manifest := filepath.Join(tempDir, backupManifestFile)
+
+	run := func(start time.Time) ([]byte, []byte) {
+		if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+
+		stats := Stats{StartTime: start, Pure: true, Capabilities: &Capabilities{}, Backup: ".orig"}
+		if err := processFile(testFile, &stats); err != nil {
+			t.Fatalf("processFile failed: %v", err)
+		}
+		stats.EndTime = time.Now()
+		if err := writeBackupManifest(manifest, stats.Backups, stats.now()); err != nil {
+			t.Fatalf("writeBackupManifest failed: %v", err)
+		}
+
+		var report bytes.Buffer
+		if err := writeJSONReport(&report, &stats); err != nil {
+			t.Fatalf("writeJSONReport failed: %v", err)
+		}
+		printSummary(&report, &stats)
+
+		var output []byte
+		for _, path := range []string{testFile, testFile + ".orig", manifest} {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read %s: %v", path, err)
+			}
+			output = append(output, data...)
+		}
+		return report.Bytes(), output
+	}
+
+	firstReport, firstOutput := run(time.Now().Add(-time.Hour))
+	secondReport, secondOutput := run(time.Now())
+
+	if !bytes.Equal(firstReport, secondReport) {
+		t.Errorf("Expected identical reports, but got:\n%s\nand:\n%s", firstReport, secondReport)
+	}
+	if !bytes.Equal(firstOutput, secondOutput) {
+		t.Errorf("Expected identical output, but got %q and %q", firstOutput, secondOutput)
+	}
+	if !bytes.Contains(firstReport, []byte(`"git": false`)) {
+		t.Errorf("Expected no capabilities to be reported, but got:\n%s", firstReport)
+	}
+}
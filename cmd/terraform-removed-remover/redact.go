@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// defaultRedaction replaces matches of rules without an explicit replacement.
+const defaultRedaction = "[REDACTED]"
+
+// Redactor rewrites sensitive parts of addresses and messages before they
+// appear in a report. A nil Redactor leaves everything unchanged.
+type Redactor struct {
+	rules []redactionRule
+}
+
+type redactionRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// redactionConfig is the file format read by -redact-config.
+type redactionConfig struct {
+	Rules []struct {
+		Pattern     string  `json:"pattern"`
+		Replacement *string `json:"replacement"`
+	} `json:"rules"`
+}
+
+func loadRedactor(path string) (*Redactor, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading redaction config %s: %w", path, err)
+	}
+
+	var config redactionConfig
+	if err := json.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("error parsing redaction config %s: %w", path, err)
+	}
+
+	redactor := &Redactor{}
+	for i, rule := range config.Rules {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern in rule %d of %s: %w", i+1, path, err)
+		}
+		replacement := defaultRedaction
+		if rule.Replacement != nil {
+			replacement = *rule.Replacement
+		}
+		redactor.rules = append(redactor.rules, redactionRule{pattern: pattern, replacement: replacement})
+	}
+
+	return redactor, nil
+}
+
+func (r *Redactor) redact(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, rule := range r.rules {
+		s = rule.pattern.ReplaceAllString(s, rule.replacement)
+	}
+	return s
+}
+
+func (r *Redactor) redactFindings(findings []Finding) []Finding {
+	if r == nil {
+		return findings
+	}
+	redacted := make([]Finding, len(findings))
+	for i, finding := range findings {
+		finding.Address = r.redact(finding.Address)
+		redacted[i] = finding
+	}
+	return redacted
+}
+
+// redactStats returns a copy of stats suitable for reporting. The original is
+// left untouched so baselines and other inputs keep the real addresses.
+func (r *Redactor) redactStats(stats *Stats) *Stats {
+	if r == nil {
+		return stats
+	}
+
+	redacted := *stats
+	redacted.Findings = r.redactFindings(stats.Findings)
+	redacted.IgnoredFindings = r.redactFindings(stats.IgnoredFindings)
+
+	redacted.NestedFindings = make([]NestedFinding, len(stats.NestedFindings))
+	for i, finding := range stats.NestedFindings {
+		finding.Address = r.redact(finding.Address)
+		redacted.NestedFindings[i] = finding
+	}
+
+	redacted.Warnings = make([]string, len(stats.Warnings))
+	for i, warning := range stats.Warnings {
+		redacted.Warnings[i] = r.redact(warning)
+	}
+
+	return &redacted
+}
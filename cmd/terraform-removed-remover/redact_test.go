@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-redact-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	configPath := filepath.Join(tempDir, "redact.json")
+	config := `{
+  "rules": [
+    {"pattern": "[0-9]{12}", "replacement": "<account>"},
+    {"pattern": "acme-[a-z]+"}
+  ]
+}`
+	if err := os.WriteFile(configPath, []byte(config), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	redactor, err := loadRedactor(configPath)
+	if err != nil {
+		t.Fatalf("loadRedactor failed: %v", err)
+	}
+
+	stats := Stats{
+		Findings:       []Finding{{File: "main.tf", Address: "aws_s3_bucket.acme-payroll_123456789012", Line: 1}},
+		NestedFindings: []NestedFinding{{Finding: Finding{File: "main.tf", Address: "aws_iam_role.acme-audit", Line: 9}, Column: 3, Parent: "resource"}},
+		Warnings:       []string{"main.tf:1: keeping aws_s3_bucket.acme-payroll_123456789012"},
+	}
+
+	redacted := redactor.redactStats(&stats)
+	if got := redacted.Findings[0].Address; got != "aws_s3_bucket.[REDACTED]_<account>" {
+		t.Errorf("Unexpected redacted address %q", got)
+	}
+	if got := redacted.NestedFindings[0].Address; got != "aws_iam_role.[REDACTED]" {
+		t.Errorf("Unexpected redacted nested address %q", got)
+	}
+	if strings.Contains(redacted.Warnings[0], "123456789012") {
+		t.Errorf("Warning was not redacted: %q", redacted.Warnings[0])
+	}
+	if stats.Findings[0].Address != "aws_s3_bucket.acme-payroll_123456789012" {
+		t.Errorf("redactStats modified the original stats")
+	}
+
+	var buf bytes.Buffer
+	if err := writeJSONReport(&buf, redacted); err != nil {
+		t.Fatalf("writeJSONReport failed: %v", err)
+	}
+	if strings.Contains(buf.String(), "acme-") || strings.Contains(buf.String(), "123456789012") {
+		t.Errorf("JSON report leaks redacted values:\n%s", buf.String())
+	}
+}
+
+func TestNilRedactor(t *testing.T) {
+	var redactor *Redactor
+	stats := Stats{Findings: []Finding{{Address: "aws_instance.old"}}}
+
+	if redactor.redactStats(&stats) != &stats {
+		t.Errorf("Expected nil redactor to return stats unchanged")
+	}
+	if redactor.redact("secret") != "secret" {
+		t.Errorf("Expected nil redactor to leave strings unchanged")
+	}
+}
+
+func TestLoadRedactorErrors(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-redact-error-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	invalid := filepath.Join(tempDir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{"rules": [{"pattern": "("}]}`), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := loadRedactor(invalid); err == nil {
+		t.Errorf("Expected error for invalid pattern, but got nil")
+	}
+	if _, err := loadRedactor(filepath.Join(tempDir, "missing.json")); err == nil {
+		t.Errorf("Expected error for missing config, but got nil")
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// defaultRepoBranch is the branch -repo pushes when -git-branch doesn't name
+// one.
+const defaultRepoBranch = "cleanup/removed-blocks"
+
+// clonedRepo is a repository given with -repo and the temporary directory
+// it was cloned into.
+type clonedRepo struct {
+	url string
+	dir string
+}
+
+// cloneRepository clones url into a new temporary directory: shallowly,
+// unless fullHistory asks for the history git blame needs. The directory's
+// symlinks are resolved, so the files found in it can be matched against
+// it.
+func cloneRepository(url string, fullHistory bool) (*clonedRepo, error) {
+	dir, err := os.MkdirTemp("", "terraform-removed-remover-repo-")
+	if err != nil {
+		return nil, fmt.Errorf("error cloning %s: %w", url, err)
+	}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	args := []string{"clone", "-q"}
+	if !fullHistory {
+		args = append(args, "--depth", "1")
+	}
+	if _, err := gitOutput(dir, append(args, "--", url, ".")...); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("error cloning %s: %w", url, err)
+	}
+	return &clonedRepo{url: url, dir: dir}, nil
+}
+
+// needsHistory reports whether the options look at the commits that
+// introduced blocks, which a shallow clone would all attribute to its one
+// commit.
+func (s *Stats) needsHistory() bool {
+	return s.OlderThan > 0 || s.Blame || s.Owners
+}
+
+// repoRoots returns the paths to process in each clone: the path arguments,
+// relative to the top of the repository, or the whole repository.
+func repoRoots(clones []*clonedRepo, paths []string) []string {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	var roots []string
+	for _, clone := range clones {
+		for _, path := range paths {
+			roots = append(roots, filepath.Join(clone.dir, path))
+		}
+	}
+	return roots
+}
+
+// written returns the files in files that are inside the clone.
+func (r *clonedRepo) written(files []string) []string {
+	var inside []string
+	for _, file := range files {
+		if isWithin(r.dir, resolvedPath(file)) {
+			inside = append(inside, file)
+		}
+	}
+	return inside
+}
+
+// stats returns the results of the run for the clone, from the files
+// written in it and the blocks found, with paths relative to the top of the
+// repository, for the pull request body.
+func (r *clonedRepo) stats(files []string, findings []Finding) *Stats {
+	stats := &Stats{FilesModified: len(files)}
+	for _, finding := range findings {
+		rel, err := filepath.Rel(r.dir, resolvedPath(finding.File))
+		if err != nil || !isWithin(r.dir, resolvedPath(finding.File)) {
+			continue
+		}
+		finding.File = filepath.ToSlash(rel)
+		stats.Findings = append(stats.Findings, finding)
+	}
+	stats.RemovedBlocksRemoved = len(stats.Findings)
+	return stats
+}
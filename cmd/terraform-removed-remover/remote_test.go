@@ -0,0 +1,174 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRepoRoots(t *testing.T) {
+	clones := []*clonedRepo{{url: "a", dir: "/tmp/a"}, {url: "b", dir: "/tmp/b"}}
+	expected := []string{"/tmp/a", "/tmp/b"}
+	if actual := repoRoots(clones, nil); !slices.Equal(actual, expected) {
+		t.Errorf("Expected %v, but got %v", expected, actual)
+	}
+	expected = []string{"/tmp/a/envs", "/tmp/a/modules", "/tmp/b/envs", "/tmp/b/modules"}
+	if actual := repoRoots(clones, []string{"envs", "modules"}); !slices.Equal(actual, expected) {
+		t.Errorf("Expected %v, but got %v", expected, actual)
+	}
+}
+
+func TestCloneRepositoryAndPublish(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-repo-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	source := filepath.Join(tempDir, "source")
+	if err := os.MkdirAll(filepath.Join(source, "envs"), 0750); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	initGitRepo(t, source)
+	if err := os.WriteFile(filepath.Join(source, "envs", "main.tf"), []byte("removed {\n  from = aws_instance.old\n}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	runGit(t, source, "add", ".")
+	runGit(t, source, "commit", "-q", "-m", "initial")
+	origin := filepath.Join(tempDir, "origin.git")
+	runGit(t, tempDir, "clone", "-q", "--bare", source, origin)
+
+	// The clone has no identity of its own
+	for _, name := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(name, "Test User")
+	}
+	for _, name := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(name, "test@example.com")
+	}
+
+	clone, err := cloneRepository("file://"+filepath.ToSlash(origin), false)
+	if err != nil {
+		t.Fatalf("cloneRepository failed: %v", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(clone.dir)
+	}()
+
+	stats := &Stats{}
+	testFile := filepath.Join(repoRoots([]*clonedRepo{clone}, []string{"envs"})[0], "main.tf")
+	if err := processFile(testFile, stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	if written := clone.written(append(stats.Written, filepath.Join(tempDir, "other.tf"))); !slices.Equal(written, []string{testFile}) {
+		t.Errorf("Expected only %s to be written in the clone, but got %v", testFile, written)
+	}
+	repoStats := clone.stats(stats.Written, stats.Findings)
+	if len(repoStats.Findings) != 1 || repoStats.Findings[0].File != "envs/main.tf" {
+		t.Errorf("Expected the finding relative to the clone, but got %+v", repoStats.Findings)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if _, err := publishChanges(stats.Written, publishOptions{branch: defaultRepoBranch}, logger); err != nil {
+		t.Fatalf("publishChanges failed: %v", err)
+	}
+	output, err := exec.Command("git", "-C", origin, "show", defaultRepoBranch+":envs/main.tf").Output()
+	if err != nil {
+		t.Fatalf("Expected the branch to be pushed: %v", err)
+	}
+	if len(output) != 0 {
+		t.Errorf("Expected the removed block to be gone on the branch, but got %q", string(output))
+	}
+}
+
+// newOriginRepo makes a bare repository in dir with two commits of
+// envs/main.tf, for -repo to clone.
+func newOriginRepo(t *testing.T, dir string) string {
+	t.Helper()
+	source := filepath.Join(dir, "source")
+	if err := os.MkdirAll(filepath.Join(source, "envs"), 0750); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	initGitRepo(t, source)
+	for _, content := range []string{"locals {}\n", "locals {}\n\nremoved {\n  from = aws_instance.old\n}\n"} {
+		if err := os.WriteFile(filepath.Join(source, "envs", "main.tf"), []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+		runGit(t, source, "add", ".")
+		runGit(t, source, "commit", "-q", "-m", "update")
+	}
+	origin := filepath.Join(dir, "origin.git")
+	runGit(t, dir, "clone", "-q", "--bare", source, origin)
+	return "file://" + filepath.ToSlash(origin)
+}
+
+func TestCloneRepositoryHistory(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-repo-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+	url := newOriginRepo(t, tempDir)
+
+	for _, tt := range []struct {
+		fullHistory bool
+		expected    string
+	}{
+		{fullHistory: false, expected: "1"},
+		{fullHistory: true, expected: "2"},
+	} {
+		clone, err := cloneRepository(url, tt.fullHistory)
+		if err != nil {
+			t.Fatalf("cloneRepository failed: %v", err)
+		}
+		count, err := gitOutput(clone.dir, "rev-list", "--count", "HEAD")
+		_ = os.RemoveAll(clone.dir)
+		if err != nil {
+			t.Fatalf("git rev-list failed: %v", err)
+		}
+		if count != tt.expected {
+			t.Errorf("Expected %s commits with fullHistory %v, but got %s", tt.expected, tt.fullHistory, count)
+		}
+	}
+
+	if !(&Stats{Blame: true}).needsHistory() || (&Stats{Only: []string{"*"}}).needsHistory() {
+		t.Errorf("Expected only the blame options to need the history")
+	}
+}
+
+func TestRunRemovesClonesOnFailure(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-repo-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+	url := newOriginRepo(t, tempDir)
+	cloneParent := filepath.Join(tempDir, "tmp")
+	if err := os.Mkdir(cloneParent, 0750); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	t.Setenv("TMPDIR", cloneParent)
+
+	// The path argument is checked only once the repository is cloned
+	if status := run([]string{"-no-config", "-log-level", "error", "-repo", url, "missing"}); status != exitUsage {
+		t.Errorf("Expected exit status %d, but got %d", exitUsage, status)
+	}
+	if left, _ := filepath.Glob(filepath.Join(cloneParent, "*")); len(left) > 0 {
+		t.Errorf("Expected the clone to be removed, but found %v", left)
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ReportSchemaVersion is the major version of the JSON report schema in
+// report.schema.json. It only changes when a field is removed, renamed, or
+// changes type; new fields may be added without a bump.
+const ReportSchemaVersion = 1
+
+// Report is the machine-readable summary written by -output json.
+type Report struct {
+	SchemaVersion        int           `json:"schema_version"`
+	ToolVersion          string        `json:"tool_version"`
+	DryRun               bool          `json:"dry_run"`
+	FilesProcessed       int           `json:"files_processed"`
+	FilesModified        int           `json:"files_modified"`
+	FilesSkipped         int           `json:"files_skipped"`
+	FilesIgnored         int           `json:"files_ignored"`
+	FilesNotUTF8         int           `json:"files_not_utf8"`
+	RemovedBlocksRemoved int           `json:"removed_blocks_removed"`
+	RemovedBlocksSkipped int           `json:"removed_blocks_skipped"`
+	DurationMillis       int64         `json:"duration_ms"`
+	Blocks               []ReportBlock `json:"blocks"`
+	IgnoredBlocks        []ReportBlock `json:"ignored_blocks"`
+	NestedBlocks         []NestedBlock `json:"nested_blocks"`
+	// ExpiringBlocks lists the blocks -expiring found near their
+	// remove-after date.
+	ExpiringBlocks []ExpiringBlock `json:"expiring_blocks,omitempty"`
+	Warnings       []string        `json:"warnings"`
+	// Errors lists the files that could not be processed.
+	Errors []ReportError `json:"errors,omitempty"`
+	// Capabilities lists the optional dependencies that were available.
+	Capabilities *Capabilities `json:"capabilities,omitempty"`
+}
+
+// NestedBlock describes a removed block nested inside another block, which
+// is invalid and never removed.
+type NestedBlock struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Address string `json:"address"`
+	Parent  string `json:"parent"`
+}
+
+// ExpiringBlock describes a removed block kept until its remove-after date.
+type ExpiringBlock struct {
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+	Address     string `json:"address"`
+	RemoveAfter string `json:"remove_after"`
+}
+
+// ReportError describes a file that could not be processed.
+type ReportError struct {
+	File    string `json:"file"`
+	Message string `json:"message"`
+}
+
+// ReportBlock describes a single removed block in a Report.
+type ReportBlock struct {
+	File    string        `json:"file"`
+	Line    int           `json:"line"`
+	Address string        `json:"address"`
+	Kind    string        `json:"kind"`
+	Commit  *ReportCommit `json:"commit,omitempty"`
+	Owner   *ReportOwner  `json:"owner,omitempty"`
+}
+
+// ReportCommit identifies the commit that introduced a block, as reported by
+// git blame. It is only present with -blame.
+type ReportCommit struct {
+	SHA    string `json:"sha"`
+	Author string `json:"author"`
+	Date   string `json:"date"`
+}
+
+// ReportOwner identifies the most recent commit touching a block and who
+// committed it, as an assignee hint. It is only present with -owners.
+type ReportOwner struct {
+	SHA       string `json:"sha"`
+	Committer string `json:"committer"`
+	Email     string `json:"email"`
+	Date      string `json:"date"`
+}
+
+func newReport(stats *Stats) *Report {
+	return &Report{
+		SchemaVersion:        ReportSchemaVersion,
+		ToolVersion:          Version,
+		DryRun:               stats.DryRun,
+		FilesProcessed:       stats.FilesProcessed,
+		FilesModified:        stats.FilesModified,
+		FilesSkipped:         stats.FilesSkipped,
+		FilesIgnored:         stats.FilesIgnored,
+		FilesNotUTF8:         stats.FilesNotUTF8,
+		RemovedBlocksRemoved: stats.RemovedBlocksRemoved,
+		RemovedBlocksSkipped: stats.RemovedBlocksSkipped,
+		DurationMillis:       stats.duration().Milliseconds(),
+		Blocks:               reportBlocks(stats.Findings),
+		IgnoredBlocks:        reportBlocks(stats.IgnoredFindings),
+		NestedBlocks:         nestedBlocks(stats.NestedFindings),
+		ExpiringBlocks:       expiringBlocks(stats.Expiring),
+		Warnings:             append([]string{}, stats.Warnings...),
+		Capabilities:         stats.Capabilities,
+		Errors:               reportErrors(stats.Errors),
+	}
+}
+
+func reportErrors(fileErrors []FileError) []ReportError {
+	var reported []ReportError
+	for _, fileError := range fileErrors {
+		reported = append(reported, ReportError(fileError))
+	}
+	return reported
+}
+
+func expiringBlocks(findings []ExpiringFinding) []ExpiringBlock {
+	var blocks []ExpiringBlock
+	for _, finding := range findings {
+		blocks = append(blocks, ExpiringBlock{File: finding.File, Line: finding.Line, Address: finding.Address, RemoveAfter: finding.RemoveAfter})
+	}
+	return blocks
+}
+
+func nestedBlocks(findings []NestedFinding) []NestedBlock {
+	blocks := make([]NestedBlock, 0, len(findings))
+	for _, finding := range findings {
+		blocks = append(blocks, NestedBlock{
+			File:    finding.File,
+			Line:    finding.Line,
+			Column:  finding.Column,
+			Address: finding.Address,
+			Parent:  finding.Parent,
+		})
+	}
+	return blocks
+}
+
+func reportBlocks(findings []Finding) []ReportBlock {
+	blocks := make([]ReportBlock, 0, len(findings))
+	for _, finding := range findings {
+		block := ReportBlock{File: finding.File, Line: finding.Line, Address: finding.Address, Kind: addressKind(finding.Address)}
+		if finding.Blame != nil {
+			block.Commit = &ReportCommit{
+				SHA:    finding.Blame.Commit,
+				Author: finding.Blame.Author,
+				Date:   finding.Blame.Time.Format(time.RFC3339),
+			}
+		}
+		if finding.Owner != nil {
+			block.Owner = &ReportOwner{
+				SHA:       finding.Owner.Commit,
+				Committer: finding.Owner.Committer,
+				Email:     finding.Owner.CommitterEmail,
+				Date:      finding.Owner.CommitTime.Format(time.RFC3339),
+			}
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+func writeJSONReport(w io.Writer, stats *Stats) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(newReport(stats)); err != nil {
+		return fmt.Errorf("error writing JSON report: %w", err)
+	}
+	return nil
+}
+
+func printSummary(w io.Writer, stats *Stats) {
+	duration := stats.duration()
+
+	fmt.Fprintf(w, "\nStatistics:\n")
+	if stats.DryRun {
+		fmt.Fprintln(w, "DRY RUN MODE: No files were modified")
+	}
+	fmt.Fprintf(w, "Files processed: %d\n", stats.FilesProcessed)
+	fmt.Fprintf(w, "Files modified: %d\n", stats.FilesModified)
+	if stats.FilesSkipped > 0 {
+		fmt.Fprintf(w, "Files skipped (invalid): %d\n", stats.FilesSkipped)
+	}
+	if stats.FilesIgnored > 0 {
+		fmt.Fprintf(w, "Files ignored (directive): %d\n", stats.FilesIgnored)
+	}
+	if stats.FilesNotUTF8 > 0 {
+		fmt.Fprintf(w, "Files skipped (not UTF-8): %d\n", stats.FilesNotUTF8)
+	}
+	if stats.Consolidate {
+		fmt.Fprintf(w, "Removed blocks consolidated: %d\n", stats.RemovedBlocksRemoved)
+	} else {
+		fmt.Fprintf(w, "Removed blocks removed: %d\n", stats.RemovedBlocksRemoved)
+	}
+	if stats.RemovedBlocksSkipped > 0 {
+		fmt.Fprintf(w, "Removed blocks skipped: %d\n", stats.RemovedBlocksSkipped)
+	}
+	fmt.Fprintf(w, "Processing time: %v\n", duration)
+
+	if len(stats.NestedFindings) > 0 {
+		fmt.Fprintf(w, "\nNested removed blocks (invalid, not removed): %d\n", len(stats.NestedFindings))
+		for _, finding := range stats.NestedFindings {
+			fmt.Fprintf(w, "%s:%d:%d: removed block for %s inside a %s block\n", finding.File, finding.Line, finding.Column, finding.Address, finding.Parent)
+		}
+	}
+
+	if len(stats.Expiring) > 0 {
+		fmt.Fprintf(w, "\nRemoved blocks expiring soon (kept until their remove-after date): %d\n", len(stats.Expiring))
+		for _, finding := range stats.Expiring {
+			fmt.Fprintf(w, "%s:%d: %s after %s\n", finding.File, finding.Line, finding.Address, finding.RemoveAfter)
+		}
+	}
+
+	if len(stats.Warnings) > 0 {
+		fmt.Fprintf(w, "\n%s\n", colorize(stats.Color, ansiYellow, "Warnings:"))
+		for _, warning := range stats.Warnings {
+			fmt.Fprintln(w, colorize(stats.Color, ansiYellow, warning))
+		}
+	}
+
+	if len(stats.Errors) > 0 {
+		fmt.Fprintf(w, "\n%s\n", colorize(stats.Color, ansiRed, fmt.Sprintf("Errors (%d files could not be processed):", len(stats.Errors))))
+		for _, fileError := range stats.Errors {
+			fmt.Fprintln(w, colorize(stats.Color, ansiRed, fileError.Message))
+		}
+	}
+
+	if len(stats.IgnoredFindings) > 0 {
+		fmt.Fprintf(w, "\nRemoved blocks in files ignored by Terraform (not modified): %d\n", len(stats.IgnoredFindings))
+		printFindings(w, stats.IgnoredFindings)
+	}
+}
+
+// formatFinding renders a finding as a single file:line line, followed by the
+// introducing commit when it is known.
+func formatFinding(finding Finding) string {
+	line := fmt.Sprintf("%s:%d: removed block for %s", finding.File, finding.Line, finding.Address)
+	if finding.Blame != nil {
+		line += fmt.Sprintf(" (added in %.12s by %s on %s)", finding.Blame.Commit, finding.Blame.Author, finding.Blame.Time.Format("2006-01-02"))
+	}
+	return line
+}
+
+func printFindings(w io.Writer, findings []Finding) {
+	for _, finding := range findings {
+		fmt.Fprintln(w, formatFinding(finding))
+	}
+}
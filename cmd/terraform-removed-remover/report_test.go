@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// validateSchema checks value against the subset of JSON Schema used by
+// report.schema.json.
+func validateSchema(root, schema map[string]interface{}, value interface{}, path string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/$defs/")
+		defs, _ := root["$defs"].(map[string]interface{})
+		def, _ := defs[name].(map[string]interface{})
+		return validateSchema(root, def, value, path)
+	}
+
+	var errs []string
+	if expected, ok := schema["const"]; ok && fmt.Sprint(expected) != fmt.Sprint(value) {
+		errs = append(errs, fmt.Sprintf("%s: expected %v, got %v", path, expected, value))
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return append(errs, fmt.Sprintf("%s: expected object", path))
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := object[name.(string)]; !ok {
+				errs = append(errs, fmt.Sprintf("%s: missing required property %q", path, name))
+			}
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			propSchema, ok := properties[key].(map[string]interface{})
+			if !ok {
+				if schema["additionalProperties"] == false {
+					errs = append(errs, fmt.Sprintf("%s: unexpected property %q", path, key))
+				}
+				continue
+			}
+			errs = append(errs, validateSchema(root, propSchema, object[key], path+"."+key)...)
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return append(errs, fmt.Sprintf("%s: expected array", path))
+		}
+		items, _ := schema["items"].(map[string]interface{})
+		for i, item := range array {
+			errs = append(errs, validateSchema(root, items, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			errs = append(errs, fmt.Sprintf("%s: expected string", path))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			errs = append(errs, fmt.Sprintf("%s: expected boolean", path))
+		}
+	case "integer":
+		number, ok := value.(float64)
+		if !ok || number != float64(int64(number)) {
+			errs = append(errs, fmt.Sprintf("%s: expected integer", path))
+		} else if minimum, ok := schema["minimum"].(float64); ok && number < minimum {
+			errs = append(errs, fmt.Sprintf("%s: %v is below minimum %v", path, number, minimum))
+		}
+	}
+
+	return errs
+}
+
+func loadReportSchema(t *testing.T) map[string]interface{} {
+	t.Helper()
+
+	content, err := os.ReadFile("report.schema.json")
+	if err != nil {
+		t.Fatalf("Failed to read schema: %v", err)
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(content, &schema); err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	return schema
+}
+
+func TestJSONReportMatchesSchema(t *testing.T) {
+	schema := loadReportSchema(t)
+
+	start := time.Now()
+	tests := []struct {
+		name  string
+		stats Stats
+	}{
+		{name: "empty", stats: Stats{StartTime: start, EndTime: start}},
+		{
+			name: "with blocks",
+			stats: Stats{
+				StartTime:            start,
+				EndTime:              start.Add(42 * time.Millisecond),
+				DryRun:               true,
+				FilesProcessed:       3,
+				FilesModified:        1,
+				RemovedBlocksRemoved: 2,
+				RemovedBlocksSkipped: 1,
+				Findings: []Finding{
+					{File: "main.tf", Address: "aws_instance.old", Line: 4},
+					{File: "main.tf", Address: "module.legacy", Line: 12, Blame: &BlameInfo{Commit: "4e1f0c5a8d6b1f4b0d0c9a6b2f3e4d5c6b7a8f90", Author: "Jane Doe", Time: start}, Owner: &BlameInfo{Commit: "9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a0b", Committer: "John Roe", CommitterEmail: "john@example.com", CommitTime: start}},
+				},
+				IgnoredFindings: []Finding{
+					{File: ".terraform/modules/vpc/main.tf", Address: "aws_vpc.old", Line: 1},
+				},
+				NestedFindings: []NestedFinding{
+					{Finding: Finding{File: "main.tf", Address: "aws_instance.bad", Line: 20}, Column: 3, Parent: "resource"},
+				},
+				Warnings:     []string{"main.tf:4: keeping aws_instance.old, could not determine its age"},
+				Capabilities: &Capabilities{Git: true, Network: true},
+				Errors:       []FileError{{File: "broken.tf", Message: "error parsing broken.tf: Unclosed configuration block"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeJSONReport(&buf, &tt.stats); err != nil {
+				t.Fatalf("writeJSONReport failed: %v", err)
+			}
+
+			var report interface{}
+			if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+				t.Fatalf("Report is not valid JSON: %v\n%s", err, buf.String())
+			}
+
+			for _, validationErr := range validateSchema(schema, schema, report, "$") {
+				t.Errorf("Schema violation: %s", validationErr)
+			}
+		})
+	}
+}
+
+func TestReportSchemaVersionMatchesSchema(t *testing.T) {
+	schema := loadReportSchema(t)
+
+	properties := schema["properties"].(map[string]interface{})
+	version := properties["schema_version"].(map[string]interface{})
+	if version["const"] != float64(ReportSchemaVersion) {
+		t.Errorf("report.schema.json pins schema_version %v, but ReportSchemaVersion is %d", version["const"], ReportSchemaVersion)
+	}
+}
+
+func TestFormatFinding(t *testing.T) {
+	finding := Finding{File: "main.tf", Address: "aws_instance.old", Line: 7}
+	if got := formatFinding(finding); got != "main.tf:7: removed block for aws_instance.old" {
+		t.Errorf("Unexpected formatting %q", got)
+	}
+
+	finding.Blame = &BlameInfo{
+		Commit: "4e1f0c5a8d6b1f4b0d0c9a6b2f3e4d5c6b7a8f90",
+		Author: "Jane Doe",
+		Time:   time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+	expected := "main.tf:7: removed block for aws_instance.old (added in 4e1f0c5a8d6b by Jane Doe on 2024-06-01)"
+	if got := formatFinding(finding); got != expected {
+		t.Errorf("formatFinding() = %q, expected %q", got, expected)
+	}
+}
+
+func TestSummaryListsErrors(t *testing.T) {
+	var out bytes.Buffer
+	printSummary(&out, &Stats{Errors: []FileError{
+		{File: "a.tf", Message: "error parsing a.tf: Unclosed configuration block"},
+		{File: "b.tf", Message: "error writing file b.tf: permission denied"},
+	}})
+
+	expected := "\nErrors (2 files could not be processed):\nerror parsing a.tf: Unclosed configuration block\nerror writing file b.tf: permission denied\n"
+	if !strings.Contains(out.String(), expected) {
+		t.Errorf("Expected the summary to contain %q, but got:\n%s", expected, out.String())
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+)
+
+// blockSelection restricts removal to blocks chosen one by one, in the TUI
+// or by an edit plan, instead of by the address filters. Files are keyed by
+// their cleaned path.
+type blockSelection struct {
+	files map[string]*fileSelection
+}
+
+// fileSelection holds the choices for one file.
+type fileSelection struct {
+	choices []*blockChoice
+}
+
+// blockChoice picks the block with address, or else the blocks starting
+// between startLine and endLine. label names where it came from in warnings
+// about choices that matched nothing.
+type blockChoice struct {
+	address            string
+	startLine, endLine int
+	label              string
+	matched            bool
+}
+
+func (c *blockChoice) matches(block removedBlock) bool {
+	if c.address != "" {
+		return block.Address == c.address
+	}
+	return block.Line >= c.startLine && block.Line <= c.endLine
+}
+
+func newBlockSelection() *blockSelection {
+	return &blockSelection{files: map[string]*fileSelection{}}
+}
+
+func (s *blockSelection) add(filePath string, choice *blockChoice) {
+	key := filepath.Clean(filePath)
+	selected, ok := s.files[key]
+	if !ok {
+		selected = &fileSelection{}
+		s.files[key] = selected
+	}
+	selected.choices = append(selected.choices, choice)
+}
+
+// addLine selects the block starting on line of filePath.
+func (s *blockSelection) addLine(filePath string, line int) {
+	s.add(filePath, &blockChoice{startLine: line, endLine: line})
+}
+
+// selects reports whether block, in filePath, was chosen.
+func (s *blockSelection) selects(filePath string, block removedBlock) bool {
+	selected, ok := s.files[filepath.Clean(filePath)]
+	if !ok {
+		return false
+	}
+	found := false
+	for _, choice := range selected.choices {
+		if choice.matches(block) {
+			choice.matched = true
+			found = true
+		}
+	}
+	return found
+}
+
+// paths returns the files with chosen blocks, sorted.
+func (s *blockSelection) paths() []string {
+	paths := make([]string, 0, len(s.files))
+	for path := range s.files {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// unmatched returns a warning for each choice that matched no removed block
+// in its file.
+func (s *blockSelection) unmatched() []string {
+	var warnings []string
+	for _, path := range s.paths() {
+		for _, choice := range s.files[path].choices {
+			if choice.matched {
+				continue
+			}
+			if choice.address != "" {
+				warnings = append(warnings, fmt.Sprintf("%s: no removed block for %s in %s", choice.label, choice.address, path))
+			} else {
+				warnings = append(warnings, fmt.Sprintf("%s: no removed block starts on lines %d-%d of %s", choice.label, choice.startLine, choice.endLine, path))
+			}
+		}
+	}
+	return warnings
+}
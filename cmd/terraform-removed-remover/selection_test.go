@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestProcessFileSelection(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-selection-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := `removed {
+  from = aws_instance.old
+}
+
+removed {
+  from = aws_instance.keep
+}
+`
+	testFile := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	selection := newBlockSelection()
+	selection.addLine(filepath.Join(tempDir, ".", "main.tf"), 1)
+	stats := &Stats{Selection: selection}
+	if err := processFile(testFile, stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+
+	data, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	expected := `
+removed {
+  from = aws_instance.keep
+}
+`
+	if string(data) != expected {
+		t.Errorf("Expected %q, but got %q", expected, string(data))
+	}
+	if stats.RemovedBlocksRemoved != 1 || stats.RemovedBlocksSkipped != 1 {
+		t.Errorf("Expected 1 block removed and 1 skipped, but got %d and %d", stats.RemovedBlocksRemoved, stats.RemovedBlocksSkipped)
+	}
+}
+
+func TestBlockSelectionPaths(t *testing.T) {
+	selection := newBlockSelection()
+	selection.addLine("modules/b/main.tf", 1)
+	selection.addLine("./modules/a/main.tf", 4)
+	selection.addLine("modules/a/main.tf", 9)
+
+	expected := []string{"modules/a/main.tf", "modules/b/main.tf"}
+	if actual := selection.paths(); !slices.Equal(actual, expected) {
+		t.Errorf("Expected %v, but got %v", expected, actual)
+	}
+	if !selection.selects("modules/a/main.tf", removedBlock{Line: 4}) {
+		t.Errorf("Expected line 4 of modules/a/main.tf to be selected")
+	}
+	if selection.selects("modules/a/main.tf", removedBlock{Line: 5}) {
+		t.Errorf("Expected line 5 of modules/a/main.tf not to be selected")
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// releaseRepository is where self-update looks for releases.
+const releaseRepository = "mkusaka/terraform-removed-remover"
+
+// checksumsAsset is the release asset listing the SHA-256 digest of every
+// binary, in the format of sha256sum.
+const checksumsAsset = "checksums.txt"
+
+// signatureAsset is the minisign signature of checksumsAsset.
+const signatureAsset = checksumsAsset + ".minisig"
+
+// releasePublicKey is the minisign public key releases are signed with.
+// Release builds set it with -ldflags "-X main.releasePublicKey=..."; other
+// builds can't self-update, since they have nothing to verify releases
+// against.
+var releasePublicKey = ""
+
+// githubRelease is the part of a GitHub release self-update reads.
+type githubRelease struct {
+	TagName string         `json:"tag_name"`
+	Assets  []releaseAsset `json:"assets"`
+}
+
+type releaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// asset returns the URL of the asset called name.
+func (r *githubRelease) asset(name string) (string, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset.URL, true
+		}
+	}
+	return "", false
+}
+
+// releaseAssetName is the name of the release binary for goos and goarch,
+// as the release workflow uploads it.
+func releaseAssetName(goos, goarch string) string {
+	name := "terraform-removed-remover_" + goos + "_" + goarch
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// runSelfUpdate replaces the binary at executable with the latest release
+// from apiURL when it is newer than current, after checking the release's
+// checksums against their signature by publicKey and the binary's digest
+// against the checksums. With checkOnly it only reports whether there is a
+// newer release. It returns the release's version when there is one, or ""
+// when current is up to date.
+func runSelfUpdate(client *forgeClient, apiURL, token, publicKey, executable, current string, checkOnly bool) (string, error) {
+	release, err := latestRelease(client, apiURL, token)
+	if err != nil {
+		return "", err
+	}
+	latest := strings.TrimPrefix(release.TagName, "v")
+	if !newerVersion(latest, current) {
+		return "", nil
+	}
+	if checkOnly {
+		return latest, nil
+	}
+	if publicKey == "" {
+		return "", errors.New("this build has no release signing key to verify updates with; download the release instead")
+	}
+	key, err := parseMinisignPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	name := releaseAssetName(runtime.GOOS, runtime.GOARCH)
+	binaryURL, ok := release.asset(name)
+	if !ok {
+		return "", fmt.Errorf("release %s has no binary for %s/%s", release.TagName, runtime.GOOS, runtime.GOARCH)
+	}
+	checksumsURL, ok := release.asset(checksumsAsset)
+	if !ok {
+		return "", fmt.Errorf("release %s has no %s to verify the binary with", release.TagName, checksumsAsset)
+	}
+	signatureURL, ok := release.asset(signatureAsset)
+	if !ok {
+		return "", fmt.Errorf("release %s has no %s to verify %s with", release.TagName, signatureAsset, checksumsAsset)
+	}
+	checksums, err := download(client, checksumsURL)
+	if err != nil {
+		return "", err
+	}
+	signature, err := download(client, signatureURL)
+	if err != nil {
+		return "", err
+	}
+	if err := key.verify(checksums, signature); err != nil {
+		return "", fmt.Errorf("%s of release %s: %w; not updating", checksumsAsset, release.TagName, err)
+	}
+	expected, ok := parseChecksums(checksums)[name]
+	if !ok {
+		return "", fmt.Errorf("%s of release %s has no digest for %s", checksumsAsset, release.TagName, name)
+	}
+	binary, err := download(client, binaryURL)
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256(binary)
+	if actual := hex.EncodeToString(digest[:]); actual != expected {
+		return "", fmt.Errorf("digest of %s is %s, but %s lists %s; not updating", name, actual, checksumsAsset, expected)
+	}
+
+	if err := replaceExecutable(executable, binary); err != nil {
+		return "", writeError(fmt.Errorf("error replacing %s: %w", executable, err))
+	}
+	return latest, nil
+}
+
+// latestRelease fetches the latest release of releaseRepository.
+func latestRelease(client *forgeClient, apiURL, token string) (*githubRelease, error) {
+	path := "/repos/" + releaseRepository + "/releases/latest"
+	req, err := http.NewRequest(http.MethodGet, apiURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("github GET %s: %w", path, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("github GET %s: %s: %s", path, resp.Status, strings.TrimSpace(string(message)))
+	}
+	var release githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("github GET %s: invalid response: %w", path, err)
+	}
+	return &release, nil
+}
+
+func download(client *forgeClient, url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading %s: %w", url, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error downloading %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading %s: %w", url, err)
+	}
+	return data, nil
+}
+
+// parseChecksums maps file names to digests in sha256sum output, whose
+// lines are a digest and a name, the name marked with "*" in binary mode.
+func parseChecksums(data []byte) map[string]string {
+	digests := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		digests[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return digests
+}
+
+// newerVersion reports whether latest is a later version than current,
+// comparing dotted numbers. A pre-release, like 1.2.0-rc.1, is never newer.
+func newerVersion(latest, current string) bool {
+	if strings.Contains(latest, "-") {
+		return false
+	}
+	parse := func(version string) []int {
+		version, _, _ = strings.Cut(version, "-")
+		var parts []int
+		for _, part := range strings.Split(version, ".") {
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return nil
+			}
+			parts = append(parts, n)
+		}
+		return parts
+	}
+	a, b := parse(latest), parse(current)
+	if a == nil {
+		return false
+	}
+	for i := 0; i < max(len(a), len(b)); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			return x > y
+		}
+	}
+	return false
+}
+
+// replaceExecutable writes binary over the executable at path, keeping its
+// permissions. The new binary is written next to it and renamed into
+// place, so a failed update leaves the old one working. Windows can't
+// replace a running executable, so there it is moved aside to path.old
+// first.
+func replaceExecutable(path string, binary []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".update-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(binary); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		if err := os.Remove(old); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if err := os.Rename(path, old); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestNewerVersion(t *testing.T) {
+	for _, tt := range []struct {
+		latest   string
+		current  string
+		expected bool
+	}{
+		{latest: "0.0.2", current: "0.0.1", expected: true},
+		{latest: "0.10.0", current: "0.9.9", expected: true},
+		{latest: "1.0", current: "0.9.9", expected: true},
+		{latest: "0.0.1", current: "0.0.1", expected: false},
+		{latest: "0.0.1", current: "0.1.0", expected: false},
+		{latest: "1.0.0-rc.1", current: "0.9.0", expected: false},
+		{latest: "nightly", current: "0.9.0", expected: false},
+	} {
+		if actual := newerVersion(tt.latest, tt.current); actual != tt.expected {
+			t.Errorf("newerVersion(%q, %q) = %v, expected %v", tt.latest, tt.current, actual, tt.expected)
+		}
+	}
+}
+
+func TestRunSelfUpdate(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-selfupdate-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	binary := []byte("new binary")
+	digest := sha256.Sum256(binary)
+	name := releaseAssetName(runtime.GOOS, runtime.GOARCH)
+	checksums := hex.EncodeToString(digest[:]) + "  " + name + "\n"
+	publicKey, private := newMinisignKey(t)
+	signature := ""
+	sign := func() {
+		signature = minisignSign(private, []byte(checksums), "terraform-removed-remover v9.0.0")
+	}
+	sign()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/" + releaseRepository + "/releases/latest":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"tag_name": "v9.0.0",
+				"assets": []map[string]string{
+					{"name": name, "browser_download_url": server.URL + "/download/" + name},
+					{"name": checksumsAsset, "browser_download_url": server.URL + "/download/" + checksumsAsset},
+					{"name": signatureAsset, "browser_download_url": server.URL + "/download/" + signatureAsset},
+				},
+			})
+		case "/download/" + name:
+			_, _ = w.Write(binary)
+		case "/download/" + checksumsAsset:
+			_, _ = w.Write([]byte(checksums))
+		case "/download/" + signatureAsset:
+			_, _ = w.Write([]byte(signature))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	executable := filepath.Join(tempDir, "terraform-removed-remover")
+	if err := os.WriteFile(executable, []byte("old binary"), 0700); err != nil {
+		t.Fatalf("Failed to write executable: %v", err)
+	}
+
+	// A release build of the latest tag is up to date
+	defer func(version string) { Version = version }(Version)
+	Version = "9.0.0"
+	latest, err := runSelfUpdate(newForgeClient(), server.URL, "", publicKey, executable, Version, false)
+	if err != nil || latest != "" {
+		t.Errorf("Expected no update for the latest version, but got %q, %v", latest, err)
+	}
+	if data, _ := os.ReadFile(executable); string(data) != "old binary" {
+		t.Errorf("Expected the latest version to leave the binary alone, but got %q", data)
+	}
+	Version = "0.0.1"
+
+	latest, err = runSelfUpdate(newForgeClient(), server.URL, "", publicKey, executable, Version, true)
+	if err != nil || latest != "9.0.0" {
+		t.Errorf("Expected 9.0.0 to be available, but got %q, %v", latest, err)
+	}
+	if data, _ := os.ReadFile(executable); string(data) != "old binary" {
+		t.Errorf("Expected a check to leave the binary alone, but got %q", data)
+	}
+
+	if _, err := runSelfUpdate(newForgeClient(), server.URL, "", "", executable, Version, false); err == nil || !strings.Contains(err.Error(), "no release signing key") {
+		t.Errorf("Expected a build without a key to refuse, but got %v", err)
+	}
+
+	// Checksums replaced after signing, as by a compromised release
+	checksums = hex.EncodeToString(digest[:]) + " *" + name + "\n"
+	if _, err := runSelfUpdate(newForgeClient(), server.URL, "", publicKey, executable, Version, false); err == nil || !strings.Contains(err.Error(), "signature does not match") {
+		t.Errorf("Expected a signature mismatch, but got %v", err)
+	}
+
+	checksums = strings.Repeat("0", 64) + "  " + name + "\n"
+	sign()
+	if _, err := runSelfUpdate(newForgeClient(), server.URL, "", publicKey, executable, Version, false); err == nil || !strings.Contains(err.Error(), "not updating") {
+		t.Errorf("Expected a digest mismatch, but got %v", err)
+	}
+	if data, _ := os.ReadFile(executable); string(data) != "old binary" {
+		t.Errorf("Expected a failed update to leave the binary alone, but got %q", data)
+	}
+
+	checksums = hex.EncodeToString(digest[:]) + " *" + name + "\n"
+	sign()
+	latest, err = runSelfUpdate(newForgeClient(), server.URL, "", publicKey, executable, Version, false)
+	if err != nil || latest != "9.0.0" {
+		t.Fatalf("Expected an update to 9.0.0, but got %q, %v", latest, err)
+	}
+	data, err := os.ReadFile(executable)
+	if err != nil || string(data) != string(binary) {
+		t.Errorf("Expected the new binary, but got %q, %v", data, err)
+	}
+	if info, err := os.Stat(executable); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("Expected the permissions kept, but got %v, %v", info.Mode(), err)
+	}
+	entries, _ := os.ReadDir(tempDir)
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary files left, but got %v", entries)
+	}
+}
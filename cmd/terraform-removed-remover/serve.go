@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"path/filepath"
+	"time"
+)
+
+// defaultServeAddr is where the serve subcommand listens without -listen.
+const defaultServeAddr = "localhost:8080"
+
+// defaultServeMaxBody caps request bodies when -max-file-size isn't set.
+const defaultServeMaxBody = 10 << 20
+
+// serveRejectedFlags are the flags the serve subcommand rejects, since
+// content sent over HTTP has no git history to read and nothing may be
+// written outside the response.
+var serveRejectedFlags = []string{"older-than", "blame", "owners", "backup", "stage-deletes", "archive", "audit-log"}
+
+// ProcessResponse is the response to POST /process: the cleaned file and
+// the report of processing it, in the -output json schema.
+type ProcessResponse struct {
+	Content string  `json:"content"`
+	Report  *Report `json:"report"`
+}
+
+// processServer is the serve subcommand's HTTP API. Each request is
+// processed on its own with the options the server was started with.
+type processServer struct {
+	base     Stats
+	redactor *Redactor
+	logger   *slog.Logger
+}
+
+// newProcessServer returns a server processing requests with the options
+// in base, as contentOptions leaves them.
+func newProcessServer(base Stats, redactor *Redactor, logger *slog.Logger) *processServer {
+	return &processServer{base: contentOptions(base), redactor: redactor, logger: logger}
+}
+
+func (s *processServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/process":
+		s.process(w, r)
+	case "/healthz":
+		_, _ = io.WriteString(w, "ok\n")
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// process handles POST /process. The body is the file to clean; the
+// filename query parameter, main.tf by default, names it, which decides
+// between HCL and JSON syntax and appears in the report.
+func (s *processServer) process(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSONError(w, http.StatusMethodNotAllowed, "use POST")
+		return
+	}
+	name, err := requestFilename(r.URL.Query().Get("filename"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit := s.maxBody()
+	content, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeJSONError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("the body exceeds %d bytes", limit))
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, stats, err := processContent(name, content, s.base)
+	if err != nil {
+		status := http.StatusInternalServerError
+		var parseErr *parseError
+		if errors.As(err, &parseErr) {
+			status = http.StatusUnprocessableEntity
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+	s.logger.Debug("Processed request", "filename", name, "removed", stats.RemovedBlocksRemoved)
+
+	w.Header().Set("Content-Type", "application/json")
+	response := ProcessResponse{Content: string(result), Report: newReport(s.redactor.redactStats(stats))}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		s.logger.Warn("Could not write response", "error", err)
+	}
+}
+
+// requestFilename returns the name a request gives its file, main.tf by
+// default. It must not have a directory.
+func requestFilename(name string) (string, error) {
+	if name == "" {
+		return "main.tf", nil
+	}
+	if name != filepath.Base(name) || name == "." || name == ".." {
+		return "", errors.New("filename must be a file name without a directory")
+	}
+	return name, nil
+}
+
+// maxBody returns the size of the largest file a request may send.
+func (s *processServer) maxBody() int64 {
+	if s.base.MaxFileSize <= 0 {
+		return defaultServeMaxBody
+	}
+	return s.base.MaxFileSize
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// serveAPIs serves the HTTP API on httpAddr, and the gRPC API on grpcAddr
+// if it is set, until ctx is done or either server fails.
+func serveAPIs(ctx context.Context, httpAddr, grpcAddr string, server *processServer, logger *slog.Logger) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, 2)
+	servers := 1
+	go func() {
+		errs <- runServer(ctx, httpAddr, server, logger)
+	}()
+	if grpcAddr != "" {
+		servers++
+		go func() {
+			errs <- runGRPCServer(ctx, grpcAddr, newGRPCServer(server), logger)
+		}()
+	}
+
+	var first error
+	for range servers {
+		if err := <-errs; err != nil && first == nil {
+			first = err
+			cancel()
+		}
+	}
+	return first
+}
+
+// runServer serves handler on addr until ctx is done, then waits for the
+// requests in progress.
+func runServer(ctx context.Context, addr string, handler http.Handler, logger *slog.Logger) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	logger.Info("Serving the HTTP API", "url", "http://"+listener.Addr().String()+"/process")
+
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve(listener)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+	shutdown, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	return server.Shutdown(shutdown)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProcessServer(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := httptest.NewServer(newProcessServer(Stats{Pure: true}, nil, logger))
+	defer server.Close()
+
+	body := "removed {\n  from = aws_instance.old\n}\n\nresource \"aws_instance\" \"web\" {}\n"
+	resp, err := http.Post(server.URL+"/process", "text/plain", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", resp.StatusCode)
+	}
+	var response ProcessResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	if expected := "\nresource \"aws_instance\" \"web\" {}\n"; response.Content != expected {
+		t.Errorf("Expected content %q, but got %q", expected, response.Content)
+	}
+	if response.Report.RemovedBlocksRemoved != 1 || len(response.Report.Blocks) != 1 || response.Report.Blocks[0].File != "main.tf" {
+		t.Errorf("Expected one block in main.tf, but got %+v", response.Report)
+	}
+}
+
+func TestProcessServerJSONFile(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := httptest.NewServer(newProcessServer(Stats{}, nil, logger))
+	defer server.Close()
+
+	body := `{"removed": [{"from": "aws_instance.old"}], "resource": {"aws_instance": {"web": {}}}}`
+	resp, err := http.Post(server.URL+"/process?filename=main.tf.json", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	var response ProcessResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatalf("Invalid response: %v", err)
+	}
+	if strings.Contains(response.Content, "removed") || response.Report.Blocks[0].File != "main.tf.json" {
+		t.Errorf("Expected the JSON removed block to be deleted, but got %q and %+v", response.Content, response.Report.Blocks)
+	}
+}
+
+func TestProcessServerErrors(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := httptest.NewServer(newProcessServer(Stats{MaxFileSize: 16}, nil, logger))
+	defer server.Close()
+
+	for _, tt := range []struct {
+		method   string
+		path     string
+		body     string
+		status   int
+		expected string
+	}{
+		{method: http.MethodGet, path: "/process", status: http.StatusMethodNotAllowed, expected: "use POST"},
+		{method: http.MethodPost, path: "/process", body: "removed {", status: http.StatusUnprocessableEntity, expected: "main.tf:1"},
+		{method: http.MethodPost, path: "/process?filename=../main.tf", body: "locals {}", status: http.StatusBadRequest, expected: "without a directory"},
+		{method: http.MethodPost, path: "/process", body: strings.Repeat("#", 17), status: http.StatusRequestEntityTooLarge, expected: "exceeds 16 bytes"},
+		{method: http.MethodGet, path: "/other", status: http.StatusNotFound},
+	} {
+		req, err := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", tt.method, tt.path, err)
+		}
+		data, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if resp.StatusCode != tt.status || !strings.Contains(string(data), tt.expected) {
+			t.Errorf("%s %s: expected status %d with %q, but got %d with %q", tt.method, tt.path, tt.status, tt.expected, resp.StatusCode, string(data))
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits are the suffixes parseSize understands, longest first.
+var sizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10},
+	{"GB", 1e9}, {"MB", 1e6}, {"KB", 1e3},
+	{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+	{"B", 1},
+}
+
+// parseSize parses a byte count with an optional unit, e.g. 512K, 10MB or
+// 1GiB. The single-letter units are binary, like those of du and ls.
+func parseSize(value string) (int64, error) {
+	number, unit := value, int64(1)
+	for _, u := range sizeUnits {
+		if n, ok := strings.CutSuffix(value, u.suffix); ok {
+			number, unit = n, u.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n < 0 || n > (1<<63-1)/unit {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return n * unit, nil
+}
+
+// exceedsMaxFileSize reports whether filePath is larger than limit, in which
+// case it is skipped without being read. A limit of zero means no limit.
+func exceedsMaxFileSize(filePath string, limit int64) (bool, int64) {
+	if limit <= 0 {
+		return false, 0
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		// Reading the file reports the problem
+		return false, 0
+	}
+	return info.Size() > limit, info.Size()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	for input, expected := range map[string]int64{
+		"0":      0,
+		"1024":   1024,
+		"512B":   512,
+		"10KB":   10_000,
+		"10K":    10 << 10,
+		"10KiB":  10 << 10,
+		"25MB":   25_000_000,
+		"25M":    25 << 20,
+		"1GiB":   1 << 30,
+		"2G":     2 << 30,
+		"100MiB": 100 << 20,
+	} {
+		got, err := parseSize(input)
+		if err != nil {
+			t.Errorf("parseSize(%q) failed: %v", input, err)
+			continue
+		}
+		if got != expected {
+			t.Errorf("parseSize(%q) = %d, expected %d", input, got, expected)
+		}
+	}
+
+	for _, input := range []string{"", "MB", "ten MB", "-1K", "1.5MB", "99999999999GiB"} {
+		if _, err := parseSize(input); err == nil {
+			t.Errorf("parseSize(%q) expected error, got nil", input)
+		}
+	}
+}
+
+func TestProcessFileMaxFileSize(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-max-file-size-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := "removed {\n  from = aws_instance.old\n}\n"
+	testFile := filepath.Join(tempDir, "generated.tf")
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	stats := Stats{MaxFileSize: int64(len(content) - 1)}
+	if err := processFile(testFile, &stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	if stats.FilesProcessed != 0 || stats.RemovedBlocksRemoved != 0 {
+		t.Errorf("Expected the file to be skipped, but got %d processed and %d removed", stats.FilesProcessed, stats.RemovedBlocksRemoved)
+	}
+	if len(stats.Warnings) != 1 || !strings.Contains(stats.Warnings[0], "-max-file-size") {
+		t.Errorf("Expected a warning naming -max-file-size, but got %v", stats.Warnings)
+	}
+
+	stats = Stats{MaxFileSize: int64(len(content))}
+	if err := processFile(testFile, &stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	if stats.RemovedBlocksRemoved != 1 {
+		t.Errorf("Expected a file at the limit to be processed, but got %d removed", stats.RemovedBlocksRemoved)
+	}
+}
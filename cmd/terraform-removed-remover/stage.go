@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// stageDeletedBlock writes the source of block to its own file in stageDir,
+// named after the block's address. A numeric suffix is added when a file for
+// the same address already exists, so nothing staged is ever overwritten.
+func stageDeletedBlock(stageDir, filePath string, block removedBlock, content []byte) error {
+	if err := os.MkdirAll(stageDir, 0750); err != nil {
+		return fmt.Errorf("error creating staging directory %s: %w", stageDir, err)
+	}
+
+	snippet := fmt.Sprintf("# Staged from %s:%d\n%s\n", filePath, block.Line, content[block.start:block.end])
+	ext := ".tf"
+	if isJSONConfig(filePath) {
+		// JSON has no comments; Terraform skips "//" members instead.
+		origin, err := json.Marshal(fmt.Sprintf("Staged from %s:%d", filePath, block.Line))
+		if err != nil {
+			return fmt.Errorf("error staging block %s: %w", block.Address, err)
+		}
+		snippet = fmt.Sprintf("{\n  \"//\": %s,\n  \"removed\": %s\n}\n", origin, content[block.start:block.end])
+		ext = jsonConfigSuffix
+	}
+
+	base := stageFileName(block.Address)
+	for i := 1; ; i++ {
+		name := base + ext
+		if i > 1 {
+			name = fmt.Sprintf("%s-%d%s", base, i, ext)
+		}
+
+		f, err := os.OpenFile(filepath.Join(stageDir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error staging block %s: %w", block.Address, err)
+		}
+
+		_, err = f.WriteString(snippet)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("error staging block %s: %w", block.Address, err)
+		}
+		return nil
+	}
+}
+
+// stageFileName turns an address into a safe file name, keeping dots so
+// module.app.aws_instance.web stays readable.
+func stageFileName(address string) string {
+	if address == "" {
+		return "removed"
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '.' || r == '_' || r == '-' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, address)
+}
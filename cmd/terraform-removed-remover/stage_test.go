@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStageDeletes(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-stage-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := `
+removed {
+  from = aws_instance.old
+  lifecycle {
+    destroy = false
+  }
+}
+
+removed {
+  from = module.app["x"]
+}
+`
+	stageDir := filepath.Join(tempDir, "staged")
+	for _, name := range []string{"a.tf", "b.tf"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+
+		stats := Stats{StartTime: time.Now(), StageDir: stageDir}
+		if err := processFile(filepath.Join(tempDir, name), &stats); err != nil {
+			t.Fatalf("processFile failed: %v", err)
+		}
+	}
+
+	entries, err := os.ReadDir(stageDir)
+	if err != nil {
+		t.Fatalf("Failed to read staging directory: %v", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	expected := []string{"aws_instance.old-2.tf", "aws_instance.old.tf", "module.app__x__-2.tf", "module.app__x__.tf"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected staged files %v, but got %v", expected, names)
+	}
+
+	staged, err := os.ReadFile(filepath.Join(stageDir, "aws_instance.old.tf"))
+	if err != nil {
+		t.Fatalf("Failed to read staged file: %v", err)
+	}
+	if !strings.Contains(string(staged), "from = aws_instance.old") || !strings.Contains(string(staged), "# Staged from ") {
+		t.Errorf("Staged file does not contain the block and its origin:\n%s", staged)
+	}
+}
+
+func TestStageDeletesSkippedInDryRun(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-stage-dry-run-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	testFile := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(testFile, []byte("removed {\n  from = aws_instance.old\n}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	stageDir := filepath.Join(tempDir, "staged")
+	stats := Stats{DryRun: true, StageDir: stageDir}
+	if err := processFile(testFile, &stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+
+	if _, err := os.Stat(stageDir); !os.IsNotExist(err) {
+		t.Errorf("Staging directory was created in dry-run mode")
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultStateDir is the sidecar directory doctor reads when -state-dir is
+// not given.
+const defaultStateDir = ".trr-state"
+
+// FileState is the sidecar record written for a file after a successful run.
+// Sidecars live at <state dir>/<path relative to the state dir's parent>.json.
+type FileState struct {
+	ToolVersion string    `json:"tool_version"`
+	CleanedAt   time.Time `json:"cleaned_at"`
+	// SHA256 is the digest of the file as the tool left it, so later runs
+	// can tell whether it changed since.
+	SHA256 string `json:"sha256"`
+	// RemainingBlocks counts removed blocks kept because of filters.
+	RemainingBlocks int `json:"remaining_blocks"`
+}
+
+// statePath returns the sidecar path for filePath.
+func statePath(stateDir, filePath string) (string, error) {
+	base, err := filepath.Abs(filepath.Dir(filepath.Clean(stateDir)))
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(base, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside %s", filePath, base)
+	}
+	return filepath.Join(stateDir, rel+".json"), nil
+}
+
+func fileDigest(filePath string) (string, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// recordFileState writes the sidecar for filePath as it is on disk now.
+func recordFileState(stateDir, filePath string, remaining int, now time.Time) error {
+	sidecar, err := statePath(stateDir, filePath)
+	if err != nil {
+		return err
+	}
+	digest, err := fileDigest(filePath)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(FileState{
+		ToolVersion:     Version,
+		CleanedAt:       now.UTC(),
+		SHA256:          digest,
+		RemainingBlocks: remaining,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(sidecar), 0750); err != nil {
+		return fmt.Errorf("error creating state directory: %w", err)
+	}
+	if err := os.WriteFile(sidecar, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("error writing state for %s: %w", filePath, err)
+	}
+	return nil
+}
+
+// loadFileState returns the sidecar for filePath, or nil if there is none.
+func loadFileState(stateDir, filePath string) (*FileState, error) {
+	sidecar, err := statePath(stateDir, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(sidecar)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state FileState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", sidecar, err)
+	}
+	return &state, nil
+}
+
+// runDoctor explains, for each file, what the recorded state says about it
+// and whether it still contains removed blocks. stats configures the
+// read-only scan and must have DryRun set.
+func runDoctor(w io.Writer, stateDir string, files []string, stats *Stats) {
+	for _, file := range files {
+		found := len(stats.Findings)
+		if err := processFile(file, stats); err != nil {
+			fmt.Fprintf(w, "%s: cannot be processed: %s\n", file, err)
+			continue
+		}
+		blocks := len(stats.Findings) - found
+
+		state, err := loadFileState(stateDir, file)
+		if err != nil {
+			fmt.Fprintf(w, "%s: unreadable state: %s\n", file, err)
+			continue
+		}
+		fmt.Fprintf(w, "%s: %s\n", file, describeFileState(file, state, blocks))
+	}
+}
+
+func describeFileState(file string, state *FileState, blocks int) string {
+	pending := ""
+	if blocks > 0 {
+		pending = fmt.Sprintf(", %d removed blocks to clean up", blocks)
+	}
+
+	if state == nil {
+		return "never cleaned" + pending
+	}
+
+	when := fmt.Sprintf("%s by v%s", state.CleanedAt.Local().Format("2006-01-02 15:04"), state.ToolVersion)
+	digest, err := fileDigest(file)
+	if err != nil || digest != state.SHA256 {
+		return "changed since it was cleaned on " + when + pending
+	}
+	if state.RemainingBlocks > 0 {
+		return fmt.Sprintf("unchanged since it was cleaned on %s, %d removed blocks kept by filters", when, state.RemainingBlocks)
+	}
+	return "clean since " + when
+}
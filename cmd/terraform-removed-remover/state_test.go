@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileStateRoundTrip(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-state-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	stateDir := filepath.Join(tempDir, defaultStateDir)
+	testFile := filepath.Join(tempDir, "modules", "vpc", "main.tf")
+	if err := os.MkdirAll(filepath.Dir(testFile), 0750); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(testFile, []byte("locals {}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	if state, err := loadFileState(stateDir, testFile); err != nil || state != nil {
+		t.Fatalf("Expected no state before recording, got %+v, %v", state, err)
+	}
+
+	cleanedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := recordFileState(stateDir, testFile, 0, cleanedAt); err != nil {
+		t.Fatalf("recordFileState failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(stateDir, "modules", "vpc", "main.tf.json")); err != nil {
+		t.Errorf("Expected the sidecar to mirror the file's path: %v", err)
+	}
+
+	state, err := loadFileState(stateDir, testFile)
+	if err != nil || state == nil {
+		t.Fatalf("loadFileState failed: %+v, %v", state, err)
+	}
+	if !state.CleanedAt.Equal(cleanedAt) || state.ToolVersion != Version {
+		t.Errorf("Unexpected state %+v", state)
+	}
+	if got := describeFileState(testFile, state, 0); !strings.HasPrefix(got, "clean since") {
+		t.Errorf("Expected an unchanged file to be clean, got %q", got)
+	}
+
+	if err := os.WriteFile(testFile, []byte("locals {}\n# edited\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	if got := describeFileState(testFile, state, 1); !strings.HasPrefix(got, "changed since") || !strings.Contains(got, "1 removed blocks") {
+		t.Errorf("Expected an edited file to be reported as changed, got %q", got)
+	}
+
+	if _, err := statePath(stateDir, filepath.Join(os.TempDir(), "elsewhere.tf")); err == nil {
+		t.Errorf("Expected an error for a file outside the state directory's parent")
+	}
+}
+
+func TestRunDoctor(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-doctor-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	testFile := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(testFile, []byte("removed {\n  from = aws_instance.old\n}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	var output bytes.Buffer
+	stats := Stats{DryRun: true}
+	runDoctor(&output, filepath.Join(tempDir, defaultStateDir), []string{testFile}, &stats)
+
+	expected := testFile + ": never cleaned, 1 removed blocks to clean up\n"
+	if output.String() != expected {
+		t.Errorf("Expected %q, but got %q", expected, output.String())
+	}
+}
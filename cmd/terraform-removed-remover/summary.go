@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// defaultSummaryInterval is how often -summary-file is rewritten while a run
+// is in progress.
+const defaultSummaryInterval = 10 * time.Second
+
+// summaryWriter keeps a JSON report of a run in progress up to date on disk
+// so external dashboards can poll it instead of waiting for the run to end.
+// The caller hands over report snapshots with update when due says one is
+// wanted; a background goroutine writes the latest one on every tick, so
+// Stats itself is never shared.
+type summaryWriter struct {
+	path     string
+	interval time.Duration
+
+	mu     sync.Mutex
+	report *Report
+	dirty  bool
+	err    error
+	// updated is when report was last replaced
+	updated time.Time
+
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func newSummaryWriter(path string, interval time.Duration) *summaryWriter {
+	if interval <= 0 {
+		interval = defaultSummaryInterval
+	}
+	return &summaryWriter{
+		path:     path,
+		interval: interval,
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// start begins flushing in the background until stop is called.
+func (w *summaryWriter) start() {
+	go func() {
+		defer close(w.stopped)
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w.flush()
+			case <-w.done:
+				return
+			}
+		}
+	}()
+}
+
+// due reports whether a new snapshot is wanted: none was handed over in the
+// last interval. Building a report copies every finding so far, so a run
+// that did it after every file would take quadratic time.
+func (w *summaryWriter) due() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return time.Since(w.updated) >= w.interval
+}
+
+// update records the current state of the run. Unfinished runs are reported
+// with their duration so far.
+func (w *summaryWriter) update(stats *Stats) {
+	snapshot := *stats
+	if snapshot.EndTime.IsZero() {
+		snapshot.EndTime = time.Now()
+	}
+	report := newReport(&snapshot)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.report = report
+	w.dirty = true
+	w.updated = time.Now()
+}
+
+// flush writes the latest report if it changed since the last write. Errors
+// are kept for stop to return rather than aborting a run over a dashboard.
+func (w *summaryWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.dirty {
+		return
+	}
+
+	data, err := json.MarshalIndent(w.report, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(w.path, append(data, '\n'))
+	}
+	if err != nil {
+		if w.err == nil {
+			w.err = fmt.Errorf("error writing summary file %s: %w", w.path, err)
+		}
+		return
+	}
+	w.dirty = false
+}
+
+// stop ends the background flushing and writes the final report. It returns
+// the first error encountered while writing.
+func (w *summaryWriter) stop(stats *Stats) error {
+	close(w.done)
+	<-w.stopped
+
+	w.update(stats)
+	w.flush()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// writeFileAtomic replaces path with data through a temporary file in the
+// same directory, so neither readers nor a crash, full disk, or SIGKILL part
+// way through ever leave a partially written file. An existing file keeps
+// its permissions, and a symlink keeps pointing at the file it names, which
+// is the one replaced.
+func writeFileAtomic(path string, data []byte) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(mode)
+	}
+	if err == nil {
+		// The rename must not reach the disk before the data does
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func readSummary(t *testing.T, path string) *Report {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("Summary file is not a valid report: %v\n%s", err, data)
+	}
+	return &report
+}
+
+func TestSummaryWriterFlushesPeriodically(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-summary-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	path := filepath.Join(tempDir, "summary.json")
+	writer := newSummaryWriter(path, 10*time.Millisecond)
+	writer.start()
+
+	stats := Stats{StartTime: time.Now(), FilesProcessed: 1}
+	writer.update(&stats)
+
+	deadline := time.Now().Add(5 * time.Second)
+	var report *Report
+	for time.Now().Before(deadline) {
+		if report = readSummary(t, path); report != nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if report == nil {
+		t.Fatalf("Expected the summary file to be written while the run is in progress")
+	}
+	if report.FilesProcessed != 1 || report.DurationMillis < 0 {
+		t.Errorf("Expected 1 file processed and a non-negative duration, but got %+v", report)
+	}
+
+	stats.FilesProcessed = 3
+	stats.EndTime = time.Now()
+	if err := writer.stop(&stats); err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+	if report := readSummary(t, path); report == nil || report.FilesProcessed != 3 {
+		t.Errorf("Expected the final summary with 3 files processed, but got %+v", report)
+	}
+
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to read temp dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the summary file, but found %d entries", len(entries))
+	}
+}
+
+func TestSummaryWriterDue(t *testing.T) {
+	writer := newSummaryWriter(filepath.Join(os.TempDir(), "summary.json"), time.Hour)
+	if !writer.due() {
+		t.Errorf("Expected the first snapshot to be due")
+	}
+	writer.update(&Stats{StartTime: time.Now()})
+	if writer.due() {
+		t.Errorf("Expected no snapshot to be due within the interval")
+	}
+
+	writer = newSummaryWriter(filepath.Join(os.TempDir(), "summary.json"), time.Millisecond)
+	writer.update(&Stats{StartTime: time.Now()})
+	time.Sleep(2 * time.Millisecond)
+	if !writer.due() {
+		t.Errorf("Expected a snapshot to be due once the interval passed")
+	}
+}
+
+func TestSummaryWriterReportsWriteErrors(t *testing.T) {
+	writer := newSummaryWriter(filepath.Join(os.TempDir(), "no-such-dir", "summary.json"), time.Hour)
+	writer.start()
+
+	if err := writer.stop(&Stats{}); err == nil {
+		t.Errorf("Expected error writing into a missing directory, but got nil")
+	}
+}
+
+func TestWriteFileAtomicKeepsModeAndSymlinks(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-atomic-write-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	target := filepath.Join(tempDir, "shared.tf")
+	if err := os.WriteFile(target, []byte("old\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	// #nosec G302 -- the test needs a mode other than the default
+	if err := os.Chmod(target, 0640); err != nil {
+		t.Fatalf("Failed to chmod test file: %v", err)
+	}
+	link := filepath.Join(tempDir, "link.tf")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	if err := writeFileAtomic(link, []byte("new\n")); err != nil {
+		t.Fatalf("writeFileAtomic failed: %v", err)
+	}
+
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("Expected %s to remain a symlink, but got %v, %v", link, info, err)
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		t.Fatalf("Failed to stat target: %v", err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("Expected mode 0640 to be kept, but got %o", info.Mode().Perm())
+	}
+	if content, _ := os.ReadFile(target); string(content) != "new\n" {
+		t.Errorf("Expected the target to be replaced, but got %q", content)
+	}
+
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to read temp dir: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected no temporary files to be left behind, but got %v", entries)
+	}
+}
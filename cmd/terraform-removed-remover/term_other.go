@@ -0,0 +1,26 @@
+//go:build !linux && !darwin
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// errNoTUI is returned on platforms where the TUI cannot drive the terminal.
+var errNoTUI = errors.New("the TUI is not supported on this platform")
+
+// terminalState is the terminal mode to restore after the TUI.
+type terminalState struct{}
+
+func makeRaw(*os.File) (*terminalState, error) {
+	return nil, errNoTUI
+}
+
+func restoreTerminal(*os.File, *terminalState) error {
+	return errNoTUI
+}
+
+func terminalSize(*os.File) (int, int, error) {
+	return 0, 0, errNoTUI
+}
@@ -0,0 +1,58 @@
+//go:build linux || darwin
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// terminalState is the terminal mode to restore after the TUI.
+type terminalState struct {
+	termios syscall.Termios
+}
+
+// makeRaw puts the terminal f into raw mode, as the TUI reads single keys
+// and draws the whole screen itself, and returns the mode to restore.
+func makeRaw(f *os.File) (*terminalState, error) {
+	var state terminalState
+	if err := termiosIoctl(f, ioctlGetTermios, &state.termios); err != nil {
+		return nil, err
+	}
+	raw := state.termios
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := termiosIoctl(f, ioctlSetTermios, &raw); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// restoreTerminal puts the terminal f back into the mode makeRaw saved.
+func restoreTerminal(f *os.File, state *terminalState) error {
+	return termiosIoctl(f, ioctlSetTermios, &state.termios)
+}
+
+// terminalSize returns the width and height of the terminal f.
+func terminalSize(f *os.File) (int, int, error) {
+	var size struct{ rows, cols, xpixel, ypixel uint16 }
+	// #nosec G103 -- the ioctl fills in the struct
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&size))); errno != 0 {
+		return 0, 0, errno
+	}
+	return int(size.cols), int(size.rows), nil
+}
+
+func termiosIoctl(f *os.File, request uintptr, termios *syscall.Termios) error {
+	// #nosec G103 -- the ioctl reads or fills in the termios struct
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), request, uintptr(unsafe.Pointer(termios))); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// terraformIgnoreFile is the file Terraform Cloud reads from the root of a
+// configuration directory to decide what not to upload.
+const terraformIgnoreFile = ".terraformignore"
+
+// ignoreRule is a single .terraformignore pattern.
+type ignoreRule struct {
+	pattern string
+	// negate re-includes paths matched by earlier rules (!pattern).
+	negate bool
+	// dirOnly only matches directories (pattern/).
+	dirOnly bool
+	// anchored patterns start with / or contain one, and match relative to
+	// the root instead of at any depth.
+	anchored bool
+}
+
+// loadTerraformIgnore reads the .terraformignore file in rootDir. It returns
+// no rules if the file does not exist.
+func loadTerraformIgnore(rootDir string) ([]ignoreRule, error) {
+	f, err := os.Open(filepath.Join(rootDir, terraformIgnoreFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", terraformIgnoreFile, err)
+	}
+	return rules, nil
+}
+
+// excludesPath reports whether rules exclude the file at rel, a slash-separated
+// path relative to the root. A rule matching one of the file's parent
+// directories excludes everything below it, and the last matching rule wins.
+func excludesPath(rules []ignoreRule, rel string) bool {
+	segments := strings.Split(rel, "/")
+	excluded := false
+	for _, rule := range rules {
+		for i := range segments {
+			isDir := i < len(segments)-1
+			if rule.dirOnly && !isDir {
+				continue
+			}
+			if rule.matches(strings.Join(segments[:i+1], "/")) {
+				excluded = !rule.negate
+				break
+			}
+		}
+	}
+	return excluded
+}
+
+func (r ignoreRule) matches(candidate string) bool {
+	if r.anchored {
+		return matchDoublestar(r.pattern, candidate)
+	}
+	return matchDoublestar("**/"+r.pattern, candidate)
+}
+
+// filterTerraformIgnored drops the files excluded by rootDir's
+// .terraformignore.
+func filterTerraformIgnored(discovery *Discovery, rootDir string) (*Discovery, error) {
+	rules, err := loadTerraformIgnore(rootDir)
+	if err != nil || len(rules) == 0 {
+		return discovery, err
+	}
+
+	return discovery.exclude(func(file string) bool {
+		rel, err := filepath.Rel(rootDir, file)
+		return err == nil && excludesPath(rules, filepath.ToSlash(rel))
+	}), nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestExcludesPath(t *testing.T) {
+	rules := []ignoreRule{
+		{pattern: "examples", dirOnly: true},
+		{pattern: "*.generated.tf"},
+		{pattern: "keep.generated.tf", negate: true},
+		{pattern: "envs/**/scratch", anchored: true},
+	}
+
+	tests := []struct {
+		rel  string
+		want bool
+	}{
+		{"main.tf", false},
+		{"examples/main.tf", true},
+		{"modules/vpc/examples/basic/main.tf", true},
+		{"examples.tf", false},
+		{"modules/a.generated.tf", true},
+		{"modules/keep.generated.tf", false},
+		{"envs/prod/scratch/main.tf", true},
+		{"modules/envs/prod/scratch/main.tf", false},
+	}
+
+	for _, tt := range tests {
+		if got := excludesPath(rules, tt.rel); got != tt.want {
+			t.Errorf("excludesPath(%q) = %v, expected %v", tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestFilterTerraformIgnored(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-terraformignore-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	files := map[string]string{
+		".terraformignore":      "# fixtures\nfixtures/\n/legacy.tf\n",
+		"main.tf":               "",
+		"legacy.tf":             "",
+		"modules/legacy.tf":     "",
+		"fixtures/test.tf":      "",
+		"modules/fixtures/x.tf": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		if mkdirErr := os.MkdirAll(filepath.Dir(path), 0750); mkdirErr != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, mkdirErr)
+		}
+		if writeErr := os.WriteFile(path, []byte(content), 0600); writeErr != nil {
+			t.Fatalf("Failed to write file %s: %v", name, writeErr)
+		}
+	}
+
+	discovery, err := discoverFiles(tempDir, DiscoveryOptions{})
+	if err != nil {
+		t.Fatalf("discoverFiles failed: %v", err)
+	}
+	filtered, err := filterTerraformIgnored(discovery, tempDir)
+	if err != nil {
+		t.Fatalf("filterTerraformIgnored failed: %v", err)
+	}
+
+	expected := []string{filepath.Join(tempDir, "main.tf"), filepath.Join(tempDir, "modules", "legacy.tf")}
+	if !slices.Equal(filtered.Files, expected) {
+		t.Errorf("Expected %v, but got %v", expected, filtered.Files)
+	}
+}
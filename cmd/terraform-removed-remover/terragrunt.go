@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// terragruntFileName is the Terragrunt configuration file picked up by
+// -terragrunt.
+const terragruntFileName = "terragrunt.hcl"
+
+// isTerragruntConfig reports whether path is a Terragrunt configuration file.
+func isTerragruntConfig(path string) bool {
+	return filepath.Base(path) == terragruntFileName
+}
+
+// heredoc is the body of a heredoc in a file: the lines between the opening
+// and closing markers. start and end are byte offsets into the file and line
+// is the line the body starts on.
+type heredoc struct {
+	start int
+	end   int
+	line  int
+}
+
+// generateHeredocs returns the heredoc contents of the top-level generate
+// blocks in body. Contents given any other way, such as file(), are skipped.
+func generateHeredocs(body *hclsyntax.Body, content []byte) []heredoc {
+	var heredocs []heredoc
+	for _, block := range body.Blocks {
+		if block.Type != "generate" {
+			continue
+		}
+		attribute, ok := block.Body.Attributes["contents"]
+		if !ok {
+			continue
+		}
+		if _, ok := attribute.Expr.(*hclsyntax.TemplateExpr); !ok {
+			continue
+		}
+
+		r := attribute.Expr.Range()
+		raw := content[r.Start.Byte:r.End.Byte]
+		if !bytes.HasPrefix(raw, []byte("<<")) {
+			continue
+		}
+		open := bytes.IndexByte(raw, '\n')
+		closing := bytes.LastIndexByte(raw, '\n')
+		if open < 0 || closing < open {
+			continue
+		}
+		heredocs = append(heredocs, heredoc{
+			start: r.Start.Byte + open + 1,
+			end:   r.Start.Byte + closing + 1,
+			line:  r.Start.Line + 1,
+		})
+	}
+	return heredocs
+}
+
+// processTerragruntFile is processFile for terragrunt.hcl: the Terraform
+// code in each generate block's heredoc is parsed on its own and its removed
+// blocks are deleted from the heredoc. Everything outside the removed blocks,
+// including the heredoc's indentation, is left untouched.
+func processTerragruntFile(filePath string, content []byte, encoding fileEncoding, stats *Stats) error {
+	syntaxFile, diags := hclsyntax.ParseConfig(content, filePath, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return invalidFile(filePath, parseFailure(filePath, content, diags), stats)
+	}
+
+	syntaxBody, ok := syntaxFile.Body.(*hclsyntax.Body)
+	if !ok {
+		return &parseError{fmt.Errorf("unexpected body type in %s", filePath)}
+	}
+	if stats.Inventory != nil {
+		stats.Inventory.addHCL(filePath, syntaxBody)
+	}
+
+	stats.FilesProcessed++
+	if stats.FormatOnly || stats.Consolidate {
+		return nil
+	}
+
+	heredocs := generateHeredocs(syntaxBody, content)
+	removed := make([][]removedBlock, len(heredocs))
+	removedBlocksCount := 0
+	for i, doc := range heredocs {
+		inner := content[doc.start:doc.end]
+		// Byte offsets stay relative to the heredoc; lines match the file
+		innerFile, diags := hclsyntax.ParseConfig(inner, filePath, hcl.Pos{Line: doc.line, Column: 1})
+		if diags.HasErrors() {
+			stats.Warnings = append(stats.Warnings, fmt.Sprintf("%s:%d: skipping generate contents that are not plain HCL: %s", filePath, doc.line, diags.Error()))
+			continue
+		}
+		innerBody, ok := innerFile.Body.(*hclsyntax.Body)
+		if !ok {
+			continue
+		}
+
+		removed[i] = selectRemovedBlocks(filePath, findRemovedBlocks(innerBody, inner), inner, stats)
+		removed[i] = withLeadingComments(inner, removed[i], stats.StripLeadingComments)
+		if stats.StripTrailingComments {
+			removed[i] = withTrailingComments(inner, removed[i])
+		} else {
+			warnTrailingComments(filePath, inner, removed[i], stats)
+		}
+		removedBlocksCount += len(removed[i])
+		for _, nested := range findNestedRemovedBlocks(innerBody, inner) {
+			nested.File = filePath
+			stats.NestedFindings = append(stats.NestedFindings, nested)
+		}
+	}
+
+	if removedBlocksCount == 0 {
+		return nil
+	}
+	stats.FilesModified++
+	stats.RemovedBlocksRemoved += removedBlocksCount
+
+	result := append([]byte(nil), content...)
+	for i := len(heredocs) - 1; i >= 0; i-- {
+		if len(removed[i]) == 0 {
+			continue
+		}
+		doc := heredocs[i]
+		var rewritten []byte
+		if stats.Tombstone {
+			rewritten = tombstoneBlocks(content[doc.start:doc.end], removed[i], stats)
+		} else {
+			rewritten = removeBlockRanges(content[doc.start:doc.end], removed[i])
+		}
+		result = append(result[:doc.start:doc.start], append(rewritten, result[doc.end:]...)...)
+	}
+	result = applyLineEndings(applyFinalNewline(result, content, stats.FinalNewline), stats.LineEndings)
+
+	if stats.DiffOutput != nil {
+		if err := writeFileDiff(filePath, content, result, stats); err != nil {
+			return err
+		}
+	}
+	if stats.Edits != nil {
+		if err := writeFileEdits(filePath, content, result, stats); err != nil {
+			return err
+		}
+	}
+	if stats.DryRun {
+		return nil
+	}
+
+	if stats.StageDir != "" {
+		for i, doc := range heredocs {
+			for _, block := range removed[i] {
+				if err := stageDeletedBlock(stats.StageDir, filePath, block, content[doc.start:doc.end]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if encoding.isUTF16() && !stats.PreserveEncoding {
+		stats.Warnings = append(stats.Warnings, fmt.Sprintf("%s: converted from %s to UTF-8", filePath, encoding.Name))
+	} else {
+		result = encodeContent(result, encoding)
+	}
+
+	if err := writeConfigFile(filePath, result, stats); err != nil {
+		return err
+	}
+	for i, doc := range heredocs {
+		if err := recordDeletedBlocks(filePath, removed[i], content[doc.start:doc.end], stats); err != nil {
+			return err
+		}
+	}
+	return nil
+}
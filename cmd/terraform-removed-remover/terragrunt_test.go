@@ -0,0 +1,142 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestProcessTerragruntFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-terragrunt-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := `include "root" {
+  path = find_in_parent_folders()
+}
+
+generate "moved" {
+  path      = "moved.tf"
+  if_exists = "overwrite"
+  contents  = <<-EOF
+    resource "aws_instance" "web" {
+      ami = "${local.ami}"
+    }
+
+    removed {
+      from = aws_instance.old
+      lifecycle {
+        destroy = false
+      }
+    }
+  EOF
+}
+
+generate "provider" {
+  path     = "provider.tf"
+  contents = file("provider.tf")
+}
+
+generate "broken" {
+  path     = "broken.tf"
+  contents = <<EOF
+removed {
+EOF
+}
+`
+	expected := `include "root" {
+  path = find_in_parent_folders()
+}
+
+generate "moved" {
+  path      = "moved.tf"
+  if_exists = "overwrite"
+  contents  = <<-EOF
+    resource "aws_instance" "web" {
+      ami = "${local.ami}"
+    }
+
+  EOF
+}
+
+generate "provider" {
+  path     = "provider.tf"
+  contents = file("provider.tf")
+}
+
+generate "broken" {
+  path     = "broken.tf"
+  contents = <<EOF
+removed {
+EOF
+}
+`
+
+	testFile := filepath.Join(tempDir, "terragrunt.hcl")
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	stats := Stats{DiscoveryOptions: DiscoveryOptions{Terragrunt: true}}
+	if err := processFile(testFile, &stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+
+	modified, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	if string(modified) != expected {
+		t.Errorf("Expected content:\n%s\nBut got:\n%s", expected, modified)
+	}
+
+	if len(stats.Findings) != 1 || stats.Findings[0].Address != "aws_instance.old" || stats.Findings[0].Line != 13 {
+		t.Errorf("Expected aws_instance.old on line 13, but got %+v", stats.Findings)
+	}
+	if len(stats.Warnings) != 1 || !strings.Contains(stats.Warnings[0], "not plain HCL") {
+		t.Errorf("Expected a warning for the unparsable heredoc, but got %v", stats.Warnings)
+	}
+}
+
+func TestDiscoverFilesTerragrunt(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-terragrunt-discovery-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	for _, name := range []string{"main.tf", "terragrunt.hcl", "root.hcl"} {
+		if writeErr := os.WriteFile(filepath.Join(tempDir, name), []byte(""), 0600); writeErr != nil {
+			t.Fatalf("Failed to write file %s: %v", name, writeErr)
+		}
+	}
+
+	discovery, err := discoverFiles(tempDir, DiscoveryOptions{})
+	if err != nil {
+		t.Fatalf("discoverFiles failed: %v", err)
+	}
+	if !slices.Equal(discovery.Files, []string{filepath.Join(tempDir, "main.tf")}) {
+		t.Errorf("Expected terragrunt.hcl to be skipped without -terragrunt, but got %v", discovery.Files)
+	}
+
+	discovery, err = discoverFiles(tempDir, DiscoveryOptions{Terragrunt: true})
+	if err != nil {
+		t.Fatalf("discoverFiles failed: %v", err)
+	}
+	expected := []string{filepath.Join(tempDir, "main.tf"), filepath.Join(tempDir, "terragrunt.hcl")}
+	if !slices.Equal(discovery.Files, expected) {
+		t.Errorf("Expected %v, but got %v", expected, discovery.Files)
+	}
+}
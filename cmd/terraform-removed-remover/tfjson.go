@@ -0,0 +1,381 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// jsonConfigSuffix marks files written in Terraform's JSON syntax, and
+// tofuJSONConfigSuffix those written in OpenTofu's.
+const (
+	jsonConfigSuffix     = ".tf.json"
+	tofuJSONConfigSuffix = ".tofu.json"
+)
+
+// isJSONConfig reports whether path is a Terraform or OpenTofu JSON
+// configuration file. Other .json files, such as those given with -ext
+// .json, are parsed as HCL like the rest.
+func isJSONConfig(path string) bool {
+	return strings.HasSuffix(path, jsonConfigSuffix) || strings.HasSuffix(path, tofuJSONConfigSuffix)
+}
+
+// jsonMember is a member of a JSON object. start is the opening quote of the
+// key and end is just past the value.
+type jsonMember struct {
+	Key        string
+	start      int
+	valueStart int
+	end        int
+}
+
+// jsonRemovedMember is a top-level "removed" member and the blocks it holds.
+// A member declares either a single block as an object or several as an
+// array of objects.
+type jsonRemovedMember struct {
+	member jsonMember
+	blocks []removedBlock
+}
+
+// findJSONRemovedBlocks returns the top-level "removed" members of a Terraform
+// JSON document. Block spans cover the object of each entry, so deleting them
+// leaves the surrounding document byte-for-byte intact.
+func findJSONRemovedBlocks(content []byte) ([]jsonRemovedMember, error) {
+	if !json.Valid(content) {
+		return nil, errors.New("invalid JSON")
+	}
+
+	start := jsonSkipSpace(content, 0)
+	if start >= len(content) || content[start] != '{' {
+		return nil, errors.New("top-level value is not an object")
+	}
+
+	members, err := jsonObjectMembers(content, start)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []jsonRemovedMember
+	for _, member := range members {
+		if member.Key != "removed" {
+			continue
+		}
+
+		var spans [][2]int
+		switch content[member.valueStart] {
+		case '{':
+			spans = [][2]int{{member.valueStart, member.end}}
+		case '[':
+			spans, err = jsonArrayElements(content, member.valueStart)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			continue
+		}
+
+		entry := jsonRemovedMember{member: member}
+		for _, span := range spans {
+			if content[span[0]] != '{' {
+				continue
+			}
+			entry.blocks = append(entry.blocks, removedBlock{
+				Address: jsonFromAddress(content[span[0]:span[1]]),
+				Line:    jsonLine(content, span[0]),
+				EndLine: jsonLine(content, span[1]),
+				Destroy: jsonBlockDestroys(content[span[0]:span[1]]),
+				start:   span[0],
+				end:     span[1],
+				comment: jsonBlockComment(content[span[0]:span[1]]),
+			})
+		}
+		removed = append(removed, entry)
+	}
+	return removed, nil
+}
+
+// jsonFromAddress returns the "from" address of a removed block object, or
+// an empty string when it is missing or not a string.
+func jsonFromAddress(object []byte) string {
+	var block struct {
+		From json.RawMessage `json:"from"`
+	}
+	if err := json.Unmarshal(object, &block); err != nil {
+		return ""
+	}
+	var from string
+	if err := json.Unmarshal(block.From, &from); err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(from), "")
+}
+
+// jsonLine returns the 1-based line of the byte at offset.
+func jsonLine(content []byte, offset int) int {
+	return bytes.Count(content[:offset], []byte("\n")) + 1
+}
+
+func jsonSkipSpace(b []byte, i int) int {
+	for i < len(b) && (b[i] == ' ' || b[i] == '\t' || b[i] == '\r' || b[i] == '\n') {
+		i++
+	}
+	return i
+}
+
+// jsonValueEnd returns the offset just past the value starting at i. The
+// document must already be known to be valid JSON.
+func jsonValueEnd(b []byte, i int) (int, error) {
+	if i >= len(b) {
+		return 0, errors.New("unexpected end of JSON")
+	}
+
+	switch b[i] {
+	case '"':
+		for j := i + 1; j < len(b); j++ {
+			switch b[j] {
+			case '\\':
+				j++
+			case '"':
+				return j + 1, nil
+			}
+		}
+		return 0, errors.New("unterminated string")
+	case '{', '[':
+		depth := 0
+		for j := i; j < len(b); j++ {
+			switch b[j] {
+			case '"':
+				end, err := jsonValueEnd(b, j)
+				if err != nil {
+					return 0, err
+				}
+				j = end - 1
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return j + 1, nil
+				}
+			}
+		}
+		return 0, errors.New("unterminated object or array")
+	default:
+		j := i
+		for j < len(b) && !bytes.ContainsRune([]byte(",}] \t\r\n"), rune(b[j])) {
+			j++
+		}
+		return j, nil
+	}
+}
+
+// jsonObjectMembers lists the members of the object starting at i.
+func jsonObjectMembers(b []byte, i int) ([]jsonMember, error) {
+	var members []jsonMember
+	j := jsonSkipSpace(b, i+1)
+	for j < len(b) && b[j] != '}' {
+		keyEnd, err := jsonValueEnd(b, j)
+		if err != nil {
+			return nil, err
+		}
+		var key string
+		if err := json.Unmarshal(b[j:keyEnd], &key); err != nil {
+			return nil, err
+		}
+
+		colon := jsonSkipSpace(b, keyEnd)
+		valueStart := jsonSkipSpace(b, colon+1)
+		valueEnd, err := jsonValueEnd(b, valueStart)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, jsonMember{Key: key, start: j, valueStart: valueStart, end: valueEnd})
+
+		j = jsonSkipSpace(b, valueEnd)
+		if j < len(b) && b[j] == ',' {
+			j = jsonSkipSpace(b, j+1)
+		}
+	}
+	return members, nil
+}
+
+// jsonArrayElements lists the spans of the elements of the array starting
+// at i.
+func jsonArrayElements(b []byte, i int) ([][2]int, error) {
+	var elements [][2]int
+	j := jsonSkipSpace(b, i+1)
+	for j < len(b) && b[j] != ']' {
+		end, err := jsonValueEnd(b, j)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, [2]int{j, end})
+
+		j = jsonSkipSpace(b, end)
+		if j < len(b) && b[j] == ',' {
+			j = jsonSkipSpace(b, j+1)
+		}
+	}
+	return elements, nil
+}
+
+// removeJSONSpan deletes the array element or object member at [start, end)
+// together with the comma that separates it from its neighbors. The next
+// element takes over the deleted one's indentation, or, for the last
+// element, the preceding comma goes instead.
+func removeJSONSpan(b []byte, start, end int) []byte {
+	next := jsonSkipSpace(b, end)
+	prev := start
+	for prev > 0 && (b[prev-1] == ' ' || b[prev-1] == '\t' || b[prev-1] == '\r' || b[prev-1] == '\n') {
+		prev--
+	}
+
+	switch {
+	case next < len(b) && b[next] == ',':
+		end = jsonSkipSpace(b, next+1)
+	case prev > 0 && b[prev-1] == ',':
+		start = prev - 1
+	default:
+		// The only element: close the container up onto the last line.
+		start = prev
+		for end < len(b) && (b[end] == ' ' || b[end] == '\t') {
+			end++
+		}
+	}
+	return append(b[:start:start], b[end:]...)
+}
+
+// removeJSONBlocks deletes the blocks in remove from content. A "removed"
+// member that would be left empty is dropped as a whole.
+func removeJSONBlocks(content []byte, members []jsonRemovedMember, remove []removedBlock) []byte {
+	selected := make(map[int]bool, len(remove))
+	for _, block := range remove {
+		selected[block.start] = true
+	}
+
+	var spans [][2]int
+	for _, member := range members {
+		var keep, drop [][2]int
+		for _, block := range member.blocks {
+			if selected[block.start] {
+				drop = append(drop, [2]int{block.start, block.end})
+			} else {
+				keep = append(keep, [2]int{block.start, block.end})
+			}
+		}
+		if len(drop) > 0 && len(keep) == 0 {
+			spans = append(spans, [2]int{member.member.start, member.member.end})
+		} else {
+			spans = append(spans, drop...)
+		}
+	}
+
+	// Delete from the end so earlier offsets stay valid
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] > spans[j][0] })
+	result := append([]byte(nil), content...)
+	for _, span := range spans {
+		result = removeJSONSpan(result, span[0], span[1])
+	}
+	return result
+}
+
+// processJSONFile is processFile for Terraform JSON syntax. Blocks are
+// removed by splicing the document, and the rest of the file, including its
+// formatting, is left untouched; there is no canonical JSON formatting to
+// apply.
+func processJSONFile(filePath string, content []byte, encoding fileEncoding, stats *Stats) error {
+	members, err := findJSONRemovedBlocks(content)
+	if err != nil {
+		return &parseError{fmt.Errorf("error parsing %s: %w", filePath, err)}
+	}
+	if stats.Inventory != nil {
+		if err := stats.Inventory.addJSON(filePath, content); err != nil {
+			return err
+		}
+	}
+
+	stats.FilesProcessed++
+	// Consolidation only moves blocks between HCL files
+	if stats.FormatOnly || stats.Consolidate {
+		return nil
+	}
+
+	var blocks []removedBlock
+	for _, member := range members {
+		blocks = append(blocks, member.blocks...)
+	}
+	removedRanges := selectRemovedBlocks(filePath, blocks, content, stats)
+	// Converting a UTF-16 file to UTF-8 is itself a change
+	converted := encoding.isUTF16() && !stats.PreserveEncoding
+	if len(removedRanges) == 0 && !converted {
+		return nil
+	}
+	if len(removedRanges) > 0 {
+		warnJSONComments(filePath, stats)
+	}
+	if stats.DryRun && len(removedRanges) == 0 && !stats.FailOnChange {
+		return nil
+	}
+
+	stats.FilesModified++
+	stats.RemovedBlocksRemoved += len(removedRanges)
+
+	result := content
+	if len(removedRanges) > 0 {
+		result = applyFinalNewline(removeJSONBlocks(content, members, removedRanges), content, stats.FinalNewline)
+		result = applyLineEndings(result, stats.LineEndings)
+	}
+	if stats.DiffOutput != nil && !bytes.Equal(result, content) {
+		if err := writeFileDiff(filePath, content, result, stats); err != nil {
+			return err
+		}
+	}
+	if stats.Edits != nil && !bytes.Equal(result, content) {
+		if err := writeFileEdits(filePath, content, result, stats); err != nil {
+			return err
+		}
+	}
+	if stats.DryRun {
+		return nil
+	}
+
+	if stats.StageDir != "" {
+		for _, block := range removedRanges {
+			if err := stageDeletedBlock(stats.StageDir, filePath, block, content); err != nil {
+				return err
+			}
+		}
+	}
+
+	if converted {
+		stats.Warnings = append(stats.Warnings, fmt.Sprintf("%s: converted from %s to UTF-8", filePath, encoding.Name))
+	} else {
+		result = encodeContent(result, encoding)
+	}
+
+	if err := writeConfigFile(filePath, result, stats); err != nil {
+		return err
+	}
+	return recordDeletedBlocks(filePath, removedRanges, content, stats)
+}
+
+// warnJSONComments warns that the options working through comments don't
+// apply to filePath, since JSON has none: its blocks are deleted as usual.
+func warnJSONComments(filePath string, stats *Stats) {
+	var flags []string
+	if stats.Tombstone {
+		flags = append(flags, "-tombstone")
+	}
+	if stats.StripLeadingComments {
+		flags = append(flags, "-strip-leading-comments")
+	}
+	if stats.StripTrailingComments {
+		flags = append(flags, "-strip-trailing-comments")
+	}
+	if len(flags) > 0 {
+		stats.Warnings = append(stats.Warnings, fmt.Sprintf("%s: JSON has no comments, so %s did not apply", filePath, strings.Join(flags, ", ")))
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindJSONRemovedBlocks(t *testing.T) {
+	content := []byte(`{
+  "resource": {"aws_instance": {"web": {"ami": "ami-123"}}},
+  "removed": [
+    {"from": "aws_instance.old", "lifecycle": {"destroy": false}},
+    {"from": "module.legacy"}
+  ],
+  "locals": {"note": "removed"}
+}
+`)
+
+	members, err := findJSONRemovedBlocks(content)
+	if err != nil {
+		t.Fatalf("findJSONRemovedBlocks failed: %v", err)
+	}
+	if len(members) != 1 || len(members[0].blocks) != 2 {
+		t.Fatalf("Expected one removed member with 2 blocks, but got %+v", members)
+	}
+
+	blocks := members[0].blocks
+	if blocks[0].Address != "aws_instance.old" || blocks[0].Line != 4 {
+		t.Errorf("Expected aws_instance.old on line 4, but got %s on line %d", blocks[0].Address, blocks[0].Line)
+	}
+	if blocks[1].Address != "module.legacy" || blocks[1].Line != 5 {
+		t.Errorf("Expected module.legacy on line 5, but got %s on line %d", blocks[1].Address, blocks[1].Line)
+	}
+
+	if _, err := findJSONRemovedBlocks([]byte(`{"removed": [`)); err == nil {
+		t.Errorf("Expected error for invalid JSON, but got nil")
+	}
+}
+
+func TestProcessJSONFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-json-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	tests := []struct {
+		name     string
+		content  string
+		stats    Stats
+		expected string
+		removed  int
+	}{
+		{
+			name: "array",
+			content: `{
+  "resource": {"aws_instance": {"web": {}}},
+  "removed": [
+    {"from": "aws_instance.old"},
+    {"from": "aws_instance.older"}
+  ]
+}
+`,
+			expected: `{
+  "resource": {"aws_instance": {"web": {}}}
+}
+`,
+			removed: 2,
+		},
+		{
+			name: "single object first",
+			content: `{
+  "removed": {"from": "aws_instance.old"},
+  "resource": {"aws_instance": {"web": {}}}
+}
+`,
+			expected: `{
+  "resource": {"aws_instance": {"web": {}}}
+}
+`,
+			removed: 1,
+		},
+		{
+			name: "partial",
+			content: `{
+  "removed": [
+    {"from": "aws_instance.old"},
+    {"from": "module.keep"},
+    {"from": "aws_instance.older"}
+  ]
+}
+`,
+			stats: Stats{Providers: []string{"aws"}},
+			expected: `{
+  "removed": [
+    {"from": "module.keep"}
+  ]
+}
+`,
+			removed: 2,
+		},
+		{
+			name: "only member",
+			content: `{
+  "removed": [{"from": "aws_instance.old"}]
+}
+`,
+			expected: `{
+}
+`,
+			removed: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := filepath.Join(tempDir, "main.tf.json")
+			if err := os.WriteFile(testFile, []byte(tt.content), 0600); err != nil {
+				t.Fatalf("Failed to write test file: %v", err)
+			}
+
+			stats := tt.stats
+			if err := processFile(testFile, &stats); err != nil {
+				t.Fatalf("processFile failed: %v", err)
+			}
+
+			content, err := os.ReadFile(testFile)
+			if err != nil {
+				t.Fatalf("Failed to read test file: %v", err)
+			}
+			if string(content) != tt.expected {
+				t.Errorf("Expected content:\n%s\nBut got:\n%s", tt.expected, content)
+			}
+			if stats.RemovedBlocksRemoved != tt.removed {
+				t.Errorf("Expected %d removed blocks, but got %d", tt.removed, stats.RemovedBlocksRemoved)
+			}
+		})
+	}
+}
+
+func TestStageJSONBlock(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-json-stage-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	testFile := filepath.Join(tempDir, "main.tf.json")
+	if err := os.WriteFile(testFile, []byte(`{"removed": {"from": "aws_instance.old"}}`), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	stageDir := filepath.Join(tempDir, "staged")
+	stats := Stats{StageDir: stageDir}
+	if err := processFile(testFile, &stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+
+	staged, err := os.ReadFile(filepath.Join(stageDir, "aws_instance.old.tf.json"))
+	if err != nil {
+		t.Fatalf("Expected staged JSON file: %v", err)
+	}
+	if _, err := findJSONRemovedBlocks(staged); err != nil {
+		t.Errorf("Expected staged file to be valid Terraform JSON, but got %v:\n%s", err, staged)
+	}
+}
+
+func TestIsJSONConfig(t *testing.T) {
+	for path, expected := range map[string]bool{
+		"main.tf.json":      true,
+		"main.tofu.json":    true,
+		"envs/prod.tf.json": true,
+		"main.tf":           false,
+		"package.json":      false,
+		"tsconfig.json":     false,
+	} {
+		if actual := isJSONConfig(path); actual != expected {
+			t.Errorf("isJSONConfig(%q) = %v, expected %v", path, actual, expected)
+		}
+	}
+}
+
+func TestProcessJSONFileUTF16(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-json-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := "{\n  \"resource\": {\"aws_instance\": {\"web\": {}}}\n}\n"
+	testFile := filepath.Join(tempDir, "main.tf.json")
+	if err := os.WriteFile(testFile, encodeContent([]byte(content), fileEncoding{Name: encodingUTF16LE, BOM: true}), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	stats := Stats{}
+	if err := processFile(testFile, &stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	modifiedContent, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	if string(modifiedContent) != content {
+		t.Errorf("Expected the file converted to UTF-8 as %q, but got %q", content, modifiedContent)
+	}
+	if stats.FilesModified != 1 || len(stats.Warnings) != 1 || !strings.Contains(stats.Warnings[0], "converted from UTF-16LE") {
+		t.Errorf("Expected one file modified with a conversion warning, but got %d, %v", stats.FilesModified, stats.Warnings)
+	}
+}
+
+func TestProcessJSONFileCommentOptions(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-json-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	testFile := filepath.Join(tempDir, "main.tf.json")
+	if err := os.WriteFile(testFile, []byte("{\n  \"removed\": {\"from\": \"aws_instance.old\"}\n}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	stats := Stats{DryRun: true, Tombstone: true, StripLeadingComments: true}
+	if err := processFile(testFile, &stats); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	expected := testFile + ": JSON has no comments, so -tombstone, -strip-leading-comments did not apply"
+	if len(stats.Warnings) != 1 || stats.Warnings[0] != expected {
+		t.Errorf("Expected the warning %q, but got %v", expected, stats.Warnings)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import "time"
+
+// tombstoneComment returns the comment -tombstone leaves in place of block.
+// -pure leaves out the date.
+func tombstoneComment(block removedBlock, stats *Stats) string {
+	comment := "# removed block"
+	if block.Address != "" {
+		comment += " for " + block.Address
+	}
+	comment += " deleted by terraform-removed-remover"
+	if !stats.Pure {
+		comment += " on " + time.Now().Format(time.DateOnly)
+	}
+	return comment
+}
+
+// tombstoneBlocks returns a copy of content with each of blocks, which must
+// be in source order, replaced by its tombstone comment. The block's
+// indentation and line break are kept, so the comment takes its place.
+func tombstoneBlocks(content []byte, blocks []removedBlock, stats *Stats) []byte {
+	result := append([]byte(nil), content...)
+	for i := len(blocks) - 1; i >= 0; i-- {
+		block := blocks[i]
+		comment := tombstoneComment(block, stats)
+		result = append(result[:block.start:block.start], append([]byte(comment), result[block.end:]...)...)
+	}
+	return result
+}
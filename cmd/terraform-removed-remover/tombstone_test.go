@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProcessFileTombstone(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-tombstone-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := `resource "aws_instance" "web" {}
+
+removed {
+  from = aws_instance.old
+}
+
+resource "aws_instance" "db" {}
+`
+	testFile := filepath.Join(tempDir, "main.tf")
+	for _, tt := range []struct {
+		pure     bool
+		expected string
+	}{
+		{
+			pure:     true,
+			expected: "resource \"aws_instance\" \"web\" {}\n\n# removed block for aws_instance.old deleted by terraform-removed-remover\n\nresource \"aws_instance\" \"db\" {}\n",
+		},
+		{
+			pure:     false,
+			expected: "resource \"aws_instance\" \"web\" {}\n\n# removed block for aws_instance.old deleted by terraform-removed-remover on " + time.Now().Format(time.DateOnly) + "\n\nresource \"aws_instance\" \"db\" {}\n",
+		},
+	} {
+		if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+		if err := processFile(testFile, &Stats{Tombstone: true, Pure: tt.pure}); err != nil {
+			t.Fatalf("processFile failed: %v", err)
+		}
+		data, err := os.ReadFile(testFile)
+		if err != nil {
+			t.Fatalf("Failed to read test file: %v", err)
+		}
+		if string(data) != tt.expected {
+			t.Errorf("Expected %q, but got %q", tt.expected, string(data))
+		}
+	}
+}
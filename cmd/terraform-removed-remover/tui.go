@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// tuiPreviewContext is the number of lines shown above a block in the
+// preview pane.
+const tuiPreviewContext = 3
+
+// tuiModel is the state of the tui subcommand: the removed blocks a dry run
+// found, grouped by file in the order they were found, and which of them
+// are marked for deletion. It knows nothing about the terminal, so it can
+// be driven by key names and rendered to lines in tests.
+type tuiModel struct {
+	findings []Finding
+	marked   []bool
+	cursor   int
+	// offset is the first list row shown.
+	offset int
+	// confirming is set while asking whether to apply the marks.
+	confirming bool
+	// done ends the session; apply is set when it should delete the marked
+	// blocks.
+	done   bool
+	apply  bool
+	status string
+	// sources caches the lines of each file for the preview pane.
+	sources  map[string][]string
+	readFile func(string) ([]byte, error)
+}
+
+func newTUIModel(findings []Finding) *tuiModel {
+	return &tuiModel{
+		findings: findings,
+		marked:   make([]bool, len(findings)),
+		sources:  map[string][]string{},
+		readFile: os.ReadFile,
+	}
+}
+
+// markedCount returns the number of blocks marked for deletion.
+func (m *tuiModel) markedCount() int {
+	count := 0
+	for _, marked := range m.marked {
+		if marked {
+			count++
+		}
+	}
+	return count
+}
+
+// selection returns the marked blocks.
+func (m *tuiModel) selection() *blockSelection {
+	selection := newBlockSelection()
+	for i, finding := range m.findings {
+		if m.marked[i] {
+			selection.addLine(finding.File, finding.Line)
+		}
+	}
+	return selection
+}
+
+// handleKey applies a key, as named by readKey, to the model.
+func (m *tuiModel) handleKey(key string) {
+	m.status = ""
+	if m.confirming {
+		m.confirming = false
+		if key == "y" {
+			m.done, m.apply = true, true
+		}
+		return
+	}
+
+	switch key {
+	case "up", "k":
+		m.cursor = max(m.cursor-1, 0)
+	case "down", "j":
+		m.cursor = min(m.cursor+1, len(m.findings)-1)
+	case "space", "x":
+		m.marked[m.cursor] = !m.marked[m.cursor]
+	case "a":
+		for i := range m.marked {
+			m.marked[i] = true
+		}
+	case "n":
+		for i := range m.marked {
+			m.marked[i] = false
+		}
+	case "enter":
+		if m.markedCount() == 0 {
+			m.status = "Nothing is marked; mark blocks with space"
+			return
+		}
+		m.confirming = true
+	case "q", "ctrl-c":
+		m.done = true
+	}
+}
+
+// tuiRow is a line of the list pane: a file heading, or a block when item
+// is its index in findings.
+type tuiRow struct {
+	item int
+	text string
+}
+
+func (m *tuiModel) rows() []tuiRow {
+	var rows []tuiRow
+	for i, finding := range m.findings {
+		if i == 0 || finding.File != m.findings[i-1].File {
+			rows = append(rows, tuiRow{item: -1, text: finding.File})
+		}
+		mark := "[ ]"
+		if m.marked[i] {
+			mark = "[x]"
+		}
+		rows = append(rows, tuiRow{item: i, text: fmt.Sprintf("  %s %d: %s", mark, finding.Line, finding.Address)})
+	}
+	return rows
+}
+
+// render draws the screen as height lines of at most width columns: a
+// title, the list of blocks, a preview of the block at the cursor, and a
+// line of help or status.
+func (m *tuiModel) render(width, height int) []string {
+	listHeight := max((height-3)/2, 1)
+	previewHeight := max(height-3-listHeight, 0)
+
+	lines := []string{fmt.Sprintf("terraform-removed-remover: %d removed blocks, %d marked for deletion", len(m.findings), m.markedCount())}
+
+	rows := m.rows()
+	cursorRow := 0
+	for i, row := range rows {
+		if row.item == m.cursor {
+			cursorRow = i
+		}
+	}
+	// Keep the cursor, and the heading of its first file, in view
+	if cursorRow < m.offset+1 {
+		m.offset = max(cursorRow-1, 0)
+	}
+	if cursorRow >= m.offset+listHeight {
+		m.offset = cursorRow - listHeight + 1
+	}
+	for i := m.offset; i < m.offset+listHeight; i++ {
+		switch {
+		case i >= len(rows):
+			lines = append(lines, "")
+		case i == cursorRow:
+			lines = append(lines, ">"+rows[i].text[1:])
+		default:
+			lines = append(lines, rows[i].text)
+		}
+	}
+
+	lines = append(lines, strings.Repeat("-", width))
+	lines = append(lines, m.preview(previewHeight)...)
+
+	switch {
+	case m.confirming:
+		lines = append(lines, fmt.Sprintf("Delete %d marked blocks? [y/N]", m.markedCount()))
+	case m.status != "":
+		lines = append(lines, m.status)
+	default:
+		lines = append(lines, "up/down move  space mark  a mark all  n mark none  enter delete marked  q quit")
+	}
+
+	for i, line := range lines {
+		lines[i] = truncateColumns(line, width)
+	}
+	return lines
+}
+
+// preview returns height lines of the file around the block at the cursor,
+// numbered, with the block's first line marked.
+func (m *tuiModel) preview(height int) []string {
+	lines := make([]string, 0, height)
+	finding := m.findings[m.cursor]
+	source, ok := m.sources[finding.File]
+	if !ok {
+		content, err := m.readFile(finding.File)
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("cannot read %s: %s", finding.File, err))
+			for len(lines) < height {
+				lines = append(lines, "")
+			}
+			return lines[:height]
+		}
+		source = strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+		m.sources[finding.File] = source
+	}
+
+	first := max(finding.Line-1-tuiPreviewContext, 0)
+	for i := first; len(lines) < height; i++ {
+		switch {
+		case i >= len(source):
+			lines = append(lines, "")
+		case i == finding.Line-1:
+			lines = append(lines, fmt.Sprintf("%5d > %s", i+1, expandTabs(source[i])))
+		default:
+			lines = append(lines, fmt.Sprintf("%5d   %s", i+1, expandTabs(source[i])))
+		}
+	}
+	return lines
+}
+
+// expandTabs replaces tabs with two spaces, so a line's width is its length.
+func expandTabs(s string) string {
+	return strings.ReplaceAll(s, "\t", "  ")
+}
+
+// truncateColumns cuts s to at most width runes.
+func truncateColumns(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:max(width, 0)])
+}
+
+// readKey reads a key press from r in raw mode and names it: arrows are
+// "up" and "down", Return is "enter", the space bar is "space", Ctrl-C is
+// "ctrl-c", and other keys are their character. Unknown escape sequences
+// read as "".
+func readKey(r *bufio.Reader) (string, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	switch b {
+	case '\r', '\n':
+		return "enter", nil
+	case ' ':
+		return "space", nil
+	case 3:
+		return "ctrl-c", nil
+	case 0x1b:
+		if next, err := r.ReadByte(); err != nil || next != '[' {
+			return "", err
+		}
+		code, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		switch code {
+		case 'A':
+			return "up", nil
+		case 'B':
+			return "down", nil
+		}
+		return "", nil
+	}
+	return string(rune(b)), nil
+}
+
+// runTUI runs the tui subcommand's browser over findings on the terminal
+// in and out. It returns the blocks marked for deletion once the user
+// applies them, or nil when they quit.
+func runTUI(in, out *os.File, findings []Finding) (*blockSelection, error) {
+	if !isTerminal(in) || !isTerminal(out) {
+		return nil, errors.New("the tui subcommand needs a terminal")
+	}
+	state, err := makeRaw(in)
+	if err != nil {
+		return nil, fmt.Errorf("error setting up the terminal: %w", err)
+	}
+	defer func() { _ = restoreTerminal(in, state) }()
+
+	// Draw on the alternate screen, so the shell's screen comes back after
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+
+	model := newTUIModel(findings)
+	keys := bufio.NewReader(in)
+	for !model.done {
+		width, height, err := terminalSize(out)
+		if err != nil || width <= 0 || height <= 0 {
+			width, height = 80, 24
+		}
+		drawScreen(out, model.render(width, height))
+
+		key, err := readKey(keys)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		model.handleKey(key)
+	}
+	if !model.apply {
+		return nil, nil
+	}
+	return model.selection(), nil
+}
+
+// drawScreen replaces the screen with lines. Raw mode turns off output
+// processing, so lines end in CR LF.
+func drawScreen(w io.Writer, lines []string) {
+	fmt.Fprint(w, "\x1b[H\x1b[2J"+strings.Join(lines, "\r\n"))
+}
+
+// withoutResults returns a copy of s with its options and none of the
+// results of processing, for a second pass over the files.
+func (s Stats) withoutResults() Stats {
+	s.FilesProcessed, s.FilesModified, s.FilesSkipped, s.FilesIgnored, s.FilesNotUTF8 = 0, 0, 0, 0, 0
+	s.RemovedBlocksRemoved, s.RemovedBlocksSkipped = 0, 0
+	s.Backups = nil
+	s.Written = nil
+	s.Reformatted = nil
+	s.Findings = nil
+	s.Warnings = nil
+	s.Errors = nil
+	s.NestedFindings = nil
+	s.IgnoredFindings = nil
+	s.Expiring = nil
+	return s
+}
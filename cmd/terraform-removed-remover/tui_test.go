@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func testTUIModel() *tuiModel {
+	model := newTUIModel([]Finding{
+		{File: "a.tf", Address: "aws_instance.a", Line: 1},
+		{File: "a.tf", Address: "aws_instance.b", Line: 5},
+		{File: "b.tf", Address: "aws_instance.c", Line: 2},
+	})
+	model.readFile = func(path string) ([]byte, error) {
+		if path == "b.tf" {
+			return nil, errors.New("permission denied")
+		}
+		return []byte("removed {\n  from = aws_instance.a\n}\n\nremoved {\n  from = aws_instance.b\n}\n"), nil
+	}
+	return model
+}
+
+func TestTUIModelMarking(t *testing.T) {
+	model := testTUIModel()
+
+	model.handleKey("enter")
+	if model.confirming || model.status == "" {
+		t.Errorf("Expected enter with nothing marked to show a status, but got confirming=%v status=%q", model.confirming, model.status)
+	}
+
+	for _, key := range []string{"down", "space", "down", "down", "x"} {
+		model.handleKey(key)
+	}
+	if expected := []bool{false, true, true}; !slices.Equal(model.marked, expected) {
+		t.Errorf("Expected marks %v, but got %v", expected, model.marked)
+	}
+
+	model.handleKey("enter")
+	model.handleKey("n")
+	if model.done || model.confirming {
+		t.Errorf("Expected declining the confirmation to go back to the list")
+	}
+	model.handleKey("enter")
+	model.handleKey("y")
+	if !model.done || !model.apply {
+		t.Errorf("Expected confirming to apply the marks")
+	}
+
+	selection := model.selection()
+	if expected := []string{"a.tf", "b.tf"}; !slices.Equal(selection.paths(), expected) {
+		t.Errorf("Expected %v, but got %v", expected, selection.paths())
+	}
+	if selection.selects("a.tf", removedBlock{Line: 1}) || !selection.selects("a.tf", removedBlock{Line: 5}) {
+		t.Errorf("Expected only line 5 of a.tf to be selected")
+	}
+}
+
+func TestTUIModelMarkAllAndQuit(t *testing.T) {
+	model := testTUIModel()
+	model.handleKey("a")
+	if model.markedCount() != 3 {
+		t.Errorf("Expected 3 marked blocks, but got %d", model.markedCount())
+	}
+	model.handleKey("n")
+	if model.markedCount() != 0 {
+		t.Errorf("Expected no marked blocks, but got %d", model.markedCount())
+	}
+	model.handleKey("q")
+	if !model.done || model.apply {
+		t.Errorf("Expected q to end without applying")
+	}
+}
+
+func TestTUIModelRender(t *testing.T) {
+	model := testTUIModel()
+	model.handleKey("down")
+	model.handleKey("space")
+
+	lines := model.render(40, 12)
+	if len(lines) != 12 {
+		t.Fatalf("Expected 12 lines, but got %d: %q", len(lines), lines)
+	}
+	for _, line := range lines {
+		if len([]rune(line)) > 40 {
+			t.Errorf("Expected at most 40 columns, but got %q", line)
+		}
+	}
+	expected := []string{
+		"terraform-removed-remover: 3 removed blo",
+		"a.tf",
+		"  [ ] 1: aws_instance.a",
+		"> [x] 5: aws_instance.b",
+		"b.tf",
+	}
+	if !slices.Equal(lines[:5], expected) {
+		t.Errorf("Expected the list %q, but got %q", expected, lines[:5])
+	}
+	if !strings.HasPrefix(lines[6], "    2   ") || lines[9] != "    5 > removed {" {
+		t.Errorf("Expected a preview around line 5, but got %q", lines[6:11])
+	}
+
+	model.handleKey("down")
+	lines = model.render(80, 12)
+	if !strings.HasPrefix(lines[6], "cannot read b.tf") {
+		t.Errorf("Expected the read error in the preview, but got %q", lines[6])
+	}
+}
+
+func TestReadKey(t *testing.T) {
+	keys := bufio.NewReader(strings.NewReader("\x1b[A\x1b[B \r\x03q\x1b[C"))
+	var actual []string
+	for {
+		key, err := readKey(keys)
+		if err != nil {
+			break
+		}
+		actual = append(actual, key)
+	}
+	expected := []string{"up", "down", "space", "enter", "ctrl-c", "q", ""}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("Expected %q, but got %q", expected, actual)
+	}
+}
+
+func TestStatsWithoutResults(t *testing.T) {
+	stats := Stats{
+		Pure:                 true,
+		FilesProcessed:       2,
+		RemovedBlocksRemoved: 1,
+		Findings:             []Finding{{File: "a.tf"}},
+		Warnings:             []string{"warning"},
+	}
+	fresh := stats.withoutResults()
+	if !fresh.Pure {
+		t.Errorf("Expected the options to be kept")
+	}
+	if fresh.FilesProcessed != 0 || fresh.RemovedBlocksRemoved != 0 || fresh.Findings != nil || fresh.Warnings != nil {
+		t.Errorf("Expected no results, but got %+v", fresh)
+	}
+}
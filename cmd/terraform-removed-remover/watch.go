@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// defaultWatchInterval is how long -watch waits, after a file changed, for
+// the changes to settle, so the burst of writes an editor or a git checkout
+// makes is handled once.
+const defaultWatchInterval = 200 * time.Millisecond
+
+// fileStamp is what -watch compares to tell that a file changed.
+type fileStamp struct {
+	size    int64
+	modTime int64
+}
+
+func stampOf(filePath string) (fileStamp, bool) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return fileStamp{}, false
+	}
+	return fileStamp{size: info.Size(), modTime: info.ModTime().UnixNano()}, true
+}
+
+// fileWatcher finds the files that were added or changed. The platform's
+// file notifications, for every directory under the roots, say when to
+// look; discover then lists the files as a normal run would find them, so
+// new files and directories go through the same filters, and their sizes
+// and modification times tell which of them changed.
+type fileWatcher struct {
+	discover            func() ([]string, error)
+	stamps              map[string]fileStamp
+	notify              *fsnotify.Watcher
+	includeDotTerraform bool
+}
+
+// newFileWatcher returns a watcher of the directories of roots that sees
+// the files as they are now as unchanged. It must be closed.
+func newFileWatcher(roots []string, includeDotTerraform bool, discover func() ([]string, error)) (*fileWatcher, error) {
+	notify, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("error watching files: %w", err)
+	}
+	w := &fileWatcher{discover: discover, stamps: map[string]fileStamp{}, notify: notify, includeDotTerraform: includeDotTerraform}
+	for _, root := range roots {
+		info, err := os.Stat(root)
+		if err == nil && !info.IsDir() {
+			// Editors replace a file by renaming another over it, which
+			// only its directory sees
+			err = notify.Add(filepath.Dir(root))
+		} else if err == nil {
+			err = w.addTree(root)
+		}
+		if err != nil {
+			_ = w.close()
+			return nil, fmt.Errorf("error watching %s: %w", root, err)
+		}
+	}
+	if _, err := w.changed(); err != nil {
+		_ = w.close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// addTree watches dir and the directories below it, except .git, which
+// changes with every git command, and .terraform unless it is processed.
+func (w *fileWatcher) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			// Removed while walking
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != dir && (d.Name() == ".git" || d.Name() == ".terraform" && !w.includeDotTerraform) {
+			return filepath.SkipDir
+		}
+		return w.notify.Add(path)
+	})
+}
+
+func (w *fileWatcher) close() error {
+	return w.notify.Close()
+}
+
+// changed returns the files added or changed since the last call.
+func (w *fileWatcher) changed() ([]string, error) {
+	files, err := w.discover()
+	if err != nil {
+		return nil, err
+	}
+	stamps := make(map[string]fileStamp, len(files))
+	var changed []string
+	for _, file := range files {
+		stamp, ok := stampOf(file)
+		if !ok {
+			continue
+		}
+		stamps[file] = stamp
+		if previous, seen := w.stamps[file]; !seen || previous != stamp {
+			changed = append(changed, file)
+		}
+	}
+	w.stamps = stamps
+	return changed, nil
+}
+
+// touch records file as it is now, so that the tool's own write isn't seen
+// as a change.
+func (w *fileWatcher) touch(file string) {
+	if stamp, ok := stampOf(file); ok {
+		w.stamps[file] = stamp
+	}
+}
+
+// watchFiles processes each file w finds changed, once notifications have
+// been quiet for delay, into stats until ctx is done, and writes a line to
+// out for each removed block deleted or, in a dry run, found. processed,
+// when set, is called after each batch of files.
+func watchFiles(ctx context.Context, w *fileWatcher, delay time.Duration, stats *Stats, out io.Writer, logger *slog.Logger, processed func()) {
+	settle := time.NewTimer(delay)
+	settle.Stop()
+	defer settle.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.notify.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := w.addTree(event.Name); err != nil {
+						logger.Warn("Could not watch a new directory", "dir", event.Name, "error", err)
+					}
+				}
+			}
+			settle.Reset(delay)
+			continue
+		case err, ok := <-w.notify.Errors:
+			if !ok {
+				return
+			}
+			// Such as an overflowed event queue: look at everything
+			logger.Warn("File notifications failed", "error", err)
+			settle.Reset(delay)
+			continue
+		case <-settle.C:
+		}
+
+		files, err := w.changed()
+		if err != nil {
+			logger.Warn("Could not look for changed files", "error", err)
+			continue
+		}
+		for _, file := range files {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Debug("Processing changed file", "file", file)
+			found, warned := len(stats.Findings), len(stats.Warnings)
+			if err := processFile(file, stats); err != nil {
+				logger.Error("Error processing file", "file", file, "error", err)
+				stats.Errors = append(stats.Errors, FileError{File: file, Message: err.Error()})
+				continue
+			}
+			w.touch(file)
+			printWatchResults(out, stats.DryRun, stats.Findings[found:], stats.Warnings[warned:])
+		}
+		if processed != nil && len(files) > 0 {
+			processed()
+		}
+	}
+}
+
+// printWatchResults writes what processing one changed file did: the
+// blocks deleted or, in a dry run, found, and the warnings.
+func printWatchResults(out io.Writer, dryRun bool, findings []Finding, warnings []string) {
+	verb := "Removed"
+	if dryRun {
+		verb = "Found"
+	}
+	for _, finding := range findings {
+		fmt.Fprintf(out, "%s %s\n", verb, formatFinding(finding))
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(out, "Warning: %s\n", warning)
+	}
+}
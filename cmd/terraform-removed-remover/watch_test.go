@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileWatcherChanged(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-watch-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	existing := filepath.Join(tempDir, "main.tf")
+	if err := os.WriteFile(existing, []byte("resource \"a\" \"b\" {}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	discover := func() ([]string, error) {
+		discovery, err := discoverFiles(tempDir, DiscoveryOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return discovery.Files, nil
+	}
+
+	watcher, err := newFileWatcher([]string{tempDir}, false, discover)
+	if err != nil {
+		t.Fatalf("newFileWatcher failed: %v", err)
+	}
+	defer func() {
+		_ = watcher.close()
+	}()
+	if changed, err := watcher.changed(); err != nil || len(changed) != 0 {
+		t.Errorf("Expected no changes, but got %v (%v)", changed, err)
+	}
+
+	added := filepath.Join(tempDir, "added.tf")
+	if err := os.WriteFile(added, []byte("removed {\n  from = aws_instance.a\n}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	if err := os.WriteFile(existing, []byte("resource \"a\" \"cc\" {}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	changed, err := watcher.changed()
+	if err != nil {
+		t.Fatalf("changed failed: %v", err)
+	}
+	slices.Sort(changed)
+	if expected := []string{added, existing}; !slices.Equal(changed, expected) {
+		t.Errorf("Expected %v, but got %v", expected, changed)
+	}
+
+	if err := os.WriteFile(added, []byte("\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	watcher.touch(added)
+	if changed, err := watcher.changed(); err != nil || len(changed) != 0 {
+		t.Errorf("Expected a touched file not to be changed, but got %v (%v)", changed, err)
+	}
+}
+
+func TestWatchFiles(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-watch-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	// A directory created after the watch started, watched in turn
+	sub := filepath.Join(tempDir, "sub")
+	testFile := filepath.Join(sub, "main.tf")
+	watcher, err := newFileWatcher([]string{tempDir}, false, func() ([]string, error) {
+		if _, err := os.Stat(testFile); err != nil {
+			return nil, nil
+		}
+		return []string{testFile}, nil
+	})
+	if err != nil {
+		t.Fatalf("newFileWatcher failed: %v", err)
+	}
+	defer func() {
+		_ = watcher.close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	stats := Stats{DryRun: true, Findings: []Finding{{File: "old.tf"}}}
+	batches := 0
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		watchFiles(ctx, watcher, 10*time.Millisecond, &stats, &out, logger, func() {
+			batches++
+			cancel()
+		})
+	}()
+
+	if err := os.Mkdir(sub, 0750); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	// Let the new directory be watched before writing into it
+	time.Sleep(100 * time.Millisecond)
+	if err := os.WriteFile(testFile, []byte("removed {\n  from = aws_instance.a\n}\n"), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	<-done
+
+	expected := "Found " + testFile + ":1: removed block for aws_instance.a\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, but got %q", expected, out.String())
+	}
+	if batches != 1 || len(stats.Findings) != 2 {
+		t.Errorf("Expected one batch adding to the findings so far, but got %d batches and %v", batches, stats.Findings)
+	}
+	data, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	if !strings.Contains(string(data), "removed") {
+		t.Errorf("Expected a dry run to leave the file alone, but got %q", string(data))
+	}
+}
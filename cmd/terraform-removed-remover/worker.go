@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WorkRequest is a Bazel persistent worker request in the JSON protocol.
+// A non-zero RequestID marks a multiplexed request, which may run
+// concurrently with others.
+type WorkRequest struct {
+	Arguments  []string `json:"arguments"`
+	RequestID  int      `json:"requestId"`
+	Cancel     bool     `json:"cancel"`
+	SandboxDir string   `json:"sandboxDir"`
+}
+
+// WorkResponse is the reply to a WorkRequest. Output is shown to the user
+// when the action fails.
+type WorkResponse struct {
+	ExitCode  int    `json:"exitCode"`
+	Output    string `json:"output"`
+	RequestID int    `json:"requestId"`
+}
+
+// runWorker implements Bazel's persistent worker protocol with JSON
+// messages: newline-delimited WorkRequests are read from stdin until it is
+// closed, and a WorkResponse is written to stdout for each. Every request
+// carries the arguments of one invocation, see runWorkRequest. base supplies
+// the options given when the worker was started.
+//
+// Cancellation is not supported, so cancel requests are ignored and every
+// request runs to completion.
+func runWorker(stdin io.Reader, stdout io.Writer, base *Stats) error {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		writeErr error
+	)
+	encoder := json.NewEncoder(stdout)
+	respond := func(response WorkResponse) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := encoder.Encode(response); err != nil && writeErr == nil {
+			writeErr = fmt.Errorf("error writing work response: %w", err)
+		}
+	}
+
+	decoder := json.NewDecoder(stdin)
+	for {
+		var request WorkRequest
+		if err := decoder.Decode(&request); err != nil {
+			wg.Wait()
+			if errors.Is(err, io.EOF) {
+				return writeErr
+			}
+			return fmt.Errorf("error decoding work request: %w", err)
+		}
+		if request.Cancel {
+			continue
+		}
+
+		if request.RequestID == 0 {
+			respond(runWorkRequest(request, base))
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			respond(runWorkRequest(request, base))
+		}()
+	}
+}
+
+// runWorkRequest runs one invocation in-process. The arguments accept a
+// subset of the command line: -dry-run, -check, -normalize-whitespace,
+// -final-newline and -output, followed by the files and directories to
+// process. Arguments of the form @file are replaced by the lines of file,
+// as Bazel passes long argument lists in flag files.
+func runWorkRequest(request WorkRequest, base *Stats) WorkResponse {
+	var output bytes.Buffer
+	response := WorkResponse{RequestID: request.RequestID}
+
+	inSandbox := func(path string) string {
+		if request.SandboxDir == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(request.SandboxDir, path)
+	}
+
+	args, err := expandFlagFiles(request.Arguments, inSandbox)
+	if err != nil {
+		fmt.Fprintf(&output, "Error: %s\n", err)
+		response.ExitCode = 1
+		response.Output = output.String()
+		return response
+	}
+
+	flags := flag.NewFlagSet("worker", flag.ContinueOnError)
+	flags.SetOutput(&output)
+	dryRun := flags.Bool("dry-run", false, "Run without modifying files")
+	check := flags.Bool("check", false, "Report removed blocks without modifying files and fail if any are found")
+	normalize := flags.Bool("normalize-whitespace", false, "Normalize whitespace after removing removed blocks")
+	finalNewline := flags.String("final-newline", "", "End-of-file newline policy: always (one), preserve, or never (none)")
+	outputFormat := flags.String("output", "text", "Output format for the summary: text or json")
+	if err := flags.Parse(args); err != nil {
+		response.ExitCode = 1
+		response.Output = output.String()
+		return response
+	}
+	if *outputFormat != "text" && *outputFormat != "json" {
+		fmt.Fprintf(&output, "Error: unknown -output format %q (expected text or json)\n", *outputFormat)
+		response.ExitCode = 1
+	}
+	finalNewlinePolicy, ok := parseFinalNewline(*finalNewline)
+	if !ok {
+		fmt.Fprintf(&output, "Error: invalid -final-newline %q: must be always (one), preserve, or never (none)\n", *finalNewline)
+		response.ExitCode = 1
+	}
+	if response.ExitCode != 0 {
+		response.Output = output.String()
+		return response
+	}
+
+	stats := Stats{
+		StartTime:           time.Now(),
+		DryRun:              *dryRun || *check,
+		NormalizeWhitespace: *normalize,
+		FinalNewline:        finalNewlinePolicy,
+		MaxFileSize:         base.MaxFileSize,
+		DiscoveryOptions:    base.DiscoveryOptions,
+		Capabilities:        base.Capabilities,
+		Pure:                base.Pure,
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	var files []string
+	for _, path := range paths {
+		path = inSandbox(path)
+		info, err := os.Stat(path)
+		if err != nil {
+			fmt.Fprintf(&output, "Error: %s\n", err)
+			response.ExitCode = 1
+			continue
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		discovery, err := discoverFiles(path, stats.DiscoveryOptions)
+		if err != nil {
+			fmt.Fprintf(&output, "Error finding Terraform files: %s\n", err)
+			response.ExitCode = 1
+			continue
+		}
+		files = append(files, discovery.Files...)
+	}
+
+	for _, file := range files {
+		if err := processFile(file, &stats); err != nil {
+			fmt.Fprintf(&output, "Error processing %s: %s\n", file, err)
+			response.ExitCode = max(response.ExitCode, fileErrorStatus(err))
+		}
+	}
+	stats.EndTime = time.Now()
+
+	if *outputFormat == "json" {
+		if err := writeJSONReport(&output, &stats); err != nil {
+			fmt.Fprintf(&output, "Error: %s\n", err)
+			response.ExitCode = 1
+		}
+	} else {
+		printSummary(&output, &stats)
+	}
+
+	if *check && len(stats.Findings) > 0 {
+		fmt.Fprintf(&output, "\nCheck failed: %d removed blocks found\n", len(stats.Findings))
+		printFindings(&output, stats.Findings)
+		response.ExitCode = 1
+	}
+
+	response.Output = output.String()
+	return response
+}
+
+// expandFlagFiles replaces every @file argument with the lines of file,
+// located through resolve.
+func expandFlagFiles(args []string, resolve func(string) string) ([]string, error) {
+	var expanded []string
+	for _, arg := range args {
+		name, ok := strings.CutPrefix(arg, "@")
+		if !ok {
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		file, err := os.Open(resolve(name))
+		if err != nil {
+			return nil, fmt.Errorf("error reading flag file: %w", err)
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if line := scanner.Text(); line != "" {
+				expanded = append(expanded, line)
+			}
+		}
+		err = scanner.Err()
+		_ = file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error reading flag file: %w", err)
+		}
+	}
+	return expanded, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRunWorker(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "terraform-worker-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			_ = removeErr // Ignore cleanup errors in tests
+		}
+	}()
+
+	content := "locals {}\n\nremoved {\n  from = aws_instance.old\n}\n"
+	for _, name := range []string{"a", "b"} {
+		if err := os.Mkdir(filepath.Join(tempDir, name), 0750); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(tempDir, name, "main.tf"), []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write test file: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "args"), []byte("-check\na\n"), 0600); err != nil {
+		t.Fatalf("Failed to write flag file: %v", err)
+	}
+
+	var input bytes.Buffer
+	encoder := json.NewEncoder(&input)
+	for _, request := range []WorkRequest{
+		{Arguments: []string{"@args"}, RequestID: 1, SandboxDir: tempDir},
+		{Arguments: []string{"b"}, RequestID: 2, SandboxDir: tempDir},
+		{RequestID: 2, Cancel: true},
+		{Arguments: []string{"-output", "xml"}, RequestID: 3},
+	} {
+		if err := encoder.Encode(request); err != nil {
+			t.Fatalf("Failed to encode request: %v", err)
+		}
+	}
+
+	var output bytes.Buffer
+	if err := runWorker(&input, &output, &Stats{}); err != nil {
+		t.Fatalf("runWorker failed: %v", err)
+	}
+
+	var responses []WorkResponse
+	decoder := json.NewDecoder(&output)
+	for decoder.More() {
+		var response WorkResponse
+		if err := decoder.Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		responses = append(responses, response)
+	}
+	sort.Slice(responses, func(i, j int) bool { return responses[i].RequestID < responses[j].RequestID })
+
+	if len(responses) != 3 {
+		t.Fatalf("Expected one response per request, but got %+v", responses)
+	}
+	if responses[0].ExitCode != 1 || !strings.Contains(responses[0].Output, "Check failed: 1 removed blocks found") {
+		t.Errorf("Expected -check from the flag file to fail, but got %+v", responses[0])
+	}
+	if responses[1].ExitCode != 0 || !strings.Contains(responses[1].Output, "Removed blocks removed: 1") {
+		t.Errorf("Expected the second request to remove the block, but got %+v", responses[1])
+	}
+	if responses[2].ExitCode != 1 || !strings.Contains(responses[2].Output, "unknown -output format") {
+		t.Errorf("Expected an invalid -output to fail, but got %+v", responses[2])
+	}
+
+	checked, err := os.ReadFile(filepath.Join(tempDir, "a", "main.tf"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	if string(checked) != content {
+		t.Errorf("Expected -check to leave the file untouched, but got:\n%s", checked)
+	}
+	cleaned, err := os.ReadFile(filepath.Join(tempDir, "b", "main.tf"))
+	if err != nil {
+		t.Fatalf("Failed to read test file: %v", err)
+	}
+	if strings.Contains(string(cleaned), "removed") {
+		t.Errorf("Expected the removed block to be deleted, but got:\n%s", cleaned)
+	}
+}
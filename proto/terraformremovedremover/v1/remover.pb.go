@@ -0,0 +1,570 @@
+// The gRPC counterpart of the serve subcommand's HTTP API. Each call
+// processes files with the options the server was started with, in the
+// schema of -output json.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: terraformremovedremover/v1/remover.proto
+
+package removedremoverv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ProcessRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// filename names the file in the report and selects JSON syntax for
+	// .tf.json names. It defaults to main.tf.
+	Filename      string `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	Content       []byte `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessRequest) Reset() {
+	*x = ProcessRequest{}
+	mi := &file_terraformremovedremover_v1_remover_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessRequest) ProtoMessage() {}
+
+func (x *ProcessRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terraformremovedremover_v1_remover_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessRequest.ProtoReflect.Descriptor instead.
+func (*ProcessRequest) Descriptor() ([]byte, []int) {
+	return file_terraformremovedremover_v1_remover_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ProcessRequest) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *ProcessRequest) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+type ProcessResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Content       []byte                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	Report        *Report                `protobuf:"bytes,2,opt,name=report,proto3" json:"report,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessResponse) Reset() {
+	*x = ProcessResponse{}
+	mi := &file_terraformremovedremover_v1_remover_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessResponse) ProtoMessage() {}
+
+func (x *ProcessResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_terraformremovedremover_v1_remover_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessResponse.ProtoReflect.Descriptor instead.
+func (*ProcessResponse) Descriptor() ([]byte, []int) {
+	return file_terraformremovedremover_v1_remover_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ProcessResponse) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *ProcessResponse) GetReport() *Report {
+	if x != nil {
+		return x.Report
+	}
+	return nil
+}
+
+// FileChunk is part of a streamed file. A chunk with a filename starts a new
+// file; the chunks after it, up to the next filename, hold its content.
+type FileChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filename      string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	Content       []byte                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FileChunk) Reset() {
+	*x = FileChunk{}
+	mi := &file_terraformremovedremover_v1_remover_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FileChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileChunk) ProtoMessage() {}
+
+func (x *FileChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_terraformremovedremover_v1_remover_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileChunk.ProtoReflect.Descriptor instead.
+func (*FileChunk) Descriptor() ([]byte, []int) {
+	return file_terraformremovedremover_v1_remover_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *FileChunk) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *FileChunk) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+// ProcessChunk is part of a streamed result. The report is only set on the
+// last chunk.
+type ProcessChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Content       []byte                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	Report        *Report                `protobuf:"bytes,2,opt,name=report,proto3" json:"report,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessChunk) Reset() {
+	*x = ProcessChunk{}
+	mi := &file_terraformremovedremover_v1_remover_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessChunk) ProtoMessage() {}
+
+func (x *ProcessChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_terraformremovedremover_v1_remover_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessChunk.ProtoReflect.Descriptor instead.
+func (*ProcessChunk) Descriptor() ([]byte, []int) {
+	return file_terraformremovedremover_v1_remover_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ProcessChunk) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *ProcessChunk) GetReport() *Report {
+	if x != nil {
+		return x.Report
+	}
+	return nil
+}
+
+type CheckResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Clean         bool                   `protobuf:"varint,1,opt,name=clean,proto3" json:"clean,omitempty"`
+	Blocks        []*RemovedBlock        `protobuf:"bytes,2,rep,name=blocks,proto3" json:"blocks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckResponse) Reset() {
+	*x = CheckResponse{}
+	mi := &file_terraformremovedremover_v1_remover_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckResponse) ProtoMessage() {}
+
+func (x *CheckResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_terraformremovedremover_v1_remover_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckResponse.ProtoReflect.Descriptor instead.
+func (*CheckResponse) Descriptor() ([]byte, []int) {
+	return file_terraformremovedremover_v1_remover_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CheckResponse) GetClean() bool {
+	if x != nil {
+		return x.Clean
+	}
+	return false
+}
+
+func (x *CheckResponse) GetBlocks() []*RemovedBlock {
+	if x != nil {
+		return x.Blocks
+	}
+	return nil
+}
+
+// Report mirrors the -output json report.
+type Report struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	SchemaVersion        int32                  `protobuf:"varint,1,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`
+	ToolVersion          string                 `protobuf:"bytes,2,opt,name=tool_version,json=toolVersion,proto3" json:"tool_version,omitempty"`
+	FilesProcessed       int32                  `protobuf:"varint,3,opt,name=files_processed,json=filesProcessed,proto3" json:"files_processed,omitempty"`
+	FilesModified        int32                  `protobuf:"varint,4,opt,name=files_modified,json=filesModified,proto3" json:"files_modified,omitempty"`
+	RemovedBlocksRemoved int32                  `protobuf:"varint,5,opt,name=removed_blocks_removed,json=removedBlocksRemoved,proto3" json:"removed_blocks_removed,omitempty"`
+	RemovedBlocksSkipped int32                  `protobuf:"varint,6,opt,name=removed_blocks_skipped,json=removedBlocksSkipped,proto3" json:"removed_blocks_skipped,omitempty"`
+	Blocks               []*RemovedBlock        `protobuf:"bytes,7,rep,name=blocks,proto3" json:"blocks,omitempty"`
+	Warnings             []string               `protobuf:"bytes,8,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *Report) Reset() {
+	*x = Report{}
+	mi := &file_terraformremovedremover_v1_remover_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Report) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Report) ProtoMessage() {}
+
+func (x *Report) ProtoReflect() protoreflect.Message {
+	mi := &file_terraformremovedremover_v1_remover_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Report.ProtoReflect.Descriptor instead.
+func (*Report) Descriptor() ([]byte, []int) {
+	return file_terraformremovedremover_v1_remover_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Report) GetSchemaVersion() int32 {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return 0
+}
+
+func (x *Report) GetToolVersion() string {
+	if x != nil {
+		return x.ToolVersion
+	}
+	return ""
+}
+
+func (x *Report) GetFilesProcessed() int32 {
+	if x != nil {
+		return x.FilesProcessed
+	}
+	return 0
+}
+
+func (x *Report) GetFilesModified() int32 {
+	if x != nil {
+		return x.FilesModified
+	}
+	return 0
+}
+
+func (x *Report) GetRemovedBlocksRemoved() int32 {
+	if x != nil {
+		return x.RemovedBlocksRemoved
+	}
+	return 0
+}
+
+func (x *Report) GetRemovedBlocksSkipped() int32 {
+	if x != nil {
+		return x.RemovedBlocksSkipped
+	}
+	return 0
+}
+
+func (x *Report) GetBlocks() []*RemovedBlock {
+	if x != nil {
+		return x.Blocks
+	}
+	return nil
+}
+
+func (x *Report) GetWarnings() []string {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+type RemovedBlock struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	File    string                 `protobuf:"bytes,1,opt,name=file,proto3" json:"file,omitempty"`
+	Line    int32                  `protobuf:"varint,2,opt,name=line,proto3" json:"line,omitempty"`
+	Address string                 `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	// kind is resource, data, module, provider, or unknown, as in the JSON
+	// report.
+	Kind          string `protobuf:"bytes,4,opt,name=kind,proto3" json:"kind,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemovedBlock) Reset() {
+	*x = RemovedBlock{}
+	mi := &file_terraformremovedremover_v1_remover_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemovedBlock) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemovedBlock) ProtoMessage() {}
+
+func (x *RemovedBlock) ProtoReflect() protoreflect.Message {
+	mi := &file_terraformremovedremover_v1_remover_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemovedBlock.ProtoReflect.Descriptor instead.
+func (*RemovedBlock) Descriptor() ([]byte, []int) {
+	return file_terraformremovedremover_v1_remover_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RemovedBlock) GetFile() string {
+	if x != nil {
+		return x.File
+	}
+	return ""
+}
+
+func (x *RemovedBlock) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *RemovedBlock) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *RemovedBlock) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+var File_terraformremovedremover_v1_remover_proto protoreflect.FileDescriptor
+
+const file_terraformremovedremover_v1_remover_proto_rawDesc = "" +
+	"\n" +
+	"(terraformremovedremover/v1/remover.proto\x12\x1aterraformremovedremover.v1\"F\n" +
+	"\x0eProcessRequest\x12\x1a\n" +
+	"\bfilename\x18\x01 \x01(\tR\bfilename\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\fR\acontent\"g\n" +
+	"\x0fProcessResponse\x12\x18\n" +
+	"\acontent\x18\x01 \x01(\fR\acontent\x12:\n" +
+	"\x06report\x18\x02 \x01(\v2\".terraformremovedremover.v1.ReportR\x06report\"A\n" +
+	"\tFileChunk\x12\x1a\n" +
+	"\bfilename\x18\x01 \x01(\tR\bfilename\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\fR\acontent\"d\n" +
+	"\fProcessChunk\x12\x18\n" +
+	"\acontent\x18\x01 \x01(\fR\acontent\x12:\n" +
+	"\x06report\x18\x02 \x01(\v2\".terraformremovedremover.v1.ReportR\x06report\"g\n" +
+	"\rCheckResponse\x12\x14\n" +
+	"\x05clean\x18\x01 \x01(\bR\x05clean\x12@\n" +
+	"\x06blocks\x18\x02 \x03(\v2(.terraformremovedremover.v1.RemovedBlockR\x06blocks\"\xec\x02\n" +
+	"\x06Report\x12%\n" +
+	"\x0eschema_version\x18\x01 \x01(\x05R\rschemaVersion\x12!\n" +
+	"\ftool_version\x18\x02 \x01(\tR\vtoolVersion\x12'\n" +
+	"\x0ffiles_processed\x18\x03 \x01(\x05R\x0efilesProcessed\x12%\n" +
+	"\x0efiles_modified\x18\x04 \x01(\x05R\rfilesModified\x124\n" +
+	"\x16removed_blocks_removed\x18\x05 \x01(\x05R\x14removedBlocksRemoved\x124\n" +
+	"\x16removed_blocks_skipped\x18\x06 \x01(\x05R\x14removedBlocksSkipped\x12@\n" +
+	"\x06blocks\x18\a \x03(\v2(.terraformremovedremover.v1.RemovedBlockR\x06blocks\x12\x1a\n" +
+	"\bwarnings\x18\b \x03(\tR\bwarnings\"d\n" +
+	"\fRemovedBlock\x12\x12\n" +
+	"\x04file\x18\x01 \x01(\tR\x04file\x12\x12\n" +
+	"\x04line\x18\x02 \x01(\x05R\x04line\x12\x18\n" +
+	"\aaddress\x18\x03 \x01(\tR\aaddress\x12\x12\n" +
+	"\x04kind\x18\x04 \x01(\tR\x04kind2\x9a\x03\n" +
+	"\x0eRemoverService\x12b\n" +
+	"\aProcess\x12*.terraformremovedremover.v1.ProcessRequest\x1a+.terraformremovedremover.v1.ProcessResponse\x12d\n" +
+	"\rProcessStream\x12%.terraformremovedremover.v1.FileChunk\x1a(.terraformremovedremover.v1.ProcessChunk(\x010\x01\x12a\n" +
+	"\n" +
+	"ListBlocks\x12%.terraformremovedremover.v1.FileChunk\x1a(.terraformremovedremover.v1.RemovedBlock(\x010\x01\x12[\n" +
+	"\x05Check\x12%.terraformremovedremover.v1.FileChunk\x1a).terraformremovedremover.v1.CheckResponse(\x01B`Z^github.com/mkusaka/terraform-removed-remover/proto/terraformremovedremover/v1;removedremoverv1b\x06proto3"
+
+var (
+	file_terraformremovedremover_v1_remover_proto_rawDescOnce sync.Once
+	file_terraformremovedremover_v1_remover_proto_rawDescData []byte
+)
+
+func file_terraformremovedremover_v1_remover_proto_rawDescGZIP() []byte {
+	file_terraformremovedremover_v1_remover_proto_rawDescOnce.Do(func() {
+		file_terraformremovedremover_v1_remover_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_terraformremovedremover_v1_remover_proto_rawDesc), len(file_terraformremovedremover_v1_remover_proto_rawDesc)))
+	})
+	return file_terraformremovedremover_v1_remover_proto_rawDescData
+}
+
+var file_terraformremovedremover_v1_remover_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_terraformremovedremover_v1_remover_proto_goTypes = []any{
+	(*ProcessRequest)(nil),  // 0: terraformremovedremover.v1.ProcessRequest
+	(*ProcessResponse)(nil), // 1: terraformremovedremover.v1.ProcessResponse
+	(*FileChunk)(nil),       // 2: terraformremovedremover.v1.FileChunk
+	(*ProcessChunk)(nil),    // 3: terraformremovedremover.v1.ProcessChunk
+	(*CheckResponse)(nil),   // 4: terraformremovedremover.v1.CheckResponse
+	(*Report)(nil),          // 5: terraformremovedremover.v1.Report
+	(*RemovedBlock)(nil),    // 6: terraformremovedremover.v1.RemovedBlock
+}
+var file_terraformremovedremover_v1_remover_proto_depIdxs = []int32{
+	5, // 0: terraformremovedremover.v1.ProcessResponse.report:type_name -> terraformremovedremover.v1.Report
+	5, // 1: terraformremovedremover.v1.ProcessChunk.report:type_name -> terraformremovedremover.v1.Report
+	6, // 2: terraformremovedremover.v1.CheckResponse.blocks:type_name -> terraformremovedremover.v1.RemovedBlock
+	6, // 3: terraformremovedremover.v1.Report.blocks:type_name -> terraformremovedremover.v1.RemovedBlock
+	0, // 4: terraformremovedremover.v1.RemoverService.Process:input_type -> terraformremovedremover.v1.ProcessRequest
+	2, // 5: terraformremovedremover.v1.RemoverService.ProcessStream:input_type -> terraformremovedremover.v1.FileChunk
+	2, // 6: terraformremovedremover.v1.RemoverService.ListBlocks:input_type -> terraformremovedremover.v1.FileChunk
+	2, // 7: terraformremovedremover.v1.RemoverService.Check:input_type -> terraformremovedremover.v1.FileChunk
+	1, // 8: terraformremovedremover.v1.RemoverService.Process:output_type -> terraformremovedremover.v1.ProcessResponse
+	3, // 9: terraformremovedremover.v1.RemoverService.ProcessStream:output_type -> terraformremovedremover.v1.ProcessChunk
+	6, // 10: terraformremovedremover.v1.RemoverService.ListBlocks:output_type -> terraformremovedremover.v1.RemovedBlock
+	4, // 11: terraformremovedremover.v1.RemoverService.Check:output_type -> terraformremovedremover.v1.CheckResponse
+	8, // [8:12] is the sub-list for method output_type
+	4, // [4:8] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_terraformremovedremover_v1_remover_proto_init() }
+func file_terraformremovedremover_v1_remover_proto_init() {
+	if File_terraformremovedremover_v1_remover_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_terraformremovedremover_v1_remover_proto_rawDesc), len(file_terraformremovedremover_v1_remover_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_terraformremovedremover_v1_remover_proto_goTypes,
+		DependencyIndexes: file_terraformremovedremover_v1_remover_proto_depIdxs,
+		MessageInfos:      file_terraformremovedremover_v1_remover_proto_msgTypes,
+	}.Build()
+	File_terraformremovedremover_v1_remover_proto = out.File
+	file_terraformremovedremover_v1_remover_proto_goTypes = nil
+	file_terraformremovedremover_v1_remover_proto_depIdxs = nil
+}
@@ -0,0 +1,239 @@
+// The gRPC counterpart of the serve subcommand's HTTP API. Each call
+// processes files with the options the server was started with, in the
+// schema of -output json.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: terraformremovedremover/v1/remover.proto
+
+package removedremoverv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	RemoverService_Process_FullMethodName       = "/terraformremovedremover.v1.RemoverService/Process"
+	RemoverService_ProcessStream_FullMethodName = "/terraformremovedremover.v1.RemoverService/ProcessStream"
+	RemoverService_ListBlocks_FullMethodName    = "/terraformremovedremover.v1.RemoverService/ListBlocks"
+	RemoverService_Check_FullMethodName         = "/terraformremovedremover.v1.RemoverService/Check"
+)
+
+// RemoverServiceClient is the client API for RemoverService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RemoverServiceClient interface {
+	// Process deletes the removed blocks from a file and returns the result,
+	// like POST /process.
+	Process(ctx context.Context, in *ProcessRequest, opts ...grpc.CallOption) (*ProcessResponse, error)
+	// ProcessStream is Process for files too large for one message: the file
+	// is sent in chunks, the first naming it, and the result comes back in
+	// chunks, the last carrying the report.
+	ProcessStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[FileChunk, ProcessChunk], error)
+	// ListBlocks reports each removed block of the files sent, without
+	// changing them, as it is found.
+	ListBlocks(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[FileChunk, RemovedBlock], error)
+	// Check reports whether the files sent are free of removed blocks, like
+	// -check.
+	Check(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[FileChunk, CheckResponse], error)
+}
+
+type removerServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRemoverServiceClient(cc grpc.ClientConnInterface) RemoverServiceClient {
+	return &removerServiceClient{cc}
+}
+
+func (c *removerServiceClient) Process(ctx context.Context, in *ProcessRequest, opts ...grpc.CallOption) (*ProcessResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProcessResponse)
+	err := c.cc.Invoke(ctx, RemoverService_Process_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *removerServiceClient) ProcessStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[FileChunk, ProcessChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RemoverService_ServiceDesc.Streams[0], RemoverService_ProcessStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[FileChunk, ProcessChunk]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RemoverService_ProcessStreamClient = grpc.BidiStreamingClient[FileChunk, ProcessChunk]
+
+func (c *removerServiceClient) ListBlocks(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[FileChunk, RemovedBlock], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RemoverService_ServiceDesc.Streams[1], RemoverService_ListBlocks_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[FileChunk, RemovedBlock]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RemoverService_ListBlocksClient = grpc.BidiStreamingClient[FileChunk, RemovedBlock]
+
+func (c *removerServiceClient) Check(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[FileChunk, CheckResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RemoverService_ServiceDesc.Streams[2], RemoverService_Check_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[FileChunk, CheckResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RemoverService_CheckClient = grpc.ClientStreamingClient[FileChunk, CheckResponse]
+
+// RemoverServiceServer is the server API for RemoverService service.
+// All implementations must embed UnimplementedRemoverServiceServer
+// for forward compatibility.
+type RemoverServiceServer interface {
+	// Process deletes the removed blocks from a file and returns the result,
+	// like POST /process.
+	Process(context.Context, *ProcessRequest) (*ProcessResponse, error)
+	// ProcessStream is Process for files too large for one message: the file
+	// is sent in chunks, the first naming it, and the result comes back in
+	// chunks, the last carrying the report.
+	ProcessStream(grpc.BidiStreamingServer[FileChunk, ProcessChunk]) error
+	// ListBlocks reports each removed block of the files sent, without
+	// changing them, as it is found.
+	ListBlocks(grpc.BidiStreamingServer[FileChunk, RemovedBlock]) error
+	// Check reports whether the files sent are free of removed blocks, like
+	// -check.
+	Check(grpc.ClientStreamingServer[FileChunk, CheckResponse]) error
+	mustEmbedUnimplementedRemoverServiceServer()
+}
+
+// UnimplementedRemoverServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRemoverServiceServer struct{}
+
+func (UnimplementedRemoverServiceServer) Process(context.Context, *ProcessRequest) (*ProcessResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Process not implemented")
+}
+func (UnimplementedRemoverServiceServer) ProcessStream(grpc.BidiStreamingServer[FileChunk, ProcessChunk]) error {
+	return status.Error(codes.Unimplemented, "method ProcessStream not implemented")
+}
+func (UnimplementedRemoverServiceServer) ListBlocks(grpc.BidiStreamingServer[FileChunk, RemovedBlock]) error {
+	return status.Error(codes.Unimplemented, "method ListBlocks not implemented")
+}
+func (UnimplementedRemoverServiceServer) Check(grpc.ClientStreamingServer[FileChunk, CheckResponse]) error {
+	return status.Error(codes.Unimplemented, "method Check not implemented")
+}
+func (UnimplementedRemoverServiceServer) mustEmbedUnimplementedRemoverServiceServer() {}
+func (UnimplementedRemoverServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeRemoverServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RemoverServiceServer will
+// result in compilation errors.
+type UnsafeRemoverServiceServer interface {
+	mustEmbedUnimplementedRemoverServiceServer()
+}
+
+func RegisterRemoverServiceServer(s grpc.ServiceRegistrar, srv RemoverServiceServer) {
+	// If the following call panics, it indicates UnimplementedRemoverServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RemoverService_ServiceDesc, srv)
+}
+
+func _RemoverService_Process_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProcessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RemoverServiceServer).Process(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RemoverService_Process_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RemoverServiceServer).Process(ctx, req.(*ProcessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RemoverService_ProcessStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(RemoverServiceServer).ProcessStream(&grpc.GenericServerStream[FileChunk, ProcessChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RemoverService_ProcessStreamServer = grpc.BidiStreamingServer[FileChunk, ProcessChunk]
+
+func _RemoverService_ListBlocks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(RemoverServiceServer).ListBlocks(&grpc.GenericServerStream[FileChunk, RemovedBlock]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RemoverService_ListBlocksServer = grpc.BidiStreamingServer[FileChunk, RemovedBlock]
+
+func _RemoverService_Check_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(RemoverServiceServer).Check(&grpc.GenericServerStream[FileChunk, CheckResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RemoverService_CheckServer = grpc.ClientStreamingServer[FileChunk, CheckResponse]
+
+// RemoverService_ServiceDesc is the grpc.ServiceDesc for RemoverService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RemoverService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "terraformremovedremover.v1.RemoverService",
+	HandlerType: (*RemoverServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Process",
+			Handler:    _RemoverService_Process_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ProcessStream",
+			Handler:       _RemoverService_ProcessStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ListBlocks",
+			Handler:       _RemoverService_ListBlocks_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Check",
+			Handler:       _RemoverService_Check_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "terraformremovedremover/v1/remover.proto",
+}